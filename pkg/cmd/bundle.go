@@ -0,0 +1,275 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/controllers"
+	"github.com/DopplerHQ/cli/pkg/http"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Manage offline secrets bundles",
+	Long: `Manage offline secrets bundles, which allow secrets to be used in environments without access to Doppler.
+
+A bundle is encrypted to one or more age public keys (recipients) and can only be decrypted using one of
+the corresponding identities. Unlike fallback files, bundles aren't tied to a token or to the user's
+config directory. Use 'doppler run --bundle' to run a command using a bundle's secrets.`,
+	Args: cobra.NoArgs,
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a bundle containing a config's secrets",
+	Example: `doppler bundle create --project backend --config prd --out bundle.enc --recipient age1...
+doppler bundle create --out bundle.enc --recipients-file recipients.txt --expires-in 720h`,
+	Args: cobra.NoArgs,
+	Run:  bundleCreate,
+}
+
+var bundleInspectCmd = &cobra.Command{
+	Use:   "inspect [bundle]",
+	Short: "Display a bundle's metadata",
+	Long: `Display a bundle's metadata. If an identity is specified, the bundle is also decrypted
+and the names of its secrets are displayed.`,
+	Args: cobra.ExactArgs(1),
+	Run:  bundleInspect,
+}
+
+var bundleKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate an identity for decrypting bundles",
+	Long: `Generate an age identity for decrypting bundles. The identity is written in the standard age identity
+file format, and its public key can be used as a bundle recipient.`,
+	Example: `doppler bundle keygen --out key.txt`,
+	Args:    cobra.NoArgs,
+	Run:     bundleKeygen,
+}
+
+func bundleCreate(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	localConfig := configuration.LocalConfig(cmd)
+	outPath := cmd.Flag("out").Value.String()
+	expiresIn := utils.GetDurationFlag(cmd, "expires-in")
+
+	utils.RequireValue("token", localConfig.Token.Value)
+	if outPath == "" {
+		utils.ErrExit(errors.New("you must specify an output file with --out"), utils.ExitCodeValidation)
+	}
+
+	recipients := getBundleRecipients(cmd)
+	if len(recipients) == 0 {
+		utils.ErrExit(errors.New("you must specify at least one recipient with --recipient or --recipients-file"), utils.ExitCodeValidation)
+	}
+
+	_, _, response, httpErr := http.DownloadSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, models.JSON, nil, "", 0, nil)
+	if !httpErr.IsNil() {
+		utils.HandleError(httpErr.Unwrap(), httpErr.Message)
+	}
+
+	secrets, err := controllers.ParseSecrets(response)
+	if err != nil {
+		utils.HandleError(err, "Unable to parse API response")
+	}
+
+	bundle := models.Bundle{
+		BundleMetadata: models.BundleMetadata{
+			Project:   localConfig.EnclaveProject.Value,
+			Config:    localConfig.EnclaveConfig.Value,
+			CreatedAt: time.Now().UTC(),
+		},
+		Secrets: secrets,
+	}
+	// service tokens don't require a project and config to be specified
+	if bundle.Project == "" {
+		bundle.Project = secrets["DOPPLER_PROJECT"]
+	}
+	if bundle.Config == "" {
+		bundle.Config = secrets["DOPPLER_CONFIG"]
+	}
+	if expiresIn > 0 {
+		expiresAt := bundle.CreatedAt.Add(expiresIn)
+		bundle.ExpiresAt = &expiresAt
+	}
+
+	data, e := controllers.EncryptBundle(bundle, recipients)
+	if !e.IsNil() {
+		utils.HandleError(e.Unwrap(), e.Message)
+	}
+
+	if err := utils.WriteFile(outPath, data, utils.RestrictedFilePerms()); err != nil {
+		utils.HandleError(err, "Unable to write bundle")
+	}
+
+	if !utils.Silent {
+		metadata, _, e := controllers.ReadBundleMetadata(data)
+		if !e.IsNil() {
+			utils.HandleError(e.Unwrap(), e.Message)
+		}
+		printer.Bundle(models.BundleInfo{BundleMetadata: metadata, Path: outPath}, jsonFlag)
+	}
+}
+
+func bundleInspect(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	path := args[0]
+	identityPath := cmd.Flag("identity").Value.String()
+
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		utils.HandleError(err, "Unable to read bundle")
+	}
+
+	metadata, _, e := controllers.ReadBundleMetadata(data)
+	if !e.IsNil() {
+		utils.HandleError(e.Unwrap(), e.Message)
+	}
+
+	info := models.BundleInfo{BundleMetadata: metadata, Path: path, Expired: metadata.IsExpired(time.Now())}
+	if identityPath != "" {
+		bundle := decryptBundle(data, identityPath)
+		info.Verified = true
+		for name := range bundle.Secrets {
+			info.SecretNames = append(info.SecretNames, name)
+		}
+		sort.Strings(info.SecretNames)
+	}
+
+	printer.Bundle(info, jsonFlag)
+}
+
+func bundleKeygen(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	outPath := cmd.Flag("out").Value.String()
+
+	identity, publicKey, e := controllers.GenerateBundleIdentity()
+	if !e.IsNil() {
+		utils.HandleError(e.Unwrap(), e.Message)
+	}
+
+	contents := fmt.Sprintf("# created: %s\n# public key: %s\n%s\n", time.Now().Format(time.RFC3339), publicKey, identity)
+	if outPath == "" {
+		if jsonFlag {
+			printer.JSON(map[string]string{"identity": identity, "public_key": publicKey})
+			return
+		}
+		utils.Print(strings.TrimSuffix(contents, "\n"))
+		return
+	}
+
+	if utils.Exists(outPath) {
+		utils.HandleError(fmt.Errorf("%s already exists", outPath), "Refusing to overwrite existing identity file")
+	}
+	if err := utils.WriteFile(outPath, []byte(contents), 0600); err != nil {
+		utils.HandleError(err, "Unable to write identity file")
+	}
+
+	if jsonFlag {
+		printer.JSON(map[string]string{"path": outPath, "public_key": publicKey})
+		return
+	}
+	utils.Print(fmt.Sprintf("Public key: %s", publicKey))
+}
+
+// getBundleRecipients the recipients specified via --recipient and --recipients-file
+func getBundleRecipients(cmd *cobra.Command) []string {
+	recipients, err := cmd.Flags().GetStringSlice("recipient")
+	if err != nil {
+		utils.HandleError(err)
+	}
+
+	recipientsFile := cmd.Flag("recipients-file").Value.String()
+	if recipientsFile != "" {
+		contents, err := os.ReadFile(recipientsFile) // #nosec G304
+		if err != nil {
+			utils.HandleError(err, "Unable to read recipients file")
+		}
+		for _, line := range strings.Split(string(contents), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				recipients = append(recipients, line)
+			}
+		}
+	}
+
+	return recipients
+}
+
+// decryptBundle decrypts the bundle using the identity file
+func decryptBundle(data []byte, identityPath string) models.Bundle {
+	identities, err := os.ReadFile(identityPath) // #nosec G304
+	if err != nil {
+		utils.HandleError(err, "Unable to read identity file")
+	}
+
+	bundle, e := controllers.DecryptBundle(data, identities)
+	if !e.IsNil() {
+		utils.HandleError(e.Unwrap(), e.Message)
+	}
+	return bundle
+}
+
+// readBundle reads and decrypts the bundle, failing if it has expired
+func readBundle(path string, identityPath string) models.Bundle {
+	utils.LogDebug(fmt.Sprintf("Reading bundle %s", path))
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		utils.HandleError(err, "Unable to read bundle")
+	}
+
+	bundle := decryptBundle(data, identityPath)
+	if bundle.IsExpired(time.Now()) {
+		utils.HandleError(fmt.Errorf("the bundle expired at %s", bundle.ExpiresAt.Format(time.RFC3339)), "Unable to use bundle")
+	}
+
+	return bundle
+}
+
+func init() {
+	bundleCreateCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := bundleCreateCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	bundleCreateCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := bundleCreateCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	bundleCreateCmd.Flags().String("out", "", "path to write the bundle to")
+	bundleCreateCmd.Flags().StringSlice("recipient", []string{}, "age public key to encrypt the bundle to. may be specified multiple times")
+	bundleCreateCmd.Flags().String("recipients-file", "", "path to a file containing age public keys to encrypt the bundle to, one per line")
+	bundleCreateCmd.Flags().Duration("expires-in", 0, "duration after which the bundle can no longer be used (e.g. '720h'). by default, bundles don't expire")
+	bundleCmd.AddCommand(bundleCreateCmd)
+
+	bundleInspectCmd.Flags().String("identity", "", "path to an age identity file used to decrypt and verify the bundle")
+	bundleCmd.AddCommand(bundleInspectCmd)
+
+	bundleKeygenCmd.Flags().String("out", "", "path to write the identity to. by default, the identity is printed to stdout")
+	bundleCmd.AddCommand(bundleKeygenCmd)
+
+	rootCmd.AddCommand(bundleCmd)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/spf13/cobra"
+)
+
+// tableColumns the columns available to each command's table output, used to complete --columns and --sort-by
+var tableColumns = map[*cobra.Command][]string{}
+
+func registerTableColumns(columns []string, cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		tableColumns[cmd] = columns
+	}
+}
+
+func columnsValidArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	columns, ok := tableColumns[cmd]
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// complete the last of the comma-separated columns
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i != -1 {
+		prefix = toComplete[:i+1]
+	}
+	var completions []string
+	for _, name := range printer.ColumnNames(columns) {
+		completions = append(completions, prefix+name)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+func sortByValidArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	columns, ok := tableColumns[cmd]
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return printer.ColumnNames(columns), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	registerTableColumns(printer.ProjectColumns, projectsCmd, projectsGetCmd)
+	registerTableColumns(printer.EnvironmentColumns, environmentsCmd, environmentsGetCmd)
+	registerTableColumns(printer.ConfigColumns, configsCmd)
+	registerTableColumns(printer.SecretColumns, secretsCmd, secretsGetCmd, secretsSetCmd, secretsUploadCmd, secretsDeleteCmd)
+	registerTableColumns(printer.ConfigServiceTokenColumns, configsTokensCmd, configsTokensGetCmd)
+	registerTableColumns(printer.FallbackFileColumns, fallbackListCmd)
+	registerTableColumns(printer.ProfileColumns, profileListCmd)
+}
@@ -49,7 +49,7 @@ var completionCmd = &cobra.Command{
 				utils.HandleError(err, "Unable to generate fish completions.")
 			}
 		} else {
-			utils.HandleError(errors.New("Your shell is not supported"))
+			utils.ErrExit(errors.New("Your shell is not supported"), utils.ExitCodeValidation)
 		}
 	},
 }
@@ -115,7 +115,7 @@ var completionInstallCmd = &cobra.Command{
 				usingFallbackPath = true
 			}
 		} else {
-			utils.HandleError(errors.New("Your shell is not supported"))
+			utils.ErrExit(errors.New("Your shell is not supported"), utils.ExitCodeValidation)
 		}
 
 		// create directory and intermediate directories if they don't exist
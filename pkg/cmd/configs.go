@@ -152,7 +152,7 @@ func createConfigs(cmd *cobra.Command, args []string) {
 	}
 
 	if name == "" {
-		utils.HandleError(errors.New("you must specify a name"))
+		utils.ErrExit(errors.New("you must specify a name"), utils.ExitCodeValidation)
 	}
 
 	if environment == "" && strings.Index(name, "_") != -1 {
@@ -160,7 +160,7 @@ func createConfigs(cmd *cobra.Command, args []string) {
 	}
 
 	if environment == "" {
-		utils.HandleError(errors.New("you must specify an environment"))
+		utils.ErrExit(errors.New("you must specify an environment"), utils.ExitCodeValidation)
 	}
 
 	info, err := http.CreateConfig(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, name, environment)
@@ -222,7 +222,7 @@ func updateConfigs(cmd *cobra.Command, args []string) {
 	}
 
 	if varsChanged != 1 {
-		utils.HandleError(fmt.Errorf("Exactly one of name, inheritable, and inherits must be specified"))
+		utils.ErrExit(fmt.Errorf("Exactly one of name, inheritable, and inherits must be specified"), utils.ExitCodeValidation)
 	}
 
 	name := cmd.Flag("name").Value.String()
@@ -117,7 +117,7 @@ func createConfigsTokens(cmd *cobra.Command, args []string) {
 
 	maxAge := utils.GetDurationFlagIfChanged(cmd, "max-age", 0)
 	if maxAge < 0 {
-		utils.HandleError(errors.New("Max age must be positive or zero"))
+		utils.ErrExit(errors.New("Max age must be positive or zero"), utils.ExitCodeValidation)
 	}
 	expireAt := time.Time{}
 	if maxAge > 0 {
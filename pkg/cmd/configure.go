@@ -18,6 +18,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
@@ -120,7 +121,13 @@ var configureSetCmd = &cobra.Command{
 	Long: `Set the value of one or more options in the config file.
 
 Ex: set the options "key" and "otherkey":
-doppler configure set key=123 otherkey=456`,
+doppler configure set key=123 otherkey=456
+
+Scopes may contain glob patterns, which match each directory whose leading path components match the pattern.
+In project and config values, '{dir}' is replaced with the name of the matched directory.
+
+Ex: use the "dev_<service>" config for each service in a monorepo:
+doppler configure set config='dev_{dir}' --scope '/work/mono/services/*'`,
 	ValidArgsFunction: configOptionsValidArgs,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
@@ -189,6 +196,19 @@ doppler configure set key=123 otherkey=456`,
 			translatedOptions[translatedKey] = value
 		}
 
+		if kdf, ok := translatedOptions[models.ConfigFallbackKDF.String()]; ok && !models.IsValidKDF(kdf) {
+			utils.ErrExit(fmt.Errorf("invalid fallback key derivation function. Valid functions are %v", models.KDFs), utils.ExitCodeValidation)
+		}
+		if keyring, ok := translatedOptions[models.ConfigFallbackKeyring.String()]; ok {
+			if _, err := strconv.ParseBool(keyring); err != nil {
+				utils.ErrExit(fmt.Errorf("invalid value for %s. Must be true or false", models.ConfigFallbackKeyring.String()), utils.ExitCodeValidation)
+			}
+		}
+
+		if profile, ok := translatedOptions[models.ConfigProfile.String()]; ok && !configuration.ProfileExists(profile) {
+			utils.ErrExit(fmt.Errorf("profile %s does not exist", profile), utils.ExitCodeNotFound, "", "Use 'doppler profile list' to view all profiles")
+		}
+
 		configuration.Set(configuration.Scope, translatedOptions)
 
 		if !utils.Silent {
@@ -0,0 +1,148 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+var configureExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the CLI configuration for use on another machine",
+	Long: `Export scoped options, profiles, and flags for use on another machine.
+
+Scopes within your home directory are exported relative to it (e.g. ~/projects/backend), so that they apply
+to the same directory on the other machine. Tokens are not exported unless --include-tokens is specified.`,
+	Example: `doppler configure export --out doppler-config.yaml`,
+	Args:    cobra.NoArgs,
+	Run:     configureExport,
+}
+
+var configureImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import CLI configuration exported from another machine",
+	Long: `Import scoped options, profiles, and flags exported via 'doppler configure export'.
+
+By default, the imported configuration is merged with the existing configuration. When an option already has
+a different value, you'll be prompted to choose which value to keep. Use --on-conflict to resolve conflicts
+without prompting, or --replace to remove all existing options, profiles, and flags before importing.`,
+	Example: `doppler configure import doppler-config.yaml
+doppler configure import doppler-config.yaml --on-conflict=overwrite`,
+	Args: cobra.ExactArgs(1),
+	Run:  configureImport,
+}
+
+func configureExport(cmd *cobra.Command, args []string) {
+	includeTokens := utils.GetBoolFlag(cmd, "include-tokens")
+	outPath := cmd.Flag("out").Value.String()
+
+	bytes, err := configuration.MarshalExportedConfig(configuration.ExportConfig(includeTokens))
+	if err != nil {
+		utils.HandleError(err, "Unable to export configuration")
+	}
+
+	if outPath == "" {
+		if _, err := os.Stdout.Write(bytes); err != nil {
+			utils.HandleError(err, "Unable to write configuration")
+		}
+		return
+	}
+
+	if err := utils.WriteFile(outPath, bytes, utils.RestrictedFilePerms()); err != nil {
+		utils.HandleError(err, "Unable to write configuration")
+	}
+	utils.Log(fmt.Sprintf("Exported configuration to %s", outPath))
+	if includeTokens {
+		utils.LogWarning("The exported configuration contains tokens and should be stored securely")
+	}
+}
+
+func configureImport(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	replace := utils.GetBoolFlag(cmd, "replace")
+	onConflict := cmd.Flag("on-conflict").Value.String()
+	yes := utils.GetBoolFlag(cmd, "yes")
+
+	if !models.IsValidImportConflictAction(onConflict) {
+		utils.ErrExit(fmt.Errorf("invalid --on-conflict value. Valid values are %v", models.ImportConflictActions), utils.ExitCodeValidation)
+	}
+
+	bytes, err := os.ReadFile(args[0]) // #nosec G304
+	if err != nil {
+		utils.HandleError(err, "Unable to read configuration")
+	}
+	config, err := configuration.ParseExportedConfig(bytes)
+	if err != nil {
+		utils.HandleError(err, "Unable to parse configuration")
+	}
+
+	if replace && !yes {
+		utils.PrintWarning("This will delete all existing scoped options, profiles, and flags")
+		if !utils.ConfirmationPrompt("Continue?", false) {
+			utils.Log("Aborting")
+			return
+		}
+	}
+
+	canPrompt := isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
+	resolve := func(location string, option string, existing string, imported string) bool {
+		switch onConflict {
+		case models.ImportConflictKeep:
+			return false
+		case models.ImportConflictOverwrite:
+			return true
+		}
+
+		if !canPrompt {
+			utils.HandleError(fmt.Errorf("conflicting value for %s in %s", configuration.TranslateConfigOption(option), location), "", "Use --on-conflict to resolve conflicts without prompting")
+		}
+
+		keep := fmt.Sprintf("Keep existing value (%s)", existing)
+		options := []string{keep, fmt.Sprintf("Use imported value (%s)", imported)}
+		message := fmt.Sprintf("Conflicting value for %s in %s:", configuration.TranslateConfigOption(option), location)
+		return utils.SelectPrompt(message, options, keep) != keep
+	}
+
+	configuration.ImportConfig(config, replace, resolve)
+
+	if !utils.Silent {
+		printer.Configs(configuration.AllConfigs(), jsonFlag, true)
+	}
+}
+
+func init() {
+	configureExportCmd.Flags().Bool("include-tokens", false, "include tokens in the exported configuration")
+	configureExportCmd.Flags().String("out", "", "path to write the configuration to. by default, the configuration is printed to stdout")
+	configureCmd.AddCommand(configureExportCmd)
+
+	configureImportCmd.Flags().Bool("replace", false, "remove all existing scoped options, profiles, and flags before importing")
+	configureImportCmd.Flags().String("on-conflict", models.ImportConflictPrompt, fmt.Sprintf("how to resolve conflicts when merging. one of %v", models.ImportConflictActions))
+	if err := configureImportCmd.RegisterFlagCompletionFunc("on-conflict", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return models.ImportConflictActions, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		utils.HandleError(err)
+	}
+	configureImportCmd.Flags().BoolP("yes", "y", false, "proceed without confirmation")
+	configureCmd.AddCommand(configureImportCmd)
+}
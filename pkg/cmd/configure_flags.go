@@ -52,7 +52,7 @@ var configureFlagsGetCmd = &cobra.Command{
 
 		flag := args[0]
 		if !configuration.IsValidFlag(flag) {
-			utils.HandleError(errors.New("invalid flag " + flag))
+			utils.ErrExit(errors.New("invalid flag "+flag), utils.ExitCodeValidation)
 		}
 
 		enabled := configuration.GetFlag(flag)
@@ -69,7 +69,7 @@ var configureFlagsEnableCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		flag := args[0]
 		if !configuration.IsValidFlag(flag) {
-			utils.HandleError(errors.New("invalid flag " + flag))
+			utils.ErrExit(errors.New("invalid flag "+flag), utils.ExitCodeValidation)
 		}
 
 		const value = true
@@ -89,7 +89,7 @@ var configureFlagsDisableCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		flag := args[0]
 		if !configuration.IsValidFlag(flag) {
-			utils.HandleError(errors.New("invalid flag " + flag))
+			utils.ErrExit(errors.New("invalid flag "+flag), utils.ExitCodeValidation)
 		}
 
 		const value = false
@@ -109,7 +109,7 @@ var configureFlagsResetCmd = &cobra.Command{
 
 		flag := args[0]
 		if !configuration.IsValidFlag(flag) {
-			utils.HandleError(errors.New("invalid flag " + flag))
+			utils.ErrExit(errors.New("invalid flag "+flag), utils.ExitCodeValidation)
 		}
 
 		yes := utils.GetBoolFlag(cmd, "yes")
@@ -0,0 +1,94 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var configureSecureStorageCmd = &cobra.Command{
+	Use:   "secure-storage",
+	Short: "Manage how tokens are stored",
+	Long: `Manage how tokens are stored.
+
+When secure storage is enabled (the default), tokens are stored in the system keyring. If the system keyring
+is unavailable (e.g. on a headless Linux machine), tokens are instead encrypted with a key that's stored in the
+config directory and is only readable by the current user. When secure storage is disabled, tokens are stored
+in plaintext in the config file.`,
+	Args: cobra.NoArgs,
+	Run:  configureSecureStorageStatus,
+}
+
+var configureSecureStorageStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "View the secure storage setting and how each token is stored",
+	Args:  cobra.NoArgs,
+	Run:   configureSecureStorageStatus,
+}
+
+var configureSecureStorageEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable secure storage, moving all plaintext tokens into secure storage",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		configuration.EnableSecureStorage()
+
+		if !utils.Silent {
+			printer.SecureStorageStatus(configuration.GetSecureStorageStatus(), utils.OutputJSON)
+		}
+	},
+}
+
+var configureSecureStorageDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable secure storage, moving all tokens into the config file in plaintext",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		yes := utils.GetBoolFlag(cmd, "yes")
+
+		if !yes {
+			utils.PrintWarning("This will store all tokens in plaintext in the config file")
+			if !utils.ConfirmationPrompt("Continue?", false) {
+				utils.Log("Aborting")
+				return
+			}
+		}
+
+		configuration.DisableSecureStorage()
+
+		if !utils.Silent {
+			printer.SecureStorageStatus(configuration.GetSecureStorageStatus(), utils.OutputJSON)
+		}
+	},
+}
+
+func configureSecureStorageStatus(cmd *cobra.Command, args []string) {
+	printer.SecureStorageStatus(configuration.GetSecureStorageStatus(), utils.OutputJSON)
+}
+
+func init() {
+	configureSecureStorageCmd.AddCommand(configureSecureStorageStatusCmd)
+
+	configureSecureStorageCmd.AddCommand(configureSecureStorageEnableCmd)
+
+	configureSecureStorageDisableCmd.Flags().BoolP("yes", "y", false, "proceed without confirmation")
+	configureSecureStorageCmd.AddCommand(configureSecureStorageDisableCmd)
+
+	configureCmd.AddCommand(configureSecureStorageCmd)
+}
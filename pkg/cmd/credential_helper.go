@@ -0,0 +1,305 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/controllers"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var credentialHelperCmd = &cobra.Command{
+	Use:   "credential-helper",
+	Short: "Provide credentials from Doppler secrets to other tools",
+	Long: `Provide credentials from Doppler secrets to tools that support external credential helpers.
+
+Each credential is read from a secret with a default name (e.g. AWS_ACCESS_KEY_ID), which can be changed via --map
+or a mapping file. A mapping file maps each protocol's credentials to secret names:
+
+  docker:
+    username: REGISTRY_USERNAME
+    secret: REGISTRY_PASSWORD
+
+Secrets are written to an encrypted fallback file after each successful fetch, so helpers keep working when
+Doppler is unreachable.`,
+	Args: cobra.NoArgs,
+}
+
+var credentialHelperGitCmd = &cobra.Command{
+	Use:       "git [get|store|erase]",
+	Short:     "Provide credentials to git",
+	Long:      fmt.Sprintf(`Provide credentials to git as a credential helper. %s`, credentialHelperFieldsHelp(models.CredentialHelperGit)),
+	Example:   `git config --global credential.https://github.com.helper '!doppler credential-helper git -p ci -c prd --map password=GITHUB_TOKEN --username x-access-token'`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"get", "store", "erase"},
+	Run: func(cmd *cobra.Command, args []string) {
+		// git provides the request's attributes via stdin, which must be consumed
+		readCredentialHelperInput()
+		if args[0] != "get" {
+			return
+		}
+
+		values := credentialHelperValues(cmd, models.CredentialHelperGit, credentialHelperUsername(cmd))
+		credential, err := controllers.GitCredential(values)
+		if err != nil {
+			utils.HandleError(err)
+		}
+		fmt.Print(credential)
+	},
+}
+
+var credentialHelperDockerCmd = &cobra.Command{
+	Use:   "docker [get|store|erase|list]",
+	Short: "Provide credentials to docker",
+	Long: fmt.Sprintf(`Provide credentials to docker as a credential helper. %s
+
+Docker runs credential helpers named docker-credential-<name>, so create an executable named
+docker-credential-doppler on your PATH that runs this command, and add it to ~/.docker/config.json:
+
+  {"credHelpers": {"ghcr.io": "doppler"}}`, credentialHelperFieldsHelp(models.CredentialHelperDocker)),
+	Example:   `printf '#!/bin/sh\nexec doppler credential-helper docker -p ci -c prd "$@"\n' > /usr/local/bin/docker-credential-doppler`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"get", "store", "erase", "list"},
+	Run: func(cmd *cobra.Command, args []string) {
+		// docker provides the server URL (or credentials, when storing) via stdin
+		input := readCredentialHelperInput()
+		switch args[0] {
+		case "get":
+			values := credentialHelperValues(cmd, models.CredentialHelperDocker, credentialHelperUsername(cmd))
+			printCredentialHelperJSON(controllers.DockerCredential(strings.TrimSpace(input), values))
+		case "list":
+			// the registries aren't known, as they're specified via the docker config
+			printCredentialHelperJSON(map[string]string{})
+		}
+	},
+}
+
+var credentialHelperAWSCmd = &cobra.Command{
+	Use:   "aws",
+	Short: "Provide credentials to AWS tools",
+	Long:  fmt.Sprintf(`Provide credentials to AWS tools via credential_process. %s`, credentialHelperFieldsHelp(models.CredentialHelperAWS)),
+	Example: `# ~/.aws/config
+[profile doppler]
+credential_process = doppler credential-helper aws -p infra -c prd`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		values := credentialHelperValues(cmd, models.CredentialHelperAWS, nil)
+		printCredentialHelperJSON(controllers.AWSCredential(values))
+	},
+}
+
+var credentialHelperKubectlCmd = &cobra.Command{
+	Use:   "kubectl",
+	Short: "Provide credentials to kubectl",
+	Long: fmt.Sprintf(`Provide credentials to kubectl as an exec credential plugin. %s
+
+Either a token, or a client certificate and client key, must be provided.`, credentialHelperFieldsHelp(models.CredentialHelperKubectl)),
+	Example: `# ~/.kube/config
+users:
+- name: doppler
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: doppler
+      args: ["credential-helper", "kubectl", "-p", "infra", "-c", "prd"]
+      interactiveMode: Never`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		values := credentialHelperValues(cmd, models.CredentialHelperKubectl, nil)
+		credential, err := controllers.KubectlExecCredential(values)
+		if err != nil {
+			utils.HandleError(err)
+		}
+		printCredentialHelperJSON(credential)
+	},
+}
+
+var credentialHelperTerraformCmd = &cobra.Command{
+	Use:   "terraform [get|store|forget] [hostname]",
+	Short: "Provide credentials to terraform",
+	Long: fmt.Sprintf(`Provide credentials to terraform as a credentials helper. %s
+
+Terraform runs credentials helpers named terraform-credentials-<name> from its plugin directory, so create an
+executable named terraform-credentials-doppler in ~/.terraform.d/plugins that runs this command, and add it to
+your CLI config:
+
+  credentials_helper "doppler" {}`, credentialHelperFieldsHelp(models.CredentialHelperTerraform)),
+	Example:   `printf '#!/bin/sh\nexec doppler credential-helper terraform -p infra -c prd "$@"\n' > ~/.terraform.d/plugins/terraform-credentials-doppler`,
+	Args:      cobra.ExactArgs(2),
+	ValidArgs: []string{"get", "store", "forget"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if args[0] != "get" {
+			// terraform provides the credentials to store via stdin
+			readCredentialHelperInput()
+			return
+		}
+
+		values := credentialHelperValues(cmd, models.CredentialHelperTerraform, nil)
+		printCredentialHelperJSON(controllers.TerraformCredential(values))
+	},
+}
+
+// credentialHelperUsername the username specified via --username, which is used instead of a secret
+func credentialHelperUsername(cmd *cobra.Command) map[string]string {
+	if !cmd.Flags().Changed("username") {
+		return nil
+	}
+	return map[string]string{"username": cmd.Flag("username").Value.String()}
+}
+
+// credentialHelperValues fetches the protocol's credentials, using the fallback file if Doppler is unreachable.
+// Credentials with a literal value aren't read from secrets.
+func credentialHelperValues(cmd *cobra.Command, protocol string, literals map[string]string) map[string]string {
+	localConfig := configuration.LocalConfig(cmd)
+	utils.RequireValue("token", localConfig.Token.Value)
+
+	var mappings []map[string]string
+	if mappingFile := cmd.Flag("mapping").Value.String(); mappingFile != "" {
+		mapping, err := controllers.ReadCredentialMappingFile(mappingFile, protocol)
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+		mappings = append(mappings, mapping)
+	}
+	flagMapping, err := cmd.Flags().GetStringToString("map")
+	if err != nil {
+		utils.HandleError(err)
+	}
+	mappings = append(mappings, flagMapping)
+
+	mapping, err := controllers.ResolveCredentialMapping(protocol, mappings...)
+	if err != nil {
+		utils.ErrExit(err, utils.ExitCodeValidation)
+	}
+	for name := range literals {
+		delete(mapping, name)
+	}
+	secretNames := controllers.CredentialSecretNames(mapping)
+
+	enableFallback := !utils.GetBoolFlag(cmd, "no-fallback")
+	enableCache := enableFallback && !utils.GetBoolFlag(cmd, "no-cache")
+	passphrase := getPassphrase(cmd, "passphrase", localConfig)
+	if passphrase == "" {
+		utils.ErrExit(errors.New("invalid passphrase"), utils.ExitCodeValidation)
+	}
+
+	fallbackPath := ""
+	legacyFallbackPath := ""
+	metadataPath := ""
+	if enableFallback {
+		// helpers shouldn't fail because the fallback file can't be written
+		fallbackPath, legacyFallbackPath = initFallbackDir(cmd, localConfig, models.JSON, nil, secretNames, false)
+	}
+	if enableCache {
+		metadataPath = controllers.MetadataFilePath(localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, models.JSON, nil, secretNames)
+	}
+
+	fallbackOpts := controllers.FallbackOptions{
+		Enable:      enableFallback,
+		Path:        fallbackPath,
+		LegacyPath:  legacyFallbackPath,
+		Passphrase:  passphrase,
+		KDF:         getFallbackKDF(localConfig),
+		MaxAge:      utils.GetDurationFlag(cmd, "fallback-max-age"),
+		StaleAction: getFallbackStaleAction(cmd),
+	}
+
+	secretsBytes, _, _ := controllers.FetchSecrets(localConfig, enableCache, fallbackOpts, metadataPath, nil, 0, models.JSON, secretNames)
+	secrets, parseErr := controllers.ParseSecrets(secretsBytes)
+	if parseErr != nil {
+		utils.HandleError(parseErr, "Unable to parse API response")
+	}
+
+	values, err := controllers.CredentialValues(mapping, secrets)
+	if err != nil {
+		utils.ErrExit(err, utils.ExitCodeNotFound)
+	}
+	for name, value := range literals {
+		values[name] = value
+	}
+	return values
+}
+
+// credentialHelperFieldsHelp describes the protocol's credentials and the secrets they're read from by default
+func credentialHelperFieldsHelp(protocol string) string {
+	var fields []string
+	for _, field := range controllers.CredentialHelperFields[protocol] {
+		description := fmt.Sprintf("%s (%s)", field.Name, field.DefaultSecret)
+		if !field.Required {
+			description += " [optional]"
+		}
+		fields = append(fields, "  "+description)
+	}
+	return fmt.Sprintf("The following credentials are provided, read from the secret in parentheses by default:\n\n%s", strings.Join(fields, "\n"))
+}
+
+// readCredentialHelperInput reads the input the tool provides via stdin
+func readCredentialHelperInput() string {
+	bytes, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		utils.HandleError(err, "Unable to read from stdin")
+	}
+	return string(bytes)
+}
+
+func printCredentialHelperJSON(value interface{}) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		utils.HandleError(err)
+	}
+	utils.Print(string(bytes))
+}
+
+func init() {
+	credentialHelperCmd.PersistentFlags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := credentialHelperCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	credentialHelperCmd.PersistentFlags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := credentialHelperCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	credentialHelperCmd.PersistentFlags().StringToString("map", nil, "map a credential to the secret it's read from (e.g. --map password=GITHUB_TOKEN). takes precedence over the mapping file")
+	credentialHelperCmd.PersistentFlags().String("mapping", "", "path to a YAML file mapping each protocol's credentials to secret names")
+	credentialHelperCmd.PersistentFlags().String("fallback", "", "path to the fallback file. encrypted secrets are written to this file after each successful fetch. secrets will be read from this file if subsequent connections are unsuccessful.")
+	credentialHelperCmd.PersistentFlags().String("passphrase", "", "passphrase to use for encrypting the fallback file. the default passphrase is computed using your current configuration.")
+	credentialHelperCmd.PersistentFlags().Duration("fallback-max-age", 0, "refuse to read secrets from a fallback file written longer ago than this duration (e.g. '24h'). 0 disables the check")
+	credentialHelperCmd.PersistentFlags().String("fallback-stale", models.FallbackStaleFail, fmt.Sprintf("action to take when the fallback file exceeds --fallback-max-age. one of %v", models.FallbackStaleActions))
+	if err := credentialHelperCmd.RegisterFlagCompletionFunc("fallback-stale", fallbackStaleValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	credentialHelperCmd.PersistentFlags().Bool("no-cache", false, "disable using the fallback file to speed up fetches. the fallback file is only used when the API indicates that it's still current.")
+	credentialHelperCmd.PersistentFlags().Bool("no-fallback", false, "disable reading and writing the fallback file (implies --no-cache)")
+
+	credentialHelperGitCmd.Flags().String("username", "", "the username to provide. takes precedence over the username secret")
+	credentialHelperCmd.AddCommand(credentialHelperGitCmd)
+	credentialHelperDockerCmd.Flags().String("username", "", "the username to provide. takes precedence over the username secret")
+	credentialHelperCmd.AddCommand(credentialHelperDockerCmd)
+	credentialHelperCmd.AddCommand(credentialHelperAWSCmd)
+	credentialHelperCmd.AddCommand(credentialHelperKubectlCmd)
+	credentialHelperCmd.AddCommand(credentialHelperTerraformCmd)
+
+	rootCmd.AddCommand(credentialHelperCmd)
+}
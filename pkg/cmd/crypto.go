@@ -0,0 +1,191 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/controllers"
+	"github.com/DopplerHQ/cli/pkg/crypto"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var cryptoCmd = &cobra.Command{
+	Use:   "crypto",
+	Short: "Encrypt and decrypt data using the CLI's file format",
+	Long: `Encrypt and decrypt data using the passphrase-based format used by fallback files
+and 'doppler secrets download --passphrase'.
+
+Input is read from the specified file, or from stdin if no file is specified.`,
+	Args: cobra.NoArgs,
+}
+
+var cryptoEncryptCmd = &cobra.Command{
+	Use:     "encrypt [file]",
+	Short:   "Encrypt data with a passphrase",
+	Example: `echo "secret" | doppler crypto encrypt --passphrase=123 > secret.enc`,
+	Args:    cobra.MaximumNArgs(1),
+	Run:     cryptoEncrypt,
+}
+
+var cryptoDecryptCmd = &cobra.Command{
+	Use:   "decrypt [file]",
+	Short: "Decrypt data with a passphrase",
+	Long: `Decrypt data with a passphrase.
+
+When decrypting a fallback file, the secrets it contains are printed. Use --raw to print the decrypted
+contents as stored, including the time the fallback file was written.`,
+	Example: `doppler crypto decrypt ~/.doppler/fallback/.secrets-abc123.json --passphrase=123`,
+	Args:    cobra.MaximumNArgs(1),
+	Run:     cryptoDecrypt,
+}
+
+var cryptoInspectCmd = &cobra.Command{
+	Use:   "inspect [file]",
+	Short: "Display the encryption parameters of encrypted data",
+	Long: `Display the file version, encoding, and key derivation parameters of encrypted data.
+No passphrase is required.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  cryptoInspect,
+}
+
+func cryptoEncrypt(cmd *cobra.Command, args []string) {
+	encoding := cmd.Flag("encoding").Value.String()
+	kdf := cmd.Flag("kdf").Value.String()
+
+	if encoding != models.Base64EncodingPrefix && encoding != models.HexEncodingPrefix {
+		utils.ErrExit(fmt.Errorf("invalid encoding. Valid encodings are %v", []string{models.Base64EncodingPrefix, models.HexEncodingPrefix}), utils.ExitCodeValidation)
+	}
+	if !models.IsValidKDF(kdf) {
+		utils.ErrExit(fmt.Errorf("invalid key derivation function. Valid functions are %v", models.KDFs), utils.ExitCodeValidation)
+	}
+
+	passphrase := getCryptoPassphrase(cmd)
+	plaintext := readCryptoInput(args)
+
+	ciphertext, err := crypto.EncryptWithKDF(passphrase, plaintext, encoding, kdf)
+	if err != nil {
+		utils.HandleError(err, "Unable to encrypt data")
+	}
+
+	utils.Print(ciphertext)
+}
+
+func cryptoDecrypt(cmd *cobra.Command, args []string) {
+	raw := utils.GetBoolFlag(cmd, "raw")
+
+	passphrase := getCryptoPassphrase(cmd)
+	ciphertext := strings.TrimSpace(string(readCryptoInput(args)))
+
+	plaintext, err := crypto.Decrypt(passphrase, []byte(ciphertext))
+	if err != nil {
+		utils.HandleError(err, "Unable to decrypt data")
+	}
+
+	output := []byte(plaintext)
+	if !raw {
+		fileVersion, _ := models.FileVersion(ciphertext)
+		output, _ = controllers.UnwrapFallbackPayload(output, fileVersion)
+	}
+
+	if _, err := os.Stdout.Write(output); err != nil {
+		utils.HandleError(err, "Unable to write decrypted data")
+	}
+}
+
+func cryptoInspect(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+
+	ciphertext := strings.TrimSpace(string(readCryptoInput(args)))
+	file, err := models.ParseEncryptedFile(ciphertext)
+	if err != nil {
+		utils.HandleError(err, "Unable to parse encrypted data")
+	}
+
+	printer.EncryptedFile(file, jsonFlag)
+}
+
+// getCryptoPassphrase the passphrase specified via flag or environment
+func getCryptoPassphrase(cmd *cobra.Command) string {
+	if cmd.Flags().Changed("passphrase") {
+		passphrase := cmd.Flag("passphrase").Value.String()
+		if passphrase == "" {
+			utils.ErrExit(errors.New("invalid passphrase"), utils.ExitCodeValidation)
+		}
+		return passphrase
+	}
+
+	if configuration.CanReadEnv {
+		if passphrase := os.Getenv("DOPPLER_PASSPHRASE"); passphrase != "" {
+			logValueFromEnvironmentNotice("DOPPLER_PASSPHRASE")
+			return passphrase
+		}
+	}
+
+	utils.ErrExit(errors.New("you must specify a passphrase with --passphrase or DOPPLER_PASSPHRASE"), utils.ExitCodeValidation)
+	return ""
+}
+
+// readCryptoInput reads the contents of the file, or stdin if no file is specified
+func readCryptoInput(args []string) []byte {
+	if len(args) > 0 && args[0] != "-" {
+		data, err := os.ReadFile(args[0]) // #nosec G304
+		if err != nil {
+			utils.HandleError(err, "Unable to read file")
+		}
+		return data
+	}
+
+	hasData, err := utils.HasDataOnStdIn()
+	if err != nil {
+		utils.HandleError(err)
+	}
+	if !hasData {
+		utils.ErrExit(errors.New("you must specify a file or provide data on stdin"), utils.ExitCodeValidation)
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		utils.HandleError(err, "Unable to read stdin")
+	}
+	return data
+}
+
+func init() {
+	cryptoEncryptCmd.Flags().String("passphrase", "", "passphrase to use for encrypting the data. may also be specified via DOPPLER_PASSPHRASE")
+	cryptoEncryptCmd.Flags().String("encoding", models.Base64EncodingPrefix, fmt.Sprintf("encoding of the ciphertext. one of %v", []string{models.Base64EncodingPrefix, models.HexEncodingPrefix}))
+	cryptoEncryptCmd.Flags().String("kdf", models.Pbkdf2KDF, fmt.Sprintf("key derivation function. one of %v", models.KDFs))
+	if err := cryptoEncryptCmd.RegisterFlagCompletionFunc("kdf", fallbackKDFValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	cryptoCmd.AddCommand(cryptoEncryptCmd)
+
+	cryptoDecryptCmd.Flags().String("passphrase", "", "passphrase to use for decrypting the data. may also be specified via DOPPLER_PASSPHRASE")
+	cryptoDecryptCmd.Flags().Bool("raw", false, "print the decrypted contents as stored, without extracting the secrets from fallback files")
+	cryptoCmd.AddCommand(cryptoDecryptCmd)
+
+	cryptoCmd.AddCommand(cryptoInspectCmd)
+
+	rootCmd.AddCommand(cryptoCmd)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/devserver"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var devServerCmd = &cobra.Command{
+	Use:   "dev-server",
+	Short: "Run a local mock of the Doppler API for development and testing",
+	Long: `Run a local mock of the Doppler API for development and testing.
+
+The dev server implements the subset of the Doppler API used by the CLI, backed by a local YAML file.
+The file is created with an example project and a personal token if it doesn't exist.
+Point the CLI at the server via --api-host (or DOPPLER_API_HOST) to use it offline.
+
+The dev server is not secure and should never be used to store real secrets.`,
+	Example: `doppler dev-server --port 8080
+doppler secrets --api-host http://127.0.0.1:8080 --token <token> --project example --config dev`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		address := cmd.Flag("address").Value.String()
+		port := cmd.Flag("port").Value.String()
+		dataFile := cmd.Flag("data").Value.String()
+		if dataFile == "" {
+			dataFile = filepath.Join(configuration.UserConfigDir, "dev-server.yaml")
+		}
+
+		var err error
+		if dataFile, err = utils.GetFilePath(dataFile); err != nil {
+			utils.HandleError(err, "Unable to parse data file path")
+		}
+
+		store, err := devserver.LoadStore(dataFile)
+		if err != nil {
+			utils.HandleError(err, fmt.Sprintf("Unable to load data file %s", dataFile))
+		}
+
+		listener, err := net.Listen("tcp", net.JoinHostPort(address, port))
+		if err != nil {
+			utils.HandleError(err, "Unable to start dev server")
+		}
+
+		host := fmt.Sprintf("http://%s", listener.Addr().String())
+		utils.Log(fmt.Sprintf("Doppler dev server listening on %s", host))
+		utils.Log(fmt.Sprintf("Using data file %s", store.Path()))
+		for _, token := range store.PersonalTokens() {
+			utils.Log(fmt.Sprintf("Personal token: %s", token))
+		}
+		utils.Log("")
+		utils.Log(fmt.Sprintf("Example: doppler secrets --api-host %s --token <token> --project example --config dev", host))
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// #nosec G112
+		server := &http.Server{
+			Handler: devserver.NewServer(store),
+			// cancel open watch streams on shutdown
+			BaseContext: func(net.Listener) context.Context { return ctx },
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.HandleError(err, "Dev server failed")
+		}
+	},
+}
+
+func init() {
+	devServerCmd.Flags().String("address", "127.0.0.1", "address to listen on")
+	devServerCmd.Flags().String("port", "8080", "port to listen on")
+	devServerCmd.Flags().String("data", "", "path to the YAML data file (default $CONFIG_DIR/dev-server.yaml)")
+	rootCmd.AddCommand(devServerCmd)
+}
@@ -168,7 +168,7 @@ func renameEnvironment(cmd *cobra.Command, args []string) {
 	utils.RequireValue("token", localConfig.Token.Value)
 
 	if newName == "" && newSlug == "" {
-		utils.HandleError(errors.New("command requires --name or --slug"))
+		utils.ErrExit(errors.New("command requires --name or --slug"), utils.ExitCodeValidation)
 	}
 
 	slug := args[0]
@@ -0,0 +1,358 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/controllers"
+	"github.com/DopplerHQ/cli/pkg/crypto"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var fallbackCmd = &cobra.Command{
+	Use:   "fallback",
+	Short: "Manage fallback files",
+	Long: `Manage the encrypted fallback files written by 'doppler run' and 'doppler secrets download'.
+
+Non-secret metadata about each fallback file (e.g. its project, config, and format) is recorded in an index
+in the fallback directory so that files can be identified without being decrypted.`,
+	Args: cobra.NoArgs,
+}
+
+var fallbackListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List fallback files",
+	Args:    cobra.NoArgs,
+	Run:     fallbackList,
+}
+
+var fallbackInspectCmd = &cobra.Command{
+	Use:   "inspect [file]",
+	Short: "Display a fallback file's metadata",
+	Long: `Display a fallback file's metadata without decrypting it.
+
+The file can be specified by path, file name, or a prefix of its ID. If no file is specified,
+the fallback file for the current scope is used.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: fallbackFilesValidArgs,
+	Run:               fallbackInspect,
+}
+
+var fallbackVerifyCmd = &cobra.Command{
+	Use:   "verify [file]",
+	Short: "Verify that fallback files can be decrypted",
+	Long: `Verify that fallback files can be decrypted.
+
+The passphrase is derived from the current token and each file's project and config. Files written using
+a different token or a custom passphrase are skipped unless a passphrase is specified.
+If no file is specified, all fallback files are verified.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: fallbackFilesValidArgs,
+	Run:               fallbackVerify,
+}
+
+var fallbackRekeyCmd = &cobra.Command{
+	Use:   "rekey [file]",
+	Short: "Re-encrypt fallback files using the current key derivation function",
+	Long: `Re-encrypt fallback files in place using the key derivation function specified by --fallback-kdf,
+the DOPPLER_FALLBACK_KDF environment variable, or the 'fallback-kdf' config option.
+
+Files are decrypted using the same passphrase resolution as 'doppler fallback verify'.
+If no file is specified, all fallback files are re-encrypted.`,
+	Example:           `doppler fallback rekey --fallback-kdf argon2id`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: fallbackFilesValidArgs,
+	Run:               fallbackRekey,
+}
+
+var fallbackPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete fallback files",
+	Long: `Delete fallback files matching all of the specified filters, along with their metadata files.
+
+Files that aren't in the fallback index have no project or config and only match the --max-age filter.
+Files outside the fallback directory, such as those written to a path specified with --fallback, are only deleted when --include-external is specified.`,
+	Example: `doppler fallback prune --project backend --config dev
+doppler fallback prune --max-age=168h --dry-run`,
+	Args: cobra.NoArgs,
+	Run:  fallbackPrune,
+}
+
+func fallbackList(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+
+	files, err := controllers.ListFallbackFiles()
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	printer.FallbackFiles(files, jsonFlag)
+}
+
+func fallbackInspect(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+
+	file := findFallbackFile(cmd, args)
+	file, err := controllers.InspectFallbackFile(file)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	printer.FallbackFile(file, jsonFlag)
+}
+
+func fallbackVerify(cmd *cobra.Command, args []string) {
+	processFallbackFiles(cmd, args, "failed verification", controllers.VerifyFallbackFile)
+}
+
+func fallbackRekey(cmd *cobra.Command, args []string) {
+	localConfig := configuration.LocalConfig(cmd)
+	kdf := getFallbackKDF(localConfig)
+
+	processFallbackFiles(cmd, args, "could not be re-encrypted", func(file models.FallbackFileInfo, passphrase string) controllers.Error {
+		return controllers.RekeyFallbackFile(file, passphrase, kdf)
+	})
+}
+
+// processFallbackFiles runs fn on the specified fallback file, or on all fallback files if none is specified.
+// Files whose passphrase can't be determined are skipped.
+func processFallbackFiles(cmd *cobra.Command, args []string, failureMessage string, fn func(file models.FallbackFileInfo, passphrase string) controllers.Error) {
+	jsonFlag := utils.OutputJSON
+	localConfig := configuration.LocalConfig(cmd)
+
+	var files []models.FallbackFileInfo
+	if len(args) > 0 {
+		files = []models.FallbackFileInfo{findFallbackFile(cmd, args)}
+	} else {
+		var err controllers.Error
+		files, err = controllers.ListFallbackFiles()
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+	}
+
+	// a passphrase specified via flag or environment is used for all files
+	passphrase := ""
+	if cmd.Flags().Changed("passphrase") || (configuration.CanReadEnv && os.Getenv("DOPPLER_PASSPHRASE") != "") {
+		passphrase = getPassphrase(cmd, "passphrase", localConfig)
+	}
+	tokenHash := ""
+	if localConfig.Token.Value != "" {
+		tokenHash = crypto.Hash(localConfig.Token.Value)
+	}
+
+	failed := 0
+	var results []models.FallbackFileResult
+	for _, file := range files {
+		result := models.FallbackFileResult{ID: file.ID, Name: file.Name, Path: file.Path, Project: file.Project, Config: file.Config}
+
+		filePassphrase := passphrase
+		if filePassphrase == "" {
+			if !file.Indexed {
+				result.Status = models.FallbackResultSkipped
+				result.Message = "file is not indexed; specify --passphrase"
+			} else if file.FallbackKey {
+				key, err := controllers.FallbackKey()
+				if err.IsNil() {
+					filePassphrase = key
+				} else {
+					result.Status = models.FallbackResultSkipped
+					result.Message = err.Message
+				}
+			} else if file.CustomPassphrase {
+				result.Status = models.FallbackResultSkipped
+				result.Message = "file uses a custom passphrase; specify --passphrase"
+			} else if tokenHash == "" || file.TokenHash != tokenHash {
+				result.Status = models.FallbackResultSkipped
+				result.Message = "file was written using a different token"
+			} else {
+				filePassphrase = controllers.DefaultFallbackPassphrase(localConfig.Token.Value, file.Project, file.Config)
+			}
+		}
+
+		if filePassphrase != "" {
+			if err := fn(file, filePassphrase); err.IsNil() {
+				result.Status = models.FallbackResultOK
+			} else {
+				failed++
+				result.Status = models.FallbackResultFailed
+				result.Message = err.Unwrap().Error()
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	printer.FallbackFileResults(results, jsonFlag)
+
+	if failed > 0 {
+		utils.HandleError(fmt.Errorf("%d of %d fallback files %s", failed, len(files), failureMessage))
+	}
+}
+
+func fallbackPrune(cmd *cobra.Command, args []string) {
+	project := cmd.Flag("project").Value.String()
+	config := cmd.Flag("config").Value.String()
+	maxAge := utils.GetDurationFlag(cmd, "max-age")
+	dryRun := utils.GetBoolFlag(cmd, "dry-run")
+	includeExternal := utils.GetBoolFlag(cmd, "include-external")
+
+	if project == "" && config == "" && maxAge == 0 {
+		utils.ErrExit(errors.New("you must specify at least one of --project, --config, or --max-age"), utils.ExitCodeValidation)
+	}
+
+	files, err := controllers.ListFallbackFiles()
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	action := "Deleted"
+	if dryRun {
+		action = "Would have deleted"
+	}
+
+	now := time.Now()
+	deleted := 0
+	for _, file := range files {
+		// files outside the fallback directory may be at arbitrary user-specified paths
+		if file.External && !includeExternal {
+			utils.LogDebug(fmt.Sprintf("Skipping external fallback file %s", file.Path))
+			continue
+		}
+		if project != "" && file.Project != project {
+			continue
+		}
+		if config != "" && file.Config != config {
+			continue
+		}
+		if maxAge > 0 {
+			lastSynced := file.SyncedAt
+			if lastSynced.IsZero() {
+				lastSynced = file.ModifiedAt
+			}
+			if lastSynced.Add(maxAge).After(now) {
+				continue
+			}
+		}
+
+		utils.LogDebug(fmt.Sprintf("%s %s", action, file.Path))
+		if dryRun {
+			deleted++
+			continue
+		}
+
+		if err := controllers.DeleteFallbackFile(file); !err.IsNil() {
+			// don't exit
+			utils.Log(err.Message)
+			utils.LogDebugError(err.Unwrap())
+		} else {
+			deleted++
+		}
+	}
+
+	if deleted == 1 {
+		utils.Print(fmt.Sprintf("%s %d fallback file\n", action, deleted))
+	} else {
+		utils.Print(fmt.Sprintf("%s %d fallback files\n", action, deleted))
+	}
+}
+
+// findFallbackFile finds the fallback file specified by the args, or the fallback file for the current scope
+func findFallbackFile(cmd *cobra.Command, args []string) models.FallbackFileInfo {
+	files, err := controllers.ListFallbackFiles()
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	query := ""
+	if len(args) > 0 {
+		query = args[0]
+	} else {
+		localConfig := configuration.LocalConfig(cmd)
+		utils.RequireValue("token", localConfig.Token.Value)
+		hash := controllers.GenerateFallbackFileHash(localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, models.JSON, nil, nil)
+		query = fmt.Sprintf(".secrets-%s.json", hash)
+	}
+
+	file, err := controllers.FindFallbackFile(files, query)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+	return file
+}
+
+func fallbackFilesValidArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	persistentValidArgsFunction(cmd)
+
+	files, err := controllers.ListFallbackFiles()
+	if !err.IsNil() {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, file := range files {
+		names = append(names, file.ID)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	fallbackCmd.AddCommand(fallbackListCmd)
+
+	fallbackInspectCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := fallbackInspectCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	fallbackInspectCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := fallbackInspectCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	fallbackCmd.AddCommand(fallbackInspectCmd)
+
+	fallbackVerifyCmd.Flags().String("passphrase", "", "passphrase to use for decrypting the fallback files. by default, the passphrase is derived from the current token and each file's project and config.")
+	fallbackCmd.AddCommand(fallbackVerifyCmd)
+
+	fallbackRekeyCmd.Flags().String("passphrase", "", "passphrase to use for decrypting and re-encrypting the fallback files. by default, the passphrase is derived from the current token and each file's project and config.")
+	fallbackRekeyCmd.Flags().String("fallback-kdf", "", fmt.Sprintf("key derivation function to use for re-encrypting the fallback files. one of %v (default \"%s\")", models.KDFs, models.Pbkdf2KDF))
+	if err := fallbackRekeyCmd.RegisterFlagCompletionFunc("fallback-kdf", fallbackKDFValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	fallbackCmd.AddCommand(fallbackRekeyCmd)
+
+	fallbackPruneCmd.Flags().StringP("project", "p", "", "only delete fallback files for this project")
+	if err := fallbackPruneCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	fallbackPruneCmd.Flags().StringP("config", "c", "", "only delete fallback files for this config")
+	if err := fallbackPruneCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	fallbackPruneCmd.Flags().Duration("max-age", 0, "only delete fallback files that haven't been synced within this duration")
+	fallbackPruneCmd.Flags().Bool("include-external", false, "also delete fallback files outside the fallback directory, such as those written to a path specified with --fallback")
+	fallbackPruneCmd.Flags().Bool("dry-run", false, "do not delete anything, print what would have happened")
+	fallbackCmd.AddCommand(fallbackPruneCmd)
+
+	rootCmd.AddCommand(fallbackCmd)
+}
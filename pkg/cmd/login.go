@@ -147,7 +147,7 @@ var loginCmd = &cobra.Command{
 			utils.Print("")
 			utils.Print(fmt.Sprint(err))
 
-			os.Exit(1)
+			utils.Exit(1)
 		}
 
 		token, ok := response["token"].(string)
@@ -20,8 +20,10 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/controllers"
 	"github.com/DopplerHQ/cli/pkg/http"
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/utils"
@@ -37,7 +39,24 @@ var oidcCmd = &cobra.Command{
 var oidcLoginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Authenticate to Doppler with a service account identity via an OIDC token",
-	Args:  cobra.NoArgs,
+	Long: `Authenticate to Doppler with a service account identity via an OIDC token.
+
+When --token isn't specified, the OIDC token is retrieved from the CI provider. By default, the provider is
+detected automatically. Supported providers:
+  github     requested from ACTIONS_ID_TOKEN_REQUEST_URL (requires the 'id-token: write' permission)
+  gitlab     read from DOPPLER_ID_TOKEN, or the variable specified via --token-env (see the job's id_tokens)
+  circleci   read from CIRCLE_OIDC_TOKEN_V2 or CIRCLE_OIDC_TOKEN, or the variable specified via --token-env
+  buildkite  requested via 'buildkite-agent oidc request-token'
+
+The token's audience can be specified via --audience for GitHub and Buildkite. For other providers, the audience
+is configured by the pipeline or organization.
+
+When the OIDC token is retrieved from the CI provider, 'doppler run' refreshes the service account identity token
+before it expires, so long-running processes (e.g. with --watch) stay authenticated.`,
+	Example: `doppler oidc login --identity 00000000-0000-0000-0000-000000000000
+doppler oidc login --identity 00000000-0000-0000-0000-000000000000 --provider github --audience https://api.doppler.com
+doppler oidc login --identity 00000000-0000-0000-0000-000000000000 --token "$OIDC_TOKEN"`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		localConfig := configuration.LocalConfig(cmd)
 		prevConfig := configuration.Get(configuration.Scope)
@@ -46,7 +65,6 @@ var oidcLoginCmd = &cobra.Command{
 		verifyTLS := utils.GetBool(localConfig.VerifyTLS.Value, true)
 
 		utils.RequireValue("identity", identity)
-		utils.RequireValue("token", token)
 
 		// Disallow overwriting a token with the same scope
 		if prevConfig.Token.Value != "" {
@@ -66,30 +84,21 @@ var oidcLoginCmd = &cobra.Command{
 			}
 		}
 
-		response, err := http.GetOIDCAuthToken(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), identity, token)
+		var session models.OIDCSession
+		if !cmd.Flags().Changed("token") {
+			token, session = fetchOIDCToken(cmd)
+		}
+		utils.RequireValue("token", token)
+
+		authToken, err := controllers.GetOIDCAuthToken(localConfig.APIHost.Value, verifyTLS, identity, token)
 		if !err.IsNil() {
 			utils.HandleError(err.Unwrap(), err.Message)
 		}
-		token, ok := response["token"].(string)
-		if !ok {
-			utils.LogDebug(fmt.Sprintf("Unexpected type mismatch for auth token, expected string, got %T", response["token"]))
-			utils.HandleError(errors.New("Unable to parse API response"))
-		}
-		expiresAt, ok := response["expires_at"].(string)
-		if !ok {
-			utils.LogDebug(fmt.Sprintf("Unexpected type mismatch for auth token expiration, expected string, got %T", response["expires_at"]))
-			utils.HandleError(errors.New("Unable to parse API response"))
-		}
-		dashboard, ok := response["dashboard_url"].(string)
-		if !ok {
-			utils.LogDebug(fmt.Sprintf("Unexpected type mismatch for dashboard url, expected string, got %T", response["dashboard_url"]))
-			utils.HandleError(errors.New("Unable to parse API response"))
-		}
-		
+
 		options := map[string]string{
-			models.ConfigToken.String():         token,
+			models.ConfigToken.String():         authToken.Token,
 			models.ConfigAPIHost.String():       localConfig.APIHost.Value,
-			models.ConfigDashboardHost.String(): dashboard,
+			models.ConfigDashboardHost.String(): authToken.DashboardURL,
 		}
 
 		// only set verifytls if using non-default value
@@ -98,12 +107,44 @@ var oidcLoginCmd = &cobra.Command{
 		}
 
 		configuration.Set(configuration.Scope, options)
+		// save the OIDC token's source so that 'doppler run' can refresh the token before it expires
+		session.Identity = identity
+		session.ExpiresAt = authToken.ExpiresAt
+		configuration.SetOIDCSession(configuration.Scope, session)
 
 		utils.Print("")
-		utils.Print(fmt.Sprintf("Authenticated via OIDC, token expires at %s", expiresAt))
+		utils.Print(fmt.Sprintf("Authenticated via OIDC, token expires at %s", authToken.ExpiresAt.Format(time.RFC3339)))
 	},
 }
 
+// fetchOIDCToken retrieves the OIDC token from the CI provider specified via --provider. The returned session
+// records the token's source.
+func fetchOIDCToken(cmd *cobra.Command) (string, models.OIDCSession) {
+	provider := cmd.Flag("provider").Value.String()
+	audience := cmd.Flag("audience").Value.String()
+	tokenEnv := cmd.Flag("token-env").Value.String()
+
+	if !models.IsValidOIDCProvider(provider) {
+		utils.ErrExit(fmt.Errorf("invalid OIDC provider %s. Valid providers are %v", provider, models.OIDCProviders), utils.ExitCodeValidation)
+	}
+	if provider == models.OIDCProviderAuto {
+		provider = controllers.DetectOIDCProvider()
+		if provider == "" {
+			utils.ErrExit(errors.New("unable to detect CI provider"), utils.ExitCodeValidation, "", "Specify the OIDC token via --token, or the CI provider via --provider")
+		}
+		utils.LogDebug(fmt.Sprintf("Detected CI provider %s", provider))
+	}
+	if audience != "" && !controllers.OIDCProviderSupportsAudience(provider) {
+		utils.LogWarning(fmt.Sprintf("--audience has no effect with the %s provider", provider))
+	}
+
+	token, err := controllers.FetchOIDCToken(provider, audience, tokenEnv)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+	return token, models.OIDCSession{Provider: provider, Audience: audience, TokenEnv: tokenEnv}
+}
+
 var oidcTokenRevokeCmd = &cobra.Command{
 	Use:   "logout",
 	Short: "Revoke the current short lived service account identity access token created via OIDC",
@@ -158,7 +199,15 @@ func revokeIdentityToken(cmd *cobra.Command, args []string) {
 func init() {
 	oidcLoginCmd.Flags().String("scope", "/", "the directory to scope your token to")
 	oidcLoginCmd.Flags().String("identity", "", "the service account identity ID to authenticate as")
-	oidcLoginCmd.Flags().String("token", "", "the signed OIDC JWT token string to authenticate with")
+	oidcLoginCmd.Flags().String("token", "", "the signed OIDC JWT token string to authenticate with. by default, the token is retrieved from the CI provider")
+	oidcLoginCmd.Flags().String("provider", models.OIDCProviderAuto, fmt.Sprintf("the CI provider to retrieve the OIDC token from. one of %v", models.OIDCProviders))
+	if err := oidcLoginCmd.RegisterFlagCompletionFunc("provider", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return models.OIDCProviders, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		utils.HandleError(err)
+	}
+	oidcLoginCmd.Flags().String("audience", "", "the audience to request the OIDC token for. by default, the provider's default audience is used")
+	oidcLoginCmd.Flags().String("token-env", "", "the environment variable to read the OIDC token from (gitlab and circleci only)")
 
 	oidcCmd.AddCommand(oidcLoginCmd)
 
@@ -0,0 +1,163 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named profiles",
+	Long: `Manage named profiles, which store a token, API host, dashboard host, and TLS setting under a name.
+
+A profile is used when it's referenced by a scope (see 'doppler profile use'), or when it's selected
+via --profile or DOPPLER_PROFILE. Options set directly on a scope, via environment variables, or via flags
+take precedence over a profile referenced by a scope. A profile selected via --profile or DOPPLER_PROFILE
+takes precedence over options set in the config file.`,
+	Args: cobra.NoArgs,
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add a profile, or update an existing one",
+	Example: `doppler profile add acme --token dp.pt.123 --api-host https://doppler.acme.internal
+doppler profile add acme --no-verify-tls`,
+	Args: cobra.ExactArgs(1),
+	Run:  profileAdd,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:               "use [name]",
+	Short:             "Use a profile for the specified scope",
+	Example:           `doppler profile use acme --scope ~/work/acme`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: profileNamesValidArgs,
+	Run:               profileUse,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Args:  cobra.NoArgs,
+	Run:   profileList,
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:               "remove [name]",
+	Aliases:           []string{"rm", "delete"},
+	Short:             "Remove a profile",
+	Long:              "Remove a profile, along with any references to it from scopes",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: profileNamesValidArgs,
+	Run:               profileRemove,
+}
+
+func profileAdd(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	name := args[0]
+
+	options := map[string]string{}
+	if cmd.Flags().Changed("token") {
+		options[models.ConfigToken.String()] = cmd.Flag("token").Value.String()
+	}
+	if cmd.Flags().Changed("api-host") {
+		options[models.ConfigAPIHost.String()] = cmd.Flag("api-host").Value.String()
+	}
+	if cmd.Flags().Changed("dashboard-host") {
+		options[models.ConfigDashboardHost.String()] = cmd.Flag("dashboard-host").Value.String()
+	}
+	if cmd.Flags().Changed("no-verify-tls") {
+		options[models.ConfigVerifyTLS.String()] = strconv.FormatBool(!utils.GetBoolFlag(cmd, "no-verify-tls"))
+	}
+
+	if len(options) == 0 {
+		utils.ErrExit(errors.New("you must specify at least one of --token, --api-host, --dashboard-host, or --no-verify-tls"), utils.ExitCodeValidation)
+	}
+
+	configuration.SetProfile(name, options)
+
+	if !utils.Silent {
+		printer.Profiles(configuration.Profiles(), "", jsonFlag)
+	}
+}
+
+func profileUse(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	name := args[0]
+
+	if !configuration.ProfileExists(name) {
+		utils.ErrExit(fmt.Errorf("profile %s does not exist", name), utils.ExitCodeNotFound, "", "Use 'doppler profile list' to view all profiles")
+	}
+
+	scope := cmd.Flag("scope").Value.String()
+	configuration.Set(scope, map[string]string{models.ConfigProfile.String(): name})
+
+	if !utils.Silent {
+		printer.ScopedConfig(configuration.Get(scope), jsonFlag)
+	}
+}
+
+func profileList(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	localConfig := configuration.LocalConfig(cmd)
+
+	printer.Profiles(configuration.Profiles(), localConfig.Profile.Value, jsonFlag)
+}
+
+func profileRemove(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	name := args[0]
+
+	configuration.RemoveProfile(name)
+
+	if !utils.Silent {
+		printer.Profiles(configuration.Profiles(), "", jsonFlag)
+	}
+}
+
+func profileNamesValidArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	persistentValidArgsFunction(cmd)
+
+	var names []string
+	for name := range configuration.Profiles() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	profileCmd.AddCommand(profileAddCmd)
+
+	profileUseCmd.Flags().String("scope", "/", "the directory to use the profile for")
+	profileCmd.AddCommand(profileUseCmd)
+
+	profileCmd.AddCommand(profileListCmd)
+
+	profileCmd.AddCommand(profileRemoveCmd)
+
+	rootCmd.AddCommand(profileCmd)
+}
@@ -16,8 +16,10 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
@@ -26,6 +28,7 @@ import (
 	"github.com/DopplerHQ/cli/pkg/http"
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/DopplerHQ/cli/pkg/telemetry"
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/DopplerHQ/cli/pkg/version"
 	"github.com/mattn/go-isatty"
@@ -45,6 +48,7 @@ var rootCmd = &cobra.Command{
 		configuration.LoadConfig()
 
 		controllers.CaptureCommand(cmd.CommandPath())
+		telemetry.Start(cmd.CommandPath())
 
 		if utils.Debug && utils.Silent {
 			utils.LogWarning("--silent has no effect when used with --debug")
@@ -103,6 +107,8 @@ func persistentValidArgsFunction(cmd *cobra.Command) {
 // this function runs before the config file has been loaded, so flags will not be honored
 func loadFlags(cmd *cobra.Command) {
 	var err error
+	utils.CommandPath = cmd.CommandPath()
+
 	var normalizedScope string
 	scope := cmd.Flag("scope").Value.String()
 	if normalizedScope, err = configuration.NormalizeScope(scope); err != nil {
@@ -112,6 +118,37 @@ func loadFlags(cmd *cobra.Command) {
 
 	configuration.CanReadEnv = !utils.GetBoolFlag(cmd, "no-read-env")
 
+	// Log format
+	if configuration.CanReadEnv {
+		if logFormat := os.Getenv("DOPPLER_LOG_FORMAT"); logFormat != "" {
+			utils.LogFormat = logFormat
+		}
+	}
+	// flag takes precedence over env var
+	utils.LogFormat = utils.GetFlagIfChanged(cmd, "log-format", utils.LogFormat)
+	if !utils.Contains(utils.LogFormats, utils.LogFormat) {
+		invalidFormat := utils.LogFormat
+		utils.LogFormat = utils.LogFormatText
+		utils.ErrExit(fmt.Errorf("Invalid log format %q. Valid formats are %s", invalidFormat, strings.Join(utils.LogFormats, ", ")), utils.ExitCodeValidation)
+	}
+
+	// Output format. some commands shadow --output with their own flag (e.g. 'secrets substitute')
+	if outputFlag := cmd.Flags().Lookup("output"); outputFlag != nil && outputFlag == cmd.Root().PersistentFlags().Lookup("output") && outputFlag.Changed {
+		format, template, err := utils.ParseOutputFormat(outputFlag.Value.String())
+		if err != nil {
+			utils.ErrExit(err, utils.ExitCodeValidation)
+		}
+		if utils.OutputJSON && format != utils.OutputFormatJSON {
+			utils.OutputJSON = false
+			utils.ErrExit(fmt.Errorf("--json cannot be used with --output=%s", format), utils.ExitCodeValidation)
+		}
+		utils.OutputFormat = format
+		utils.OutputTemplate = template
+	} else if utils.OutputJSON {
+		utils.OutputFormat = utils.OutputFormatJSON
+	}
+	utils.OutputJSON = utils.IsStructuredOutputFormat(utils.OutputFormat)
+
 	// User Config Dir
 	if configuration.CanReadEnv {
 		userConfigDir := os.Getenv("DOPPLER_CONFIG_DIR")
@@ -121,7 +158,9 @@ func loadFlags(cmd *cobra.Command) {
 			configuration.SetConfigDir(userConfigDir)
 		}
 	}
-	configuration.SetConfigDir(utils.GetPathFlagIfChanged(cmd, "config-dir", configuration.UserConfigDir))
+	if cmd.Flags().Changed("config-dir") {
+		configuration.SetConfigDir(utils.GetPathFlagIfChanged(cmd, "config-dir", configuration.UserConfigDir))
+	}
 	configuration.UserConfigFile = utils.GetPathFlagIfChanged(cmd, "configuration", configuration.UserConfigFile)
 	http.UseTimeout = !utils.GetBoolFlag(cmd, "no-timeout")
 
@@ -137,6 +176,30 @@ func loadFlags(cmd *cobra.Command) {
 	// flag takes precedence over env var
 	http.UseCustomDNSResolver = utils.GetBoolFlagIfChanged(cmd, "enable-dns-resolver", http.UseCustomDNSResolver)
 
+	// OpenTelemetry
+	if configuration.CanReadEnv {
+		enableOTel := os.Getenv("DOPPLER_ENABLE_OTEL")
+		if enableOTel == "true" {
+			telemetry.Enabled = true
+		} else if enableOTel == "false" {
+			telemetry.Enabled = false
+		}
+	}
+	// flag takes precedence over env var
+	telemetry.Enabled = utils.GetBoolFlagIfChanged(cmd, "enable-otel", telemetry.Enabled)
+
+	// HTTP record/replay. these are used for testing and are always read from the environment
+	http.RecordDir = os.Getenv("DOPPLER_HTTP_RECORD")
+	http.ReplayDir = os.Getenv("DOPPLER_HTTP_REPLAY")
+	if http.RecordDir != "" && http.ReplayDir != "" {
+		utils.ErrExit(errors.New("Conflict: unable to specify both DOPPLER_HTTP_RECORD and DOPPLER_HTTP_REPLAY"), utils.ExitCodeValidation)
+	}
+	if http.ReplayDir != "" {
+		utils.LogDebug(fmt.Sprintf("Replaying HTTP responses from %s", http.ReplayDir))
+	} else if http.RecordDir != "" {
+		utils.LogDebug(fmt.Sprintf("Recording HTTP responses to %s", http.RecordDir))
+	}
+
 	// no-file is used by the 'secrets download' command to output secrets to stdout
 	utils.Silent = utils.GetBoolFlagIfChanged(cmd, "no-file", utils.Silent)
 }
@@ -157,7 +220,7 @@ func Execute() {
 		if !version.IsDevelopment() {
 			if err := recover(); err != nil {
 				utils.Log(fmt.Sprintf("%s %v\n", color.Red.Render("Doppler Exception:"), err))
-				os.Exit(1)
+				utils.Exit(1)
 			}
 		}
 	}()
@@ -170,9 +233,12 @@ func Execute() {
 	// wait for group before checking error
 	global.WaitGroup.Wait()
 
+	// commands handle their own errors, so any error returned here is from invalid flags or args
 	if err != nil {
-		os.Exit(1)
+		utils.Exit(utils.ExitCodeValidation)
 	}
+
+	telemetry.Shutdown(0)
 }
 
 func init() {
@@ -183,11 +249,16 @@ func init() {
 	rootCmd.PersistentFlags().StringP("token", "t", "", "doppler token")
 	rootCmd.PersistentFlags().String("api-host", "https://api.doppler.com", "The host address for the Doppler API")
 	rootCmd.PersistentFlags().String("dashboard-host", "https://dashboard.doppler.com", "The host address for the Doppler Dashboard")
+	rootCmd.PersistentFlags().String("profile", "", "the profile to use. may also be specified via DOPPLER_PROFILE")
+	if err := rootCmd.RegisterFlagCompletionFunc("profile", profileNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
 	rootCmd.PersistentFlags().Bool("no-check-version", !version.PerformVersionCheck, "disable checking for Doppler CLI updates")
 	rootCmd.PersistentFlags().Bool("no-verify-tls", false, "do not verify the validity of TLS certificates on HTTP requests (not recommended)")
 	rootCmd.PersistentFlags().Bool("no-timeout", !http.UseTimeout, "disable http timeout")
 	rootCmd.PersistentFlags().DurationVar(&http.TimeoutDuration, "timeout", http.TimeoutDuration, "max http request duration")
 	rootCmd.PersistentFlags().IntVar(&http.RequestAttempts, "attempts", http.RequestAttempts, "number of http request attempts made before failing")
+	rootCmd.PersistentFlags().IntVar(&http.MaxConnsPerHost, "max-connections", http.MaxConnsPerHost, "max number of concurrent http connections per host (0 for no limit)")
 	// DNS resolver
 	rootCmd.PersistentFlags().Bool("no-dns-resolver", !http.UseCustomDNSResolver, "use the OS's default DNS resolver")
 	if err := rootCmd.PersistentFlags().MarkDeprecated("no-dns-resolver", "the DNS resolver is disabled by default"); err != nil {
@@ -201,6 +272,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&http.DNSResolverProto, "dns-resolver-proto", http.DNSResolverProto, "protocol to use for DNS resolution")
 	rootCmd.PersistentFlags().DurationVar(&http.DNSResolverTimeout, "dns-resolver-timeout", http.DNSResolverTimeout, "max dns lookup duration")
 
+	rootCmd.PersistentFlags().Bool("enable-otel", telemetry.Enabled, "export OpenTelemetry traces and metrics to the endpoint specified by OTEL_EXPORTER_OTLP_ENDPOINT")
+
 	rootCmd.PersistentFlags().Bool("no-read-env", false, "do not read config from the environment")
 	rootCmd.PersistentFlags().String("scope", configuration.Scope, "the directory to scope your config to")
 	rootCmd.PersistentFlags().String("config-dir", configuration.UserConfigDir, "config directory")
@@ -211,7 +284,28 @@ func init() {
 	if err := rootCmd.PersistentFlags().MarkHidden("configuration"); err != nil {
 		utils.HandleError(err)
 	}
-	rootCmd.PersistentFlags().BoolVar(&utils.OutputJSON, "json", utils.OutputJSON, "output json")
+	rootCmd.PersistentFlags().BoolVar(&utils.OutputJSON, "json", utils.OutputJSON, "output json. alias for --output=json")
+	rootCmd.PersistentFlags().StringP("output", "o", utils.OutputFormat, fmt.Sprintf("output format. one of [%s]. the template format is specified as template=<go template>", strings.Join(utils.OutputFormats, ", ")))
+	if err := rootCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return utils.OutputFormats, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		utils.HandleError(err)
+	}
+	rootCmd.PersistentFlags().StringSliceVar(&utils.OutputColumns, "columns", utils.OutputColumns, "comma-separated list of columns to include in table output (e.g. name,value,note)")
+	if err := rootCmd.RegisterFlagCompletionFunc("columns", columnsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	rootCmd.PersistentFlags().StringVar(&utils.OutputSortBy, "sort-by", utils.OutputSortBy, "column to sort table output by")
+	if err := rootCmd.RegisterFlagCompletionFunc("sort-by", sortByValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	rootCmd.PersistentFlags().BoolVar(&utils.OutputReverse, "reverse", utils.OutputReverse, "reverse the order of table output")
+	rootCmd.PersistentFlags().String("log-format", utils.LogFormat, fmt.Sprintf("format of log messages written to stderr. one of [%s]", strings.Join(utils.LogFormats, ", ")))
+	if err := rootCmd.RegisterFlagCompletionFunc("log-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return utils.LogFormats, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		utils.HandleError(err)
+	}
 	rootCmd.PersistentFlags().BoolVar(&utils.Debug, "debug", utils.Debug, "output additional information")
 	rootCmd.PersistentFlags().BoolVar(&printConfig, "print-config", printConfig, "output active configuration")
 	rootCmd.PersistentFlags().BoolVar(&utils.Silent, "silent", utils.Silent, "disable output of info messages")
@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -51,6 +52,10 @@ var runCmd = &cobra.Command{
 	Long: `Run a command with secrets injected into the environment.
 Secrets can also be mounted to an ephemeral file using the --mount flag.
 
+If no command is specified, the command from the repo config file (doppler.yaml) is used. The name transformer
+and mount settings from the repo config file are used unless the corresponding flags are specified. The repo
+config file is searched for in the current directory and its parents, up to the git root.
+
 To view the CLI's active configuration, run ` + "`doppler configure debug`",
 	Example: `doppler run -- YOUR_COMMAND --YOUR-FLAG
 doppler run --command "YOUR_COMMAND && YOUR_OTHER_COMMAND"
@@ -67,13 +72,19 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			if len(args) > 0 {
 				return errors.New("arg(s) may not be set when using --command flag")
 			}
-		} else if len(args) == 0 {
+		} else if len(args) == 0 && repoRunConfig(cmd).Command == "" {
 			return errors.New("requires at least 1 arg(s), received 0")
 		}
 
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		repoDefaults := repoRunDefaults(cmd, len(args) == 0)
+		command := cmd.Flag("command").Value.String()
+		if repoDefaults.Command != "" {
+			command = repoDefaults.Command
+		}
+
 		enableFallback := !utils.GetBoolFlag(cmd, "no-fallback")
 		enableCache := enableFallback && !utils.GetBoolFlag(cmd, "no-cache")
 		fallbackReadonly := utils.GetBoolFlag(cmd, "fallback-readonly")
@@ -91,38 +102,95 @@ doppler run --mount secrets.json -- cat secrets.json`,
 		localConfig := configuration.LocalConfig(cmd)
 		dynamicSecretsTTL := utils.GetDurationFlag(cmd, "dynamic-ttl")
 		exitOnMissingIncludedSecrets := !cmd.Flags().Changed("no-exit-on-missing-only-secrets")
+		bundlePath := cmd.Flag("bundle").Value.String()
+		identityPath := cmd.Flag("identity").Value.String()
+		useBundle := bundlePath != ""
 
-		utils.RequireValue("token", localConfig.Token.Value)
+		if useBundle {
+			if identityPath == "" {
+				utils.ErrExit(errors.New("you must specify an identity file with --identity when using --bundle"), utils.ExitCodeValidation)
+			}
+			if cmd.Flags().Changed("name-transformer") {
+				utils.ErrExit(errors.New("--name-transformer cannot be used with --bundle"), utils.ExitCodeValidation)
+			}
+			// bundles are used in environments without access to Doppler
+			enableFallback = false
+			enableCache = false
+			enableLivenessPing = false
+		} else {
+			if identityPath != "" {
+				utils.LogWarning("--identity has no effect without --bundle")
+			}
+			utils.RequireValue("token", localConfig.Token.Value)
+		}
+
+		// refresh the token of an OIDC session before it expires, so that long-running processes stay authenticated.
+		// the original token is still used to derive the fallback file's path and passphrase
+		var oidcRefresher *controllers.OIDCTokenRefresher
+		if !useBundle && !fallbackOnly {
+			oidcRefresher = controllers.NewOIDCTokenRefresher(localConfig)
+		}
+		if oidcRefresher != nil {
+			if oidcRefresher.NeedsRefresh() {
+				if err := oidcRefresher.Refresh(); !err.IsNil() {
+					utils.LogDebugError(err.Unwrap())
+					utils.LogWarning("Unable to refresh OIDC token")
+				}
+			}
+			oidcRefresher.Start()
+		}
+		// the config to use for API requests, with the current token
+		apiConfig := func() models.ScopedOptions {
+			config := localConfig
+			if oidcRefresher != nil {
+				config.Token.Value = oidcRefresher.Token()
+			}
+			return config
+		}
 
 		if cmd.Flags().Changed("only-secrets") && len(secretsToInclude) == 0 {
-			utils.HandleError(fmt.Errorf("you must specify secrets when using --only-secrets"))
+			utils.ErrExit(fmt.Errorf("you must specify secrets when using --only-secrets"), utils.ExitCodeValidation)
 		}
 
 		nameTransformerString := cmd.Flag("name-transformer").Value.String()
+		if repoDefaults.NameTransformer != "" {
+			nameTransformerString = repoDefaults.NameTransformer
+		}
 		var nameTransformer *models.SecretsNameTransformer
 		if nameTransformerString != "" {
 			nameTransformer = models.SecretsNameTransformerMap[nameTransformerString]
 			if nameTransformer == nil || !nameTransformer.EnvCompat {
-				utils.HandleError(fmt.Errorf("invalid name transformer. Valid transformers are %s", validEnvCompatNameTransformersList))
+				utils.ErrExit(fmt.Errorf("invalid name transformer. Valid transformers are %s", validEnvCompatNameTransformersList), utils.ExitCodeValidation)
 			}
 		}
 
-		passphrase := getPassphrase(cmd, "passphrase", localConfig)
-		if passphrase == "" {
-			utils.HandleError(errors.New("invalid passphrase"))
+		passphrase := ""
+		if !useBundle {
+			passphrase = getPassphrase(cmd, "passphrase", localConfig)
+			if passphrase == "" {
+				utils.ErrExit(errors.New("invalid passphrase"), utils.ExitCodeValidation)
+			}
 		}
 
 		mountPath := cmd.Flag("mount").Value.String()
+		if repoDefaults.Mount != "" {
+			mountPath = repoDefaults.Mount
+		}
 		// --format is the primary flag, --mount-format is a deprecated alias
 		mountFormatString := cmd.Flag("format").Value.String()
 		if cmd.Flags().Changed("mount-format") && !cmd.Flags().Changed("format") {
 			// Use --mount-format value if specified and --format was not
 			mountFormatString = cmd.Flag("mount-format").Value.String()
+		} else if repoDefaults.Format != "" {
+			mountFormatString = repoDefaults.Format
 		}
 		mountTemplate := cmd.Flag("mount-template").Value.String()
+		if repoDefaults.MountTemplate != "" {
+			mountTemplate = repoDefaults.MountTemplate
+		}
 		maxReads := utils.GetIntFlag(cmd, "mount-max-reads", 32)
 		// only auto-detect the format if it hasn't been explicitly specified
-		shouldAutoDetectFormat := !cmd.Flags().Changed("format") && !cmd.Flags().Changed("mount-format")
+		shouldAutoDetectFormat := !cmd.Flags().Changed("format") && !cmd.Flags().Changed("mount-format") && repoDefaults.Format == ""
 		shouldMountFile := mountPath != ""
 		shouldMountTemplate := mountTemplate != ""
 
@@ -130,7 +198,7 @@ doppler run --mount secrets.json -- cat secrets.json`,
 		if models.IsValidMountFormat(mountFormatString) {
 			mountFormat = mountFormatString
 		} else {
-			utils.HandleError(fmt.Errorf("Invalid mount format. Valid formats are %s", models.SecretsMountFormats))
+			utils.ErrExit(fmt.Errorf("Invalid mount format. Valid formats are %s", models.SecretsMountFormats), utils.ExitCodeValidation)
 		}
 
 		if preserveEnv != "false" {
@@ -142,7 +210,7 @@ doppler run --mount secrets.json -- cat secrets.json`,
 		}
 
 		if shouldMountTemplate && !shouldMountFile {
-			utils.HandleError(errors.New("--mount-template must be used with --mount"))
+			utils.ErrExit(errors.New("--mount-template must be used with --mount"), utils.ExitCodeValidation)
 		}
 
 		var templateBody string
@@ -171,11 +239,11 @@ doppler run --mount secrets.json -- cat secrets.json`,
 
 			if shouldMountTemplate {
 				if mountFormat != models.TemplateMountFormat {
-					utils.HandleError(errors.New("--mount-template can only be used with --format=template"))
+					utils.ErrExit(errors.New("--mount-template can only be used with --format=template"), utils.ExitCodeValidation)
 				}
 				templateBody = controllers.ReadTemplateFile(mountTemplate)
 			} else if mountFormat == models.TemplateMountFormat {
-				utils.HandleError(errors.New("--mount-template must be specified when using --format=template"))
+				utils.ErrExit(errors.New("--mount-template must be specified when using --format=template"), utils.ExitCodeValidation)
 			}
 		}
 
@@ -199,8 +267,8 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			metadataPath = controllers.MetadataFilePath(localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, format, nameTransformer, secretsToInclude)
 		}
 
-		if !enableFallback {
-			flags := []string{"fallback", "fallback-only", "offline", "fallback-readonly", "no-exit-on-write-failure", "passphrase"}
+		if !enableFallback && !useBundle {
+			flags := []string{"fallback", "fallback-only", "offline", "fallback-readonly", "no-exit-on-write-failure", "passphrase", "fallback-max-age", "fallback-stale"}
 			for _, flag := range flags {
 				if cmd.Flags().Changed(flag) {
 					utils.LogWarning(fmt.Sprintf("--%s has no effect when the fallback file is disabled", flag))
@@ -221,6 +289,10 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			ExclusiveFlag:      fallbackFlag,
 			ExitOnWriteFailure: exitOnWriteFailure,
 			Passphrase:         passphrase,
+			KDF:                getFallbackKDF(localConfig),
+			MaxAge:             utils.GetDurationFlag(cmd, "fallback-max-age"),
+			StaleAction:        getFallbackStaleAction(cmd),
+			Token:              localConfig.Token.Value,
 		}
 
 		mountOptions := controllers.MountOptions{
@@ -238,6 +310,34 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			watch = false
 		}
 
+		var bundleSecrets []byte
+		if useBundle {
+			if shouldMountFile && format != models.JSON {
+				utils.ErrExit(errors.New("--bundle only supports the json and template mount formats"), utils.ExitCodeValidation)
+			}
+			if watch {
+				utils.LogWarning("--watch has no effect when used with --bundle")
+				watch = false
+			}
+
+			bundle := readBundle(bundlePath, identityPath)
+			secrets := bundle.Secrets
+			// filter the secrets locally, as the API would have done
+			if len(secretsToInclude) > 0 {
+				secrets = map[string]string{}
+				for _, name := range secretsToInclude {
+					if value, ok := bundle.Secrets[name]; ok {
+						secrets[name] = value
+					}
+				}
+			}
+			secretsJSON, err := json.Marshal(secrets)
+			if err != nil {
+				utils.HandleError(err, "Unable to marshal bundle secrets")
+			}
+			bundleSecrets = secretsJSON
+		}
+
 		var c *exec.Cmd
 		var cleanupMount func()
 		var err error
@@ -258,7 +358,7 @@ doppler run --mount secrets.json -- cat secrets.json`,
 				for {
 					select {
 					case <-ticker.C:
-						_, err := controllers.LivenessPing(localConfig)
+						_, err := controllers.LivenessPing(apiConfig())
 						if !err.IsNil() {
 							// If we fail the liveness ping, we'll just log it for debugging, but it's likely an intermittent
 							// connectivity error. We'll allow the ticker to continue.
@@ -270,8 +370,15 @@ doppler run --mount secrets.json -- cat secrets.json`,
 		}
 
 		startProcess := func() {
-			// Fetch secrets (returns raw bytes, supports caching/fallback for all formats)
-			secretsBytes, fromCache := controllers.FetchSecrets(localConfig, enableCache, fallbackOpts, metadataPath, nameTransformer, dynamicSecretsTTL, format, secretsToInclude)
+			var secretsBytes []byte
+			var fromCache bool
+			if useBundle {
+				secretsBytes = bundleSecrets
+				fromCache = true
+			} else {
+				// Fetch secrets (returns raw bytes, supports caching/fallback for all formats)
+				secretsBytes, fromCache, _ = controllers.FetchSecrets(apiConfig(), enableCache, fallbackOpts, metadataPath, nameTransformer, dynamicSecretsTTL, format, secretsToInclude)
+			}
 
 			secretsFetchedAt := time.Now()
 			if secretsFetchedAt.After(lastSecretsFetch) {
@@ -358,7 +465,7 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			}
 
 			// start the process
-			c, err = controllers.Run(cmd, args, env, forwardSignals)
+			c, err = controllers.Run(command, args, env, forwardSignals)
 			if err != nil {
 				defer global.WaitGroup.Done()
 				if cleanupMount != nil {
@@ -389,7 +496,7 @@ doppler run --mount secrets.json -- cat secrets.json`,
 						utils.LogDebugError(err)
 					}
 
-					os.Exit(exitCode)
+					utils.Exit(exitCode)
 				}
 			}()
 		}
@@ -456,7 +563,7 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			var watchConnectionHandler func()
 
 			watchConnectionHandler = func() {
-				statusCode, headers, httpErr := http.WatchSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, watchHandler)
+				statusCode, headers, httpErr := http.WatchSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), apiConfig().Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, watchHandler)
 
 				if !httpErr.IsNil() {
 					e := httpErr.Unwrap()
@@ -540,7 +647,7 @@ var runCleanCmd = &cobra.Command{
 		}
 
 		for _, entry := range entries {
-			if entry.IsDir() {
+			if entry.IsDir() || controllers.IsFallbackIndexFile(entry.Name()) {
 				continue
 			}
 
@@ -603,11 +710,89 @@ func getPassphrase(cmd *cobra.Command, flag string, config models.ScopedOptions)
 		}
 	}
 
-	if config.EnclaveProject.Value != "" && config.EnclaveConfig.Value != "" {
-		return fmt.Sprintf("%s:%s:%s", config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value)
+	if controllers.FallbackKeyringEnabled(config) {
+		key, err := controllers.FallbackKey()
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+		return key
 	}
 
-	return config.Token.Value
+	return controllers.DefaultFallbackPassphrase(config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value)
+}
+
+// getFallbackKDF the key derivation function to use for encrypting fallback files
+func getFallbackKDF(config models.ScopedOptions) string {
+	kdf := config.FallbackKDF.Value
+	if kdf == "" {
+		return models.Pbkdf2KDF
+	}
+
+	if !models.IsValidKDF(kdf) {
+		utils.ErrExit(fmt.Errorf("invalid fallback key derivation function. Valid functions are %v", models.KDFs), utils.ExitCodeValidation)
+	}
+	return kdf
+}
+
+// getFallbackStaleAction the action to take when the fallback file exceeds its max age
+func getFallbackStaleAction(cmd *cobra.Command) string {
+	action := cmd.Flag("fallback-stale").Value.String()
+	if !models.IsValidFallbackStaleAction(action) {
+		utils.ErrExit(fmt.Errorf("invalid value for --fallback-stale. Valid values are %v", models.FallbackStaleActions), utils.ExitCodeValidation)
+	}
+	return action
+}
+
+// the run settings from the repo config file, read at most once per invocation
+var cachedRepoRunConfig *models.RunConfig
+
+// repoRunConfig the run settings from the repo config file
+func repoRunConfig(cmd *cobra.Command) models.RunConfig {
+	if cachedRepoRunConfig == nil {
+		runConfig := controllers.RepoRunConfig(utils.Cwd(), cmd.Flag("repo-config").Value.String())
+		cachedRepoRunConfig = &runConfig
+	}
+	return *cachedRepoRunConfig
+}
+
+// repoRunDefaults the run settings from the repo config file that apply, as the corresponding flags weren't specified
+func repoRunDefaults(cmd *cobra.Command, useCommand bool) models.RunConfig {
+	runConfig := repoRunConfig(cmd)
+
+	var defaults models.RunConfig
+	if useCommand && !cmd.Flags().Changed("command") {
+		defaults.Command = runConfig.Command
+	}
+	// name transformers aren't supported with bundles
+	if !cmd.Flags().Changed("bundle") && !cmd.Flags().Changed("name-transformer") {
+		defaults.NameTransformer = runConfig.NameTransformer
+	}
+	// the format and template only apply to the repo config's mount
+	if !cmd.Flags().Changed("mount") && runConfig.Mount != "" {
+		defaults.Mount = runConfig.Mount
+		if !cmd.Flags().Changed("format") && !cmd.Flags().Changed("mount-format") {
+			defaults.Format = runConfig.Format
+		}
+		if !cmd.Flags().Changed("mount-template") {
+			defaults.MountTemplate = runConfig.MountTemplate
+		}
+	}
+
+	for _, flag := range [][2]string{{"command", defaults.Command}, {"name-transformer", defaults.NameTransformer}, {"mount", defaults.Mount}, {"format", defaults.Format}, {"mount-template", defaults.MountTemplate}} {
+		if flag[1] != "" {
+			utils.LogDebug(fmt.Sprintf("Using --%s from repo config file", flag[0]))
+		}
+	}
+
+	return defaults
+}
+
+func fallbackStaleValidArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return models.FallbackStaleActions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func fallbackKDFValidArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return models.KDFs, cobra.ShellCompDirectiveNoFileComp
 }
 
 func initFallbackDir(cmd *cobra.Command, config models.ScopedOptions, format models.SecretsFormat, nameTransformer *models.SecretsNameTransformer, secretNames []string, exitOnWriteFailure bool) (string, string) {
@@ -628,8 +813,8 @@ func initFallbackDir(cmd *cobra.Command, config models.ScopedOptions, format mod
 			legacyFallbackPath = legacyFallbackFile(config.EnclaveProject.Value, config.EnclaveConfig.Value)
 		}
 
-		err := os.Mkdir(configuration.UserFallbackDir, 0700)
-		if err != nil && !os.IsExist(err) {
+		err := os.MkdirAll(configuration.UserFallbackDir, 0700)
+		if err != nil {
 			utils.LogDebug("Unable to create directory for fallback file")
 			if exitOnWriteFailure {
 				utils.HandleError(err, "Unable to create directory for fallback file", strings.Join(controllers.WriteFailureMessage(), "\n"))
@@ -679,12 +864,24 @@ func init() {
 	runCmd.Flags().String("fallback", "", "path to the fallback file. encrypted secrets are written to this file after each successful fetch. secrets will be read from this file if subsequent connections are unsuccessful.")
 	// TODO rename this to 'fallback-passphrase' in CLI v4 (DPLR-435)
 	runCmd.Flags().String("passphrase", "", "passphrase to use for encrypting the fallback file. the default passphrase is computed using your current configuration.")
+	runCmd.Flags().String("fallback-kdf", "", fmt.Sprintf("key derivation function to use for encrypting the fallback file. one of %v (default \"%s\")", models.KDFs, models.Pbkdf2KDF))
+	if err := runCmd.RegisterFlagCompletionFunc("fallback-kdf", fallbackKDFValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	runCmd.Flags().Duration("fallback-max-age", 0, "refuse to read secrets from a fallback file written longer ago than this duration (e.g. '24h'). 0 disables the check")
+	runCmd.Flags().String("fallback-stale", models.FallbackStaleFail, fmt.Sprintf("action to take when the fallback file exceeds --fallback-max-age. one of %v", models.FallbackStaleActions))
+	if err := runCmd.RegisterFlagCompletionFunc("fallback-stale", fallbackStaleValidArgs); err != nil {
+		utils.HandleError(err)
+	}
 	runCmd.Flags().Bool("no-cache", false, "disable using the fallback file to speed up fetches. the fallback file is only used when the API indicates that it's still current.")
 	runCmd.Flags().Bool("no-fallback", false, "disable reading and writing the fallback file (implies --no-cache)")
 	runCmd.Flags().Bool("fallback-readonly", false, "disable modifying the fallback file. secrets can still be read from the file.")
 	runCmdFallbackOnly := runCmd.Flags().Bool("fallback-only", false, "read all secrets directly from the fallback file, without contacting Doppler. secrets will not be updated. (implies --fallback-readonly and --no-liveness-ping)")
 	runCmd.Flags().BoolVar(runCmdFallbackOnly, "offline", false, "alias for --fallback-only")
 	runCmd.Flags().Bool("no-exit-on-write-failure", false, "do not exit if unable to write the fallback file")
+	// bundle flags
+	runCmd.Flags().String("bundle", "", "read secrets from an offline bundle created by 'doppler bundle create', without contacting Doppler. implies --no-fallback")
+	runCmd.Flags().String("identity", "", "path to an age identity file used to decrypt the bundle")
 	runCmd.Flags().Bool("forward-signals", forwardSignals, "forward signals to the child process (defaults to false when STDOUT is a TTY)")
 	runCmd.Flags().Bool("no-liveness-ping", false, "disable the periodic liveness ping")
 	// secrets mount flags
@@ -705,6 +902,7 @@ func init() {
 	runCmd.Flags().Int("mount-max-reads", 0, "maximum number of times the mounted secrets file can be read (0 for unlimited)")
 	runCmd.Flags().StringSliceVar(&secretsToInclude, "only-secrets", []string{}, "only include the specified secrets")
 	runCmd.Flags().Bool("no-exit-on-missing-only-secrets", false, "do not exit on missing secrets via --only-secrets")
+	runCmd.Flags().String("repo-config", "", "path to the repo config file. by default, doppler.yaml is searched for in the current directory and its parents, up to the git root")
 	// we only restart the process if it hasn't already exited
 	runCmd.Flags().Bool("watch", false, "(BETA) automatically restart the process when secrets change")
 
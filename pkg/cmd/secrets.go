@@ -43,8 +43,10 @@ type secretsResponse struct {
 var secretsCmd = &cobra.Command{
 	Use:   "secrets",
 	Short: "Manage secrets",
-	Args:  cobra.NoArgs,
-	Run:   secrets,
+	Example: `doppler secrets
+doppler secrets --columns name,value,raw-value,note --sort-by note`,
+	Args: cobra.NoArgs,
+	Run:  secrets,
 }
 
 var secretsGetCmd = &cobra.Command{
@@ -236,7 +238,7 @@ func getSecrets(cmd *cobra.Command, args []string) {
 			if len(missingSecrets) == 1 {
 				pluralized = "secret"
 			}
-			utils.HandleError(fmt.Errorf("Could not find requested %s: %s", pluralized, strings.Join(missingSecrets, ", ")))
+			utils.ErrExit(fmt.Errorf("Could not find requested %s: %s", pluralized, strings.Join(missingSecrets, ", ")), utils.ExitCodeNotFound)
 		}
 	}
 
@@ -274,7 +276,7 @@ func setSecrets(cmd *cobra.Command, args []string) {
 		interactiveMode := !hasData
 		if interactiveMode {
 			if !canPromptUser {
-				utils.HandleError(errors.New("Secret value must be provided when using --no-interactive"))
+				utils.ErrExit(errors.New("Secret value must be provided when using --no-interactive"), utils.ExitCodeValidation)
 			}
 
 			utils.Print("Enter your secret value")
@@ -489,7 +491,7 @@ func downloadSecrets(cmd *cobra.Command, args []string) {
 		}
 
 		if !isValid {
-			utils.HandleError(fmt.Errorf("invalid format. Valid formats are %s", validFormatList))
+			utils.ErrExit(fmt.Errorf("invalid format. Valid formats are %s", validFormatList), utils.ExitCodeValidation)
 		}
 	}
 
@@ -498,13 +500,13 @@ func downloadSecrets(cmd *cobra.Command, args []string) {
 	if nameTransformerString != "" {
 		nameTransformer = models.SecretsNameTransformerMap[nameTransformerString]
 		if nameTransformer == nil {
-			utils.HandleError(fmt.Errorf("invalid name transformer. Valid transformers are %s", validNameTransformersList))
+			utils.ErrExit(fmt.Errorf("invalid name transformer. Valid transformers are %s", validNameTransformersList), utils.ExitCodeValidation)
 		}
 	}
 
 	fallbackPassphrase := getPassphrase(cmd, "fallback-passphrase", localConfig)
 	if fallbackPassphrase == "" {
-		utils.HandleError(errors.New("invalid fallback file passphrase"))
+		utils.ErrExit(errors.New("invalid fallback file passphrase"), utils.ExitCodeValidation)
 	}
 
 	if fallbackOnly && cmd.Flags().Changed("format") {
@@ -530,10 +532,17 @@ func downloadSecrets(cmd *cobra.Command, args []string) {
 		ExclusiveFlag:      fallbackFlag,
 		ExitOnWriteFailure: exitOnWriteFailure,
 		Passphrase:         fallbackPassphrase,
+		KDF:                getFallbackKDF(localConfig),
+		MaxAge:             utils.GetDurationFlag(cmd, "fallback-max-age"),
+		StaleAction:        getFallbackStaleAction(cmd),
 	}
 
 	// FetchSecrets returns raw bytes and supports caching/fallback for all formats
-	body, _ := controllers.FetchSecrets(localConfig, enableCache, fallbackOpts, metadataPath, nameTransformer, dynamicSecretsTTL, format, nil)
+	body, _, usedFallback := controllers.FetchSecrets(localConfig, enableCache, fallbackOpts, metadataPath, nameTransformer, dynamicSecretsTTL, format, nil)
+	// the secrets are still output, but the exit code indicates that they may be out of date
+	if usedFallback && utils.GetBoolFlag(cmd, "exit-code-on-fallback") {
+		defer utils.Exit(utils.ExitCodeFallbackUsed)
+	}
 
 	if !saveFile {
 		utils.Print(string(body))
@@ -555,7 +564,7 @@ func downloadSecrets(cmd *cobra.Command, args []string) {
 
 	passphrase := getPassphrase(cmd, "passphrase", localConfig)
 	if passphrase == "" {
-		utils.HandleError(errors.New("invalid passphrase"))
+		utils.ErrExit(errors.New("invalid passphrase"), utils.ExitCodeValidation)
 	}
 
 	encryptedBody, err := crypto.Encrypt(passphrase, body, "base64")
@@ -575,7 +584,7 @@ func substituteSecrets(cmd *cobra.Command, args []string) {
 
 	useEnv := cmd.Flag("use-env").Value.String()
 	if !slices.Contains(validUseEnvSettings, useEnv) {
-		utils.HandleError(fmt.Errorf("invalid use-env option. Valid options are %s", validUseEnvSettingsList))
+		utils.ErrExit(fmt.Errorf("invalid use-env option. Valid options are %s", validUseEnvSettingsList), utils.ExitCodeValidation)
 	}
 
 	if useEnv != "only" {
@@ -747,10 +756,20 @@ func init() {
 	secretsDownloadCmd.Flags().Bool("no-cache", false, "disable using the fallback file to speed up fetches. the fallback file is only used when the API indicates that it's still current.")
 	secretsDownloadCmd.Flags().Bool("no-fallback", false, "disable reading and writing the fallback file")
 	secretsDownloadCmd.Flags().String("fallback-passphrase", "", "passphrase to use for encrypting the fallback file. by default the passphrase is computed using your current configuration.")
+	secretsDownloadCmd.Flags().String("fallback-kdf", "", fmt.Sprintf("key derivation function to use for encrypting the fallback file. one of %v (default \"%s\")", models.KDFs, models.Pbkdf2KDF))
+	if err := secretsDownloadCmd.RegisterFlagCompletionFunc("fallback-kdf", fallbackKDFValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsDownloadCmd.Flags().Duration("fallback-max-age", 0, "refuse to read secrets from a fallback file written longer ago than this duration (e.g. '24h'). 0 disables the check")
+	secretsDownloadCmd.Flags().String("fallback-stale", models.FallbackStaleFail, fmt.Sprintf("action to take when the fallback file exceeds --fallback-max-age. one of %v", models.FallbackStaleActions))
+	if err := secretsDownloadCmd.RegisterFlagCompletionFunc("fallback-stale", fallbackStaleValidArgs); err != nil {
+		utils.HandleError(err)
+	}
 	secretsDownloadCmd.Flags().Bool("fallback-readonly", false, "disable modifying the fallback file. secrets can still be read from the file.")
 	secretsDownloadCmdFallbackOnly := secretsDownloadCmd.Flags().Bool("fallback-only", false, "read all secrets directly from the fallback file, without contacting Doppler. secrets will not be updated. (implies --fallback-readonly)")
 	secretsDownloadCmd.Flags().BoolVar(secretsDownloadCmdFallbackOnly, "offline", false, "alias for --fallback-only")
 	secretsDownloadCmd.Flags().Bool("no-exit-on-write-failure", false, "do not exit if unable to write the fallback file")
+	secretsDownloadCmd.Flags().Bool("exit-code-on-fallback", false, fmt.Sprintf("exit with code %d when secrets are read from the fallback file because the Doppler API is unavailable", utils.ExitCodeFallbackUsed))
 	secretsCmd.AddCommand(secretsDownloadCmd)
 
 	secretsSubstituteCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
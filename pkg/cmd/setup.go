@@ -34,16 +34,38 @@ import (
 var setupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Setup the Doppler CLI for managing secrets",
-	Args:  cobra.NoArgs,
-	Run:   setup,
+	Long: `Setup the Doppler CLI for managing secrets.
+
+If a repo config file (doppler.yaml) exists in the current directory or one of its parents (up to the git root),
+it's validated against the schema and used to select the project and config for each path. Paths in the repo
+config file are relative to the directory containing it. Use --repo-config to specify the file explicitly. The repo config file can also specify a config
+for each git branch pattern, secrets that must exist in the config, defaults for 'doppler run', and
+post-setup hooks. Hooks are only run after confirmation, or when --run-hooks is specified.
+
+Use --check to verify that the local config matches the repo config file without changing anything.`,
+	Example: `doppler setup
+doppler setup --check`,
+	Args: cobra.NoArgs,
+	Run:  setup,
 }
 
 func setup(cmd *cobra.Command, args []string) {
 	canPromptUser := !utils.GetBoolFlag(cmd, "no-prompt") && !utils.GetBoolFlag(cmd, "no-interactive")
 	canSaveToken := !utils.GetBoolFlag(cmd, "no-save-token")
+	runHooks := utils.GetBoolFlag(cmd, "run-hooks")
+	repoConfigFile := cmd.Flag("repo-config").Value.String()
 	localConfig := configuration.LocalConfig(cmd)
 	scopedConfig := configuration.Get(configuration.Scope)
 
+	if validationErr := controllers.ValidateRepoConfig(repoConfigFile); !validationErr.IsNil() {
+		utils.HandleError(validationErr.Unwrap(), validationErr.Message)
+	}
+
+	if utils.GetBoolFlag(cmd, "check") {
+		checkSetup(localConfig, repoConfigFile)
+		return
+	}
+
 	utils.RequireValue("token", localConfig.Token.Value)
 
 	saveToken := false
@@ -58,27 +80,33 @@ func setup(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	repoConfig, err := controllers.RepoConfig()
+	repoConfig, err := controllers.RepoConfig(repoConfigFile)
 	if !err.IsNil() {
 		utils.Log(err.Message)
 		utils.LogDebugError(err.Unwrap())
 	}
+	if repoConfig.File != "" && filepath.Dir(repoConfig.File) != utils.Cwd() {
+		utils.Log(fmt.Sprintf("Using repo config file %s", repoConfig.File))
+	}
 
 	// do an initial pass to see if there are errors we want to bail on before attempting to proceed
 	setupFileErrorCheck(repoConfig.Setup)
 
+	var missingSecrets []string
 	for _, repo := range repoConfig.Setup {
-		expandedPath, _ := filepath.Abs(repo.Path)
+		expandedPath := controllers.RepoConfigPath(repoConfig, repo.Path)
 		scopedConfig = configuration.Get(expandedPath)
+		repo = controllers.RepoConfigForBranch(repo, expandedPath)
 
+		flagsSpecified := localConfig.EnclaveProject.Source == models.FlagSource.String() && localConfig.EnclaveConfig.Source == models.FlagSource.String()
 		ignoreRepoConfig :=
 			// ignore when repo config is blank
 			(repo.Project == "" && repo.Config == "") ||
 				// ignore when project and config are already specified
-				(localConfig.EnclaveProject.Source == models.FlagSource.String() && localConfig.EnclaveConfig.Source == models.FlagSource.String())
+				flagsSpecified
 
 		// default to true so repo config is used on --no-interactive
-		useRepoConfig := true
+		useRepoConfig := !flagsSpecified
 		if !ignoreRepoConfig && canPromptUser {
 			if len(repoConfig.Setup) > 1 && repo.Path != "" {
 				useRepoConfig = utils.ConfirmationPrompt(fmt.Sprintf("Use settings from repo config file (doppler.yaml) for %s?", expandedPath), true)
@@ -176,6 +204,21 @@ func setup(cmd *cobra.Command, args []string) {
 			}
 			printer.ScopedConfigValues(conf, valuesToPrint, models.ScopedOptionsMap(&conf), utils.OutputJSON, false, false)
 		}
+
+		// the repo config's required secrets and hooks only apply when its settings are used
+		if useRepoConfig && len(repo.RequiredSecrets) > 0 && selectedConfig != "" {
+			secretNames, httpErr := http.GetSecretNames(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, selectedProject, selectedConfig, false)
+			if !httpErr.IsNil() {
+				utils.HandleError(httpErr.Unwrap(), httpErr.Message)
+			}
+			if missing := controllers.MissingRequiredSecrets(repo.RequiredSecrets, secretNames); len(missing) > 0 {
+				missingSecrets = append(missingSecrets, fmt.Sprintf("  - %s (%s/%s): %s", expandedPath, selectedProject, selectedConfig, strings.Join(missing, ", ")))
+			}
+		}
+
+		if useRepoConfig && len(repo.Hooks.PostSetup) > 0 {
+			runPostSetupHooks(repo.Hooks.PostSetup, expandedPath, runHooks, canPromptUser)
+		}
 	}
 
 	if repoConfig.Flags.Analytics != nil {
@@ -217,6 +260,88 @@ func setup(cmd *cobra.Command, args []string) {
 		}
 		configuration.SetFlag(flag, value)
 	}
+
+	if len(missingSecrets) > 0 {
+		errorMessage := append([]string{"the following required secrets are missing:"}, missingSecrets...)
+		utils.HandleError(errors.New(strings.Join(errorMessage, "\n")))
+	}
+}
+
+// checkSetup verifies that the local config matches the repo config file, exiting with an error if it doesn't
+func checkSetup(localConfig models.ScopedOptions, repoConfigFile string) {
+	repoConfig, err := controllers.RepoConfig(repoConfigFile)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+	setupFileErrorCheck(repoConfig.Setup)
+
+	results := []models.SetupCheck{}
+	for _, repo := range repoConfig.Setup {
+		expandedPath := controllers.RepoConfigPath(repoConfig, repo.Path)
+		repo = controllers.RepoConfigForBranch(repo, expandedPath)
+		conf := configuration.Get(expandedPath)
+
+		if repo.Project != "" {
+			results = append(results, models.SetupCheck{Path: expandedPath, Option: models.ConfigEnclaveProject.String(), Expected: repo.Project, Actual: conf.EnclaveProject.Value, Matches: repo.Project == conf.EnclaveProject.Value})
+		}
+		if repo.Config != "" {
+			results = append(results, models.SetupCheck{Path: expandedPath, Option: models.ConfigEnclaveConfig.String(), Expected: repo.Config, Actual: conf.EnclaveConfig.Value, Matches: repo.Config == conf.EnclaveConfig.Value})
+		}
+
+		if len(repo.RequiredSecrets) == 0 {
+			continue
+		}
+		var secretNames []string
+		if conf.EnclaveProject.Value != "" && conf.EnclaveConfig.Value != "" {
+			utils.RequireValue("token", localConfig.Token.Value)
+			names, httpErr := http.GetSecretNames(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, conf.EnclaveProject.Value, conf.EnclaveConfig.Value, false)
+			if !httpErr.IsNil() {
+				utils.HandleError(httpErr.Unwrap(), httpErr.Message)
+			}
+			secretNames = names
+		}
+		missing := controllers.MissingRequiredSecrets(repo.RequiredSecrets, secretNames)
+		for _, name := range repo.RequiredSecrets {
+			actual := name
+			if utils.Contains(missing, name) {
+				actual = ""
+			}
+			results = append(results, models.SetupCheck{Path: expandedPath, Option: "required-secret", Expected: name, Actual: actual, Matches: actual == name})
+		}
+	}
+
+	printer.SetupCheck(results, utils.OutputJSON)
+
+	for _, result := range results {
+		if !result.Matches {
+			utils.HandleError(errors.New("local config does not match the repo config file (doppler.yaml)"), "", "Run 'doppler setup' to apply the repo config file")
+		}
+	}
+}
+
+// runPostSetupHooks runs the hooks in the specified directory, after confirming with the user unless runHooks is specified
+func runPostSetupHooks(hooks []string, dir string, runHooks bool, canPromptUser bool) {
+	if !runHooks {
+		if !canPromptUser {
+			utils.Log("Skipping post-setup hooks from repo config file. Use --run-hooks to run them.")
+			return
+		}
+
+		utils.Print(fmt.Sprintf("The repo config file (doppler.yaml) specifies the following post-setup hooks for %s:", dir))
+		for _, hook := range hooks {
+			utils.Print(fmt.Sprintf("  %s", hook))
+		}
+		if !utils.ConfirmationPrompt("Run post-setup hooks?", false) {
+			return
+		}
+	}
+
+	for _, hook := range hooks {
+		utils.Log(fmt.Sprintf("Running post-setup hook: %s", hook))
+		if err := controllers.RunHook(hook, dir); err != nil {
+			utils.HandleError(err, fmt.Sprintf("Post-setup hook failed: %s", hook))
+		}
+	}
 }
 
 func selectProject(projects []models.ProjectInfo, prevConfiguredProject string, canPromptUser bool) string {
@@ -243,7 +368,7 @@ func selectProject(projects []models.ProjectInfo, prevConfiguredProject string,
 	}
 
 	if !canPromptUser {
-		utils.HandleError(errors.New("project must be specified via --project flag, DOPPLER_PROJECT environment variable, or repo config file when using --no-interactive"))
+		utils.ErrExit(errors.New("project must be specified via --project flag, DOPPLER_PROJECT environment variable, or repo config file when using --no-interactive"), utils.ExitCodeValidation)
 	}
 
 	selectedProject := utils.SelectPrompt("Select a project:", options, defaultOption)
@@ -279,7 +404,7 @@ func selectConfig(configs []models.ConfigInfo, selectedConfiguredProject bool, p
 	}
 
 	if !canPromptUser {
-		utils.HandleError(errors.New("config must be specified via --config flag, DOPPLER_CONFIG environment variable, or repo config file when using --no-interactive"))
+		utils.ErrExit(errors.New("config must be specified via --config flag, DOPPLER_CONFIG environment variable, or repo config file when using --no-interactive"), utils.ExitCodeValidation)
 	}
 
 	selectedConfig := utils.SelectPrompt("Select a config:", options, defaultOption)
@@ -330,6 +455,9 @@ func init() {
 	}
 	setupCmd.Flags().Bool("no-interactive", false, "do not prompt for information. if the project or config is not specified, an error will be thrown.")
 	setupCmd.Flags().Bool("no-save-token", false, "do not save the token to the config when passed via flag or environment variable.")
+	setupCmd.Flags().String("repo-config", "", "path to the repo config file. by default, doppler.yaml is searched for in the current directory and its parents, up to the git root")
+	setupCmd.Flags().Bool("check", false, "verify that the local config matches the repo config file (doppler.yaml) without prompting or changing anything")
+	setupCmd.Flags().Bool("run-hooks", false, "run post-setup hooks from the repo config file without prompting")
 
 	// deprecated
 	setupCmd.Flags().Bool("no-prompt", false, "do not prompt for information. if the project or config is not specified, an error will be thrown.")
@@ -24,6 +24,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/utils"
@@ -37,7 +38,7 @@ var UserConfigDir string
 // UserConfigFile (e.g. /home/user/doppler/.doppler.yaml)
 var UserConfigFile string
 
-// UserFallbackDir (e.g. /home/user/doppler/.doppler.yaml)
+// UserFallbackDir (e.g. /home/user/.doppler/fallback)
 var UserFallbackDir string
 
 // UserMetadataDir the directory containing metadata files
@@ -50,12 +51,25 @@ var Scope = "."
 var CanReadEnv = true
 
 var configFileName = ".doppler.yaml"
+
+// FallbackIndexFileName the name of the fallback index file within the fallback directory
+const FallbackIndexFileName = ".index.json"
+
 var configContents models.ConfigFile
 var configUid = -1
 var configGid = -1
 
+// configMutex guards configContents against updates made in the background, like OIDC token refreshes
+var configMutex sync.Mutex
+
+// the config directory used when the XDG base directories aren't set
+var legacyConfigDir = filepath.Join(utils.HomeDir(), ".doppler")
+
+// whether the config and cache directories are the defaults, rather than specified by the user
+var usingDefaultConfigDir = false
+
 func init() {
-	SetConfigDir(filepath.Join(utils.HomeDir(), ".doppler"))
+	setDefaultConfigDir()
 }
 
 func SetConfigDir(dir string) {
@@ -63,6 +77,25 @@ func SetConfigDir(dir string) {
 	UserFallbackDir = filepath.Join(dir, "fallback")
 	UserMetadataDir = UserFallbackDir
 	UserConfigFile = filepath.Join(UserConfigDir, configFileName)
+	usingDefaultConfigDir = false
+}
+
+// setDefaultConfigDir uses $XDG_CONFIG_HOME/doppler for config and $XDG_CACHE_HOME/doppler for fallback and
+// metadata files when those variables are set, and ~/.doppler otherwise
+func setDefaultConfigDir() {
+	SetConfigDir(legacyConfigDir)
+
+	// per the XDG spec, relative paths are invalid and should be ignored
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" && filepath.IsAbs(dir) {
+		UserConfigDir = filepath.Join(dir, "doppler")
+		UserConfigFile = filepath.Join(UserConfigDir, configFileName)
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" && filepath.IsAbs(dir) {
+		UserFallbackDir = filepath.Join(dir, "doppler", "fallback")
+		UserMetadataDir = UserFallbackDir
+	}
+
+	usingDefaultConfigDir = true
 }
 
 // Setup the config directory and config file
@@ -70,8 +103,12 @@ func Setup() {
 	utils.LogDebug(fmt.Sprintf("Using config dir %s", UserConfigDir))
 	utils.LogDebug(fmt.Sprintf("Using config file %s", UserConfigFile))
 
-	err := os.Mkdir(UserConfigDir, 0700)
-	if err != nil && !os.IsExist(err) {
+	if usingDefaultConfigDir {
+		migrateLegacyConfigDir()
+	}
+
+	err := os.MkdirAll(UserConfigDir, 0700)
+	if err != nil {
 		utils.HandleError(err, fmt.Sprintf("Unable to create config directory %s", UserConfigDir))
 	}
 
@@ -119,6 +156,9 @@ func VersionCheck() models.VersionCheck {
 
 // SetVersionCheck the last version check
 func SetVersionCheck(version models.VersionCheck) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
 	configContents.VersionCheck = version
 	writeConfig(configContents)
 }
@@ -130,20 +170,24 @@ func Get(scope string) models.ScopedOptions {
 	if normalizedScope, err = NormalizeScope(scope); err != nil {
 		utils.HandleError(err, fmt.Sprintf("Invalid scope: %s", scope))
 	}
+
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
 	if !strings.HasSuffix(normalizedScope, string(filepath.Separator)) {
 		normalizedScope = normalizedScope + string(filepath.Separator)
 	}
 	var scopedConfig models.ScopedOptions
 
 	for confScope, conf := range configContents.Scoped {
-		confScopePath := confScope
-		// both paths must end in / to prevent partial match (e.g. /test matching /test123)
-		if !strings.HasSuffix(confScopePath, string(filepath.Separator)) {
-			confScopePath = confScopePath + string(filepath.Separator)
+		matchedScope, matches := MatchScope(confScope, normalizedScope)
+		if !matches {
+			continue
 		}
 
-		if !strings.HasPrefix(normalizedScope, confScopePath) {
-			continue
+		rule := ""
+		if IsGlobScope(confScope) {
+			rule = confScope
 		}
 
 		pairs := models.OptionsMap(conf)
@@ -151,18 +195,29 @@ func Get(scope string) models.ScopedOptions {
 		for name, pair := range pairs {
 			if pair != "" {
 				scopedPair := scopedPairs[name]
-				if *scopedPair == (models.ScopedOption{}) || len(confScope) > len(scopedPair.Scope) {
+				if *scopedPair == (models.ScopedOption{}) || isMoreSpecificScope(matchedScope, rule, scopedPair.Scope, scopedPair.Rule) {
+					if name == models.ConfigEnclaveProject.String() || name == models.ConfigEnclaveConfig.String() {
+						pair = strings.ReplaceAll(pair, scopeDirPlaceholder, filepath.Base(matchedScope))
+					}
 					scopedPair.Value = pair
-					scopedPair.Scope = confScope
+					scopedPair.Scope = matchedScope
 					scopedPair.Source = models.ConfigFileSource.String()
+					scopedPair.Rule = rule
 				}
 			}
 		}
 	}
 
-	if IsKeyringSecret(scopedConfig.Token.Value) {
-		utils.LogDebug(fmt.Sprintf("Retrieving %s from system keyring", models.ConfigToken.String()))
-		token, err := GetKeyring(scopedConfig.Token.Value)
+	if scopedConfig.Profile.Value != "" {
+		if profile, ok := configContents.Profiles[scopedConfig.Profile.Value]; ok {
+			applyProfile(&scopedConfig, profile, scopedConfig.Profile.Scope, false)
+		} else {
+			utils.LogWarning(fmt.Sprintf("Profile %s referenced by scope %s does not exist", scopedConfig.Profile.Value, scopedConfig.Profile.Scope))
+		}
+	}
+
+	if scopedConfig.Token.Value != "" {
+		token, err := ResolveToken(scopedConfig.Token.Value)
 		if !err.IsNil() {
 			utils.HandleError(err.Unwrap(), err.Message)
 		}
@@ -178,6 +233,22 @@ func LocalConfig(cmd *cobra.Command) models.ScopedOptions {
 	// config file (lowest priority)
 	localConfig := Get(Scope)
 
+	// explicitly selected profile
+	profileName := ""
+	if CanReadEnv {
+		profileName = os.Getenv("DOPPLER_PROFILE")
+	}
+	if cmd.Flags().Changed("profile") {
+		profileName = cmd.Flag("profile").Value.String()
+	}
+	if profileName != "" {
+		profile, exists := configContents.Profiles[profileName]
+		if !exists {
+			utils.HandleError(fmt.Errorf("profile %s does not exist", profileName), "", "Use 'doppler profile list' to view all profiles")
+		}
+		applyProfile(&localConfig, profile, "/", true)
+	}
+
 	// environment variables
 	if CanReadEnv {
 		pairs := models.EnvOptions(&localConfig)
@@ -277,6 +348,20 @@ func LocalConfig(cmd *cobra.Command) models.ScopedOptions {
 		}
 	}
 
+	flagSet = cmd.Flags().Changed("profile")
+	if flagSet {
+		localConfig.Profile.Value = cmd.Flag("profile").Value.String()
+		localConfig.Profile.Scope = "/"
+		localConfig.Profile.Source = models.FlagSource.String()
+	}
+
+	flagSet = cmd.Flags().Changed("fallback-kdf")
+	if flagSet {
+		localConfig.FallbackKDF.Value = cmd.Flag("fallback-kdf").Value.String()
+		localConfig.FallbackKDF.Scope = "/"
+		localConfig.FallbackKDF.Source = models.FlagSource.String()
+	}
+
 	return localConfig
 }
 
@@ -286,9 +371,8 @@ func AllConfigs() map[string]models.FileScopedOptions {
 	for scope, scopedOptions := range configContents.Scoped {
 		options := scopedOptions
 
-		if IsKeyringSecret(options.Token) {
-			utils.LogDebug(fmt.Sprintf("Retrieving %s from system keyring", models.ConfigToken.String()))
-			token, err := GetKeyring(options.Token)
+		if options.Token != "" {
+			token, err := ResolveToken(options.Token)
 			if !err.IsNil() {
 				utils.HandleError(err.Unwrap(), err.Message)
 			}
@@ -314,32 +398,13 @@ func Set(scope string, options map[string]string) {
 
 	for key, value := range options {
 		if !IsValidConfigOption(key) {
-			utils.HandleError(errors.New("invalid option "+key), "")
+			utils.ErrExit(errors.New("invalid option "+key), utils.ExitCodeValidation, "")
 		}
 
 		if key == models.ConfigToken.String() {
-			utils.LogDebug(fmt.Sprintf("Saving %s to system keyring", key))
-			uuid, err := utils.UUID()
-			if err != nil {
-				utils.HandleError(err, "Unable to generate UUID for keyring")
-			}
-			id := GenerateKeyringID(uuid)
-
-			if controllerError := SetKeyring(id, value); !controllerError.IsNil() {
-				utils.LogDebugError(controllerError.Unwrap())
-				utils.LogDebug(controllerError.Message)
-			} else {
-				value = id
-
-				// remove old token from keyring
-				if IsKeyringSecret(previousToken) {
-					utils.LogDebug("Removing previous token from system keyring")
-					if controllerError := DeleteKeyring(previousToken); !controllerError.IsNil() {
-						utils.LogDebugError(controllerError.Unwrap())
-						utils.LogDebug(controllerError.Message)
-					}
-				}
-			}
+			value = storeToken(value, previousToken)
+			// the scope's OIDC session only applies to the token it was created with
+			delete(configContents.OIDCSessions, normalizedScope)
 		}
 
 		SetConfigValue(&config, key, value)
@@ -363,20 +428,15 @@ func Unset(scope string, options []string) {
 
 	for _, key := range options {
 		if !IsValidConfigOption(key) {
-			utils.HandleError(errors.New("invalid option "+key), "")
+			utils.ErrExit(errors.New("invalid option "+key), utils.ExitCodeValidation, "")
 		}
 
 		config := configContents.Scoped[normalizedScope]
 
 		if key == models.ConfigToken.String() {
-			previousToken := config.Token
 			// remove old token from keyring
-			if IsKeyringSecret(previousToken) {
-				if controllerError := DeleteKeyring(previousToken); !controllerError.IsNil() {
-					utils.LogDebugError(controllerError.Unwrap())
-					utils.LogDebug(controllerError.Message)
-				}
-			}
+			deleteStoredToken(config.Token)
+			delete(configContents.OIDCSessions, normalizedScope)
 		}
 
 		SetConfigValue(&config, key, "")
@@ -402,12 +462,35 @@ func ClearConfig() {
 			}
 		}
 	}
+	for _, profile := range configContents.Profiles {
+		if IsKeyringSecret(profile.Token) {
+			utils.LogDebug(fmt.Sprintf("Removing %s from keychain", profile.Token))
+			err := DeleteKeyring(profile.Token)
+			if !err.IsNil() {
+				utils.LogDebugError(err.Unwrap())
+			}
+		}
+	}
+
+	if utils.Exists(TokenKeyPath()) {
+		utils.LogDebug("Removing token key")
+		if err := os.Remove(TokenKeyPath()); err != nil {
+			utils.LogDebugError(err)
+		}
+	}
 
 	writeConfig(models.ConfigFile{})
 }
 
 // Write config to filesystem
 func writeConfig(config models.ConfigFile) {
+	if err := tryWriteConfig(config); !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+}
+
+// tryWriteConfig writes the config to the filesystem, returning an error rather than exiting on failure
+func tryWriteConfig(config models.ConfigFile) Error {
 	// keep both analytics properties up-to-date
 	if config.Flags.Analytics != nil {
 		config.Analytics.Disable = !*config.Flags.Analytics
@@ -415,39 +498,49 @@ func writeConfig(config models.ConfigFile) {
 
 	bytes, err := yaml.Marshal(config)
 	if err != nil {
-		utils.HandleError(err)
+		return Error{Err: err}
 	}
 
 	utils.LogDebug(fmt.Sprintf("Writing user config to %s", UserConfigFile))
 	if err := utils.WriteFile(UserConfigFile, bytes, os.FileMode(0600)); err != nil {
-		utils.HandleError(err)
+		return Error{Err: err}
 	}
 
 	// restore file's original ownership, in case doppler has been subsequently run with 'sudo'
 	if !utils.IsWindows() && configUid != -1 && configGid != -1 {
 		if err := os.Chown(UserConfigFile, configUid, configGid); err != nil {
-			utils.HandleError(err, "Unable to modify config file ownership")
+			return Error{Err: err, Message: "Unable to modify config file ownership"}
 		}
 	}
+	return Error{}
 }
 
 func readConfig() (models.ConfigFile, int, int) {
+	config, uid, gid, err := tryReadConfig()
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+	return config, uid, gid
+}
+
+// tryReadConfig reads the config from the filesystem, returning an error rather than exiting on failure
+func tryReadConfig() (models.ConfigFile, int, int, Error) {
 	utils.LogDebug("Reading config file")
 
 	uid, gid, err := utils.FileOwnership(UserConfigFile)
 	if err != nil {
-		utils.HandleError(err, "Unable to stat user config file")
+		return models.ConfigFile{}, -1, -1, Error{Err: err, Message: "Unable to stat user config file"}
 	}
 
 	fileContents, err := ioutil.ReadFile(UserConfigFile) // #nosec G304
 	if err != nil {
-		utils.HandleError(err, "Unable to read user config file")
+		return models.ConfigFile{}, -1, -1, Error{Err: err, Message: "Unable to read user config file"}
 	}
 
 	var config models.ConfigFile
 	err = yaml.Unmarshal(fileContents, &config)
 	if err != nil {
-		utils.HandleError(err, "Unable to parse user config file")
+		return models.ConfigFile{}, -1, -1, Error{Err: err, Message: "Unable to parse user config file"}
 	}
 
 	// sort scopes before normalizing so that if multiple scopes normalize to
@@ -463,7 +556,7 @@ func readConfig() (models.ConfigFile, int, int) {
 	for _, scope := range sorted {
 		var normalizedScope string
 		if normalizedScope, err = NormalizeScope(scope); err != nil {
-			utils.HandleError(err, fmt.Sprintf("Invalid scope: %s", scope))
+			return models.ConfigFile{}, -1, -1, Error{Err: err, Message: fmt.Sprintf("Invalid scope: %s", scope)}
 		}
 		scopedOption := normalizedOptions[normalizedScope]
 
@@ -486,6 +579,15 @@ func readConfig() (models.ConfigFile, int, int) {
 		if options.VerifyTLS != "" {
 			scopedOption.VerifyTLS = options.VerifyTLS
 		}
+		if options.FallbackKDF != "" {
+			scopedOption.FallbackKDF = options.FallbackKDF
+		}
+		if options.FallbackKeyring != "" {
+			scopedOption.FallbackKeyring = options.FallbackKeyring
+		}
+		if options.Profile != "" {
+			scopedOption.Profile = options.Profile
+		}
 
 		normalizedOptions[normalizedScope] = scopedOption
 	}
@@ -498,18 +600,21 @@ func readConfig() (models.ConfigFile, int, int) {
 		config.Flags.Analytics = &b
 	}
 
-	return config, uid, gid
+	return config, uid, gid, Error{}
 }
 
 // IsValidConfigOption whether the specified key is a valid config option
 func IsValidConfigOption(key string) bool {
 	configOptions := map[string]interface{}{
-		models.ConfigToken.String():          nil,
-		models.ConfigAPIHost.String():        nil,
-		models.ConfigDashboardHost.String():  nil,
-		models.ConfigVerifyTLS.String():      nil,
-		models.ConfigEnclaveProject.String(): nil,
-		models.ConfigEnclaveConfig.String():  nil,
+		models.ConfigToken.String():           nil,
+		models.ConfigAPIHost.String():         nil,
+		models.ConfigDashboardHost.String():   nil,
+		models.ConfigVerifyTLS.String():       nil,
+		models.ConfigEnclaveProject.String():  nil,
+		models.ConfigEnclaveConfig.String():   nil,
+		models.ConfigFallbackKDF.String():     nil,
+		models.ConfigFallbackKeyring.String(): nil,
+		models.ConfigProfile.String():         nil,
 	}
 
 	_, exists := configOptions[key]
@@ -564,6 +669,12 @@ func SetConfigValue(conf *models.FileScopedOptions, key string, value string) {
 		(*conf).EnclaveProject = value
 	} else if key == models.ConfigEnclaveConfig.String() {
 		(*conf).EnclaveConfig = value
+	} else if key == models.ConfigFallbackKDF.String() {
+		(*conf).FallbackKDF = value
+	} else if key == models.ConfigFallbackKeyring.String() {
+		(*conf).FallbackKeyring = value
+	} else if key == models.ConfigProfile.String() {
+		(*conf).Profile = value
 	}
 }
 
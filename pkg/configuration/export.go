@@ -0,0 +1,254 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportConflictResolver decides whether an imported value should replace the existing one
+type ImportConflictResolver func(location string, option string, existing string, imported string) bool
+
+// ExportConfig the portable parts of the config: scoped options, profiles, and flags.
+// Scopes are made relative to the home directory, and tokens are only included if specified.
+func ExportConfig(includeTokens bool) models.ConfigFile {
+	exported := models.ConfigFile{
+		Scoped:   map[string]models.FileScopedOptions{},
+		Profiles: map[string]models.ProfileOptions{},
+		Flags:    configContents.Flags,
+	}
+
+	exportToken := func(value string) string {
+		if !includeTokens || value == "" {
+			return ""
+		}
+		token, err := ResolveToken(value)
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+		return token
+	}
+
+	for scope, options := range configContents.Scoped {
+		options.Token = exportToken(options.Token)
+		if options != (models.FileScopedOptions{}) {
+			exported.Scoped[homeRelativeScope(scope)] = options
+		}
+	}
+	for name, profile := range configContents.Profiles {
+		profile.Token = exportToken(profile.Token)
+		exported.Profiles[name] = profile
+	}
+
+	return exported
+}
+
+// MarshalExportedConfig serializes the exported config
+func MarshalExportedConfig(config models.ConfigFile) ([]byte, error) {
+	bytes, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("# Doppler CLI configuration. Import with 'doppler configure import'\n"), bytes...), nil
+}
+
+// ParseExportedConfig parses a config previously created by ExportConfig
+func ParseExportedConfig(bytes []byte) (models.ConfigFile, error) {
+	var config models.ConfigFile
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return models.ConfigFile{}, err
+	}
+
+	scoped := map[string]models.FileScopedOptions{}
+	for scope, options := range config.Scoped {
+		normalizedScope, err := NormalizeScope(scope)
+		if err != nil {
+			return models.ConfigFile{}, fmt.Errorf("invalid scope %s: %w", scope, err)
+		}
+		scoped[normalizedScope] = options
+	}
+	config.Scoped = scoped
+
+	for name := range config.Profiles {
+		if !IsValidProfileName(name) {
+			return models.ConfigFile{}, fmt.Errorf("invalid profile name %s", name)
+		}
+	}
+
+	return config, nil
+}
+
+// ImportConfig imports scoped options, profiles, and flags. When replacing, existing options and profiles are
+// removed first. When merging, resolve is called for each option whose existing value differs from the imported one.
+func ImportConfig(config models.ConfigFile, replace bool, resolve ImportConflictResolver) {
+	// resolving a conflict may exit, so all conflicts are resolved before any tokens are stored. replaced tokens are
+	// only removed from the keyring once the config file no longer references them
+	merged := configContents
+	merged.Scoped = map[string]models.FileScopedOptions{}
+	merged.Profiles = map[string]models.ProfileOptions{}
+	merged.OIDCSessions = map[string]models.OIDCSession{}
+	var replacedTokens []string
+	if replace {
+		for _, options := range configContents.Scoped {
+			replacedTokens = append(replacedTokens, options.Token)
+		}
+		for _, profile := range configContents.Profiles {
+			replacedTokens = append(replacedTokens, profile.Token)
+		}
+		merged.Flags = models.Flags{}
+	} else {
+		for scope, options := range configContents.Scoped {
+			merged.Scoped[scope] = options
+		}
+		for name, profile := range configContents.Profiles {
+			merged.Profiles[name] = profile
+		}
+		for scope, session := range configContents.OIDCSessions {
+			merged.OIDCSessions[scope] = session
+		}
+	}
+
+	scopeTokens := map[string]string{}
+	for _, scope := range sortedKeys(config.Scoped) {
+		existing := merged.Scoped[scope]
+		location := fmt.Sprintf("scope %s", homeRelativeScope(scope))
+		imported := models.OptionsMap(config.Scoped[scope])
+		for _, key := range sortedKeys(imported) {
+			value := imported[key]
+			if useImportedValue(location, key, models.OptionsMap(existing)[key], value, resolve) {
+				if key == models.ConfigToken.String() {
+					scopeTokens[scope] = value
+					continue
+				}
+				SetConfigValue(&existing, key, value)
+			}
+		}
+		merged.Scoped[scope] = existing
+	}
+
+	profileTokens := map[string]string{}
+	for _, name := range sortedKeys(config.Profiles) {
+		existing := merged.Profiles[name]
+		location := fmt.Sprintf("profile %s", name)
+		imported := models.ProfileOptionsMap(config.Profiles[name])
+		for _, key := range sortedKeys(imported) {
+			value := imported[key]
+			if useImportedValue(location, key, models.ProfileOptionsMap(existing)[key], value, resolve) {
+				if key == models.ConfigToken.String() {
+					profileTokens[name] = value
+					continue
+				}
+				setProfileValue(&existing, key, value)
+			}
+		}
+		merged.Profiles[name] = existing
+	}
+
+	importFlag := func(flag string, existing **bool, imported *bool) {
+		if imported == nil {
+			return
+		}
+		existingValue := ""
+		if *existing != nil {
+			existingValue = strconv.FormatBool(**existing)
+		}
+		if useImportedValue("flags", flag, existingValue, strconv.FormatBool(*imported), resolve) {
+			value := *imported
+			*existing = &value
+		}
+	}
+	importFlag(models.FlagAnalytics, &merged.Flags.Analytics, config.Flags.Analytics)
+	importFlag(models.FlagEnvWarning, &merged.Flags.EnvWarning, config.Flags.EnvWarning)
+	importFlag(models.FlagUpdateCheck, &merged.Flags.UpdateCheck, config.Flags.UpdateCheck)
+
+	for _, scope := range sortedKeys(scopeTokens) {
+		options := merged.Scoped[scope]
+		replacedTokens = append(replacedTokens, options.Token)
+		options.Token = storedTokenValue(scopeTokens[scope])
+		merged.Scoped[scope] = options
+		// the scope's OIDC session only applies to the token it was created with
+		delete(merged.OIDCSessions, scope)
+	}
+	for _, name := range sortedKeys(profileTokens) {
+		profile := merged.Profiles[name]
+		replacedTokens = append(replacedTokens, profile.Token)
+		profile.Token = storedTokenValue(profileTokens[name])
+		merged.Profiles[name] = profile
+	}
+	if len(merged.OIDCSessions) == 0 {
+		merged.OIDCSessions = nil
+	}
+
+	configContents = merged
+	writeConfig(configContents)
+
+	for _, token := range replacedTokens {
+		deleteStoredToken(token)
+	}
+}
+
+// useImportedValue whether the imported value should be used, calling resolve if it conflicts with the existing value
+func useImportedValue(location string, option string, existing string, imported string, resolve ImportConflictResolver) bool {
+	if imported == "" {
+		return false
+	}
+	if existing == "" {
+		return true
+	}
+
+	if option == models.ConfigToken.String() {
+		// compare the actual tokens, and never display them
+		existingToken, err := ResolveToken(existing)
+		if err.IsNil() && existingToken == imported {
+			return false
+		}
+		return resolve(location, option, utils.RedactAuthToken(existingToken), utils.RedactAuthToken(imported))
+	}
+
+	if existing == imported {
+		return false
+	}
+	return resolve(location, option, existing, imported)
+}
+
+// homeRelativeScope rewrites scopes within the home directory to begin with ~
+func homeRelativeScope(scope string) string {
+	home := utils.HomeDir()
+	if scope == home {
+		return "~"
+	}
+	if strings.HasPrefix(scope, home+string(filepath.Separator)) {
+		return "~" + strings.TrimPrefix(scope, home)
+	}
+	return scope
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/zalando/go-keyring"
+)
+
+func TestExportImportConfig(t *testing.T) {
+	keyring.MockInit()
+	originalConfigDir := UserConfigDir
+	SetConfigDir(t.TempDir())
+	originalConfigContents := configContents
+	defer func() {
+		SetConfigDir(originalConfigDir)
+		configContents = originalConfigContents
+	}()
+
+	projectScope := filepath.Join(utils.HomeDir(), "projects", "backend")
+	configContents = models.ConfigFile{
+		Scoped: map[string]models.FileScopedOptions{
+			"/":          {Token: "dp.pt.123", APIHost: "https://api.acme.internal"},
+			projectScope: {EnclaveProject: "backend", EnclaveConfig: "dev"},
+		},
+		Profiles: map[string]models.ProfileOptions{"acme": {Token: "dp.pt.456", VerifyTLS: "false"}},
+	}
+
+	// tokens are excluded by default, and scopes are relative to the home directory
+	exported := ExportConfig(false)
+	assert.Equal(t, models.FileScopedOptions{APIHost: "https://api.acme.internal"}, exported.Scoped["/"])
+	assert.Equal(t, models.FileScopedOptions{EnclaveProject: "backend", EnclaveConfig: "dev"}, exported.Scoped[filepath.Join("~", "projects", "backend")])
+	assert.Equal(t, models.ProfileOptions{VerifyTLS: "false"}, exported.Profiles["acme"])
+
+	exported = ExportConfig(true)
+	assert.Equal(t, "dp.pt.123", exported.Scoped["/"].Token)
+	assert.Equal(t, "dp.pt.456", exported.Profiles["acme"].Token)
+
+	bytes, err := MarshalExportedConfig(exported)
+	assert.Nil(t, err)
+	parsed, err := ParseExportedConfig(bytes)
+	assert.Nil(t, err)
+	assert.Equal(t, models.FileScopedOptions{EnclaveProject: "backend", EnclaveConfig: "dev"}, parsed.Scoped[projectScope])
+
+	// merge, keeping existing values on conflict
+	configContents = models.ConfigFile{
+		Scoped: map[string]models.FileScopedOptions{projectScope: {EnclaveConfig: "stg"}},
+	}
+	var conflicts []string
+	ImportConfig(parsed, false, func(location string, option string, existing string, imported string) bool {
+		conflicts = append(conflicts, location+" "+option+" "+existing+" "+imported)
+		return false
+	})
+	assert.Equal(t, []string{"scope " + filepath.Join("~", "projects", "backend") + " enclave.config stg dev"}, conflicts)
+	assert.Equal(t, "stg", configContents.Scoped[projectScope].EnclaveConfig)
+	assert.Equal(t, "backend", configContents.Scoped[projectScope].EnclaveProject)
+	assert.True(t, IsKeyringSecret(configContents.Scoped["/"].Token))
+	assert.True(t, IsKeyringSecret(configContents.Profiles["acme"].Token))
+
+	// merge, using imported values on conflict. options that already match are not conflicts
+	conflicts = nil
+	ImportConfig(parsed, false, func(location string, option string, existing string, imported string) bool {
+		conflicts = append(conflicts, location+" "+option)
+		return true
+	})
+	assert.Equal(t, []string{"scope " + filepath.Join("~", "projects", "backend") + " enclave.config"}, conflicts)
+	assert.Equal(t, "dev", configContents.Scoped[projectScope].EnclaveConfig)
+
+	// conflicts are resolved before tokens are stored, so exiting while resolving leaves the existing tokens usable
+	existingToken := configContents.Scoped["/"].Token
+	conflicting := models.ConfigFile{
+		Scoped:   map[string]models.FileScopedOptions{"/": {Token: "dp.pt.789"}},
+		Profiles: map[string]models.ProfileOptions{"acme": {VerifyTLS: "true"}},
+	}
+	assert.Panics(t, func() {
+		ImportConfig(conflicting, false, func(location string, option string, existing string, imported string) bool {
+			if location == "profile acme" {
+				panic("exit")
+			}
+			return true
+		})
+	})
+	assert.Equal(t, existingToken, configContents.Scoped["/"].Token)
+	token, Err := ResolveToken(existingToken)
+	assert.True(t, Err.IsNil())
+	assert.Equal(t, "dp.pt.123", token)
+
+	// the replaced token is removed from the keyring once the config has been written
+	ImportConfig(conflicting, false, func(location string, option string, existing string, imported string) bool {
+		return true
+	})
+	assert.NotEqual(t, existingToken, configContents.Scoped["/"].Token)
+	assert.Equal(t, "dp.pt.789", Get("/").Token.Value)
+	_, Err = ResolveToken(existingToken)
+	assert.False(t, Err.IsNil())
+
+	// replace
+	configContents.Scoped["/other"] = models.FileScopedOptions{EnclaveProject: "other"}
+	ImportConfig(parsed, true, nil)
+	_, exists := configContents.Scoped["/other"]
+	assert.False(t, exists)
+	assert.Equal(t, "dev", configContents.Scoped[projectScope].EnclaveConfig)
+}
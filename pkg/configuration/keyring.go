@@ -16,6 +16,7 @@ limitations under the License.
 package configuration
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -62,6 +63,16 @@ func GetKeyring(id string) (string, Error) {
 	return value, Error{}
 }
 
+// IsKeyringNotFound whether the keyring error indicates the value doesn't exist, rather than that the keyring couldn't be accessed
+func IsKeyringNotFound(err Error) bool {
+	return errors.Is(err.Err, keyring.ErrNotFound)
+}
+
+// IsKeyringUnsupported whether the keyring error indicates the OS doesn't support keyring
+func IsKeyringUnsupported(err Error) bool {
+	return errors.Is(err.Err, keyring.ErrUnsupportedPlatform)
+}
+
 // SetKeyring saves a value to the keyring
 func SetKeyring(key string, value string) Error {
 	if err := keyring.Set(keyringService, key, value); err != nil {
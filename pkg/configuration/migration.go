@@ -17,8 +17,11 @@ package configuration
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/utils"
@@ -71,3 +74,156 @@ func parseJSONConfig() map[string]oldConfig {
 
 	return config
 }
+
+// migrateLegacyConfigDir moves the contents of ~/.doppler into the XDG config and cache directories.
+// This only happens once, as the legacy config file and fallback directory are moved as part of the migration.
+func migrateLegacyConfigDir() {
+	legacyConfigFile := filepath.Join(legacyConfigDir, configFileName)
+	legacyFallbackDir := filepath.Join(legacyConfigDir, "fallback")
+	migrated := false
+
+	if UserConfigDir != legacyConfigDir && UserConfigFile == filepath.Join(UserConfigDir, configFileName) &&
+		utils.Exists(legacyConfigFile) && !utils.Exists(UserConfigFile) {
+		utils.LogDebug(fmt.Sprintf("Migrating config dir from %s to %s", legacyConfigDir, UserConfigDir))
+		moveDirContents(legacyConfigDir, UserConfigDir, func(name string) bool { return name != filepath.Base(legacyFallbackDir) })
+		migrated = true
+	}
+
+	if UserFallbackDir != legacyFallbackDir && utils.Exists(legacyFallbackDir) && !utils.Exists(UserFallbackDir) {
+		utils.LogDebug(fmt.Sprintf("Migrating fallback dir from %s to %s", legacyFallbackDir, UserFallbackDir))
+		moved := moveDirContents(legacyFallbackDir, UserFallbackDir, func(name string) bool { return true })
+		migrateFallbackIndex(legacyFallbackDir, UserFallbackDir)
+		if moved {
+			// the legacy fallback dir may still contain lock files, which are disposable
+			if err := os.RemoveAll(legacyFallbackDir); err != nil {
+				utils.LogDebugError(err)
+			}
+		} else {
+			utils.LogDebug(fmt.Sprintf("Not removing legacy fallback dir %s as some files weren't migrated", legacyFallbackDir))
+		}
+		migrated = true
+	}
+
+	// remove the legacy dir if everything was moved out of it
+	if migrated {
+		if err := os.Remove(legacyConfigDir); err != nil {
+			utils.LogDebug(fmt.Sprintf("Not removing legacy config dir %s", legacyConfigDir))
+		}
+	}
+}
+
+// moveDirContents moves each regular file matching the filter from src to dst. Files that already exist in dst are skipped.
+// Returns whether every matching file was moved.
+func moveDirContents(src string, dst string, filter func(name string) bool) bool {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Unable to migrate %s to %s", src, dst))
+		utils.LogDebugError(err)
+		return false
+	}
+
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		utils.LogWarning(fmt.Sprintf("Unable to migrate %s to %s", src, dst))
+		utils.LogDebugError(err)
+		return false
+	}
+
+	moved := true
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !filter(entry.Name()) {
+			continue
+		}
+
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+		if utils.Exists(dstPath) {
+			utils.LogDebug(fmt.Sprintf("Not migrating %s as %s already exists", srcPath, dstPath))
+			moved = false
+			continue
+		}
+
+		if err := moveFile(srcPath, dstPath); err != nil {
+			utils.LogWarning(fmt.Sprintf("Unable to migrate %s to %s", srcPath, dstPath))
+			utils.LogDebugError(err)
+			moved = false
+		}
+	}
+
+	return moved
+}
+
+// migrateFallbackIndex updates the paths in the fallback index, which is keyed by each fallback file's absolute path,
+// to refer to the fallback files' new location. Paths outside of the legacy fallback dir, and files that weren't moved,
+// are left as is.
+func migrateFallbackIndex(src string, dst string) {
+	indexPath := filepath.Join(dst, FallbackIndexFileName)
+	bytes, err := os.ReadFile(indexPath) // #nosec G304
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LogDebugError(err)
+		}
+		return
+	}
+
+	var index models.FallbackIndex
+	if err := json.Unmarshal(bytes, &index); err != nil {
+		utils.LogDebug("Unable to parse fallback index")
+		utils.LogDebugError(err)
+		return
+	}
+
+	files := map[string]models.FallbackIndexEntry{}
+	for _, entry := range index.Files {
+		entry.Path = migratePath(entry.Path, src, dst)
+		if entry.MetadataPath != "" {
+			entry.MetadataPath = migratePath(entry.MetadataPath, src, dst)
+		}
+		files[entry.Path] = entry
+	}
+	index.Files = files
+
+	bytes, err = json.Marshal(index)
+	if err != nil {
+		utils.LogDebugError(err)
+		return
+	}
+	utils.LogDebug(fmt.Sprintf("Migrating fallback index %s", indexPath))
+	if err := utils.WriteFile(indexPath, bytes, utils.RestrictedFilePerms()); err != nil {
+		utils.LogWarning(fmt.Sprintf("Unable to migrate fallback index %s", indexPath))
+		utils.LogDebugError(err)
+	}
+}
+
+// migratePath the path's new location in dst if it was within src and has been moved, otherwise the original path
+func migratePath(path string, src string, dst string) string {
+	rel, err := filepath.Rel(src, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+
+	newPath := filepath.Join(dst, rel)
+	if !utils.Exists(newPath) {
+		return path
+	}
+	return newPath
+}
+
+// moveFile renames the file, falling back to copying it when the destination is on a different device
+func moveFile(src string, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	contents, err := os.ReadFile(src) // #nosec G304
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFile(dst, contents, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
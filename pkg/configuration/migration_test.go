@@ -0,0 +1,109 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateLegacyConfigDir(t *testing.T) {
+	home := t.TempDir()
+	originalLegacyConfigDir := legacyConfigDir
+	legacyConfigDir = filepath.Join(home, ".doppler")
+	defer func() {
+		legacyConfigDir = originalLegacyConfigDir
+		setDefaultConfigDir()
+	}()
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(legacyConfigDir, "fallback", "locks"), 0700))
+	assert.Nil(t, os.WriteFile(filepath.Join(legacyConfigDir, configFileName), []byte("scoped: {}\n"), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(legacyConfigDir, tokenKeyFileName), []byte("key"), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(legacyConfigDir, "fallback", ".secrets-123.json"), []byte("secrets"), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(legacyConfigDir, "fallback", "locks", "123.lock"), []byte{}, 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(legacyConfigDir, "fallback", ".metadata-123.json"), []byte("metadata"), 0600))
+	legacyFallbackPath := filepath.Join(legacyConfigDir, "fallback", ".secrets-123.json")
+	customFallbackPath := filepath.Join(home, "custom-fallback.json")
+	index := models.FallbackIndex{Version: "1", Files: map[string]models.FallbackIndexEntry{
+		legacyFallbackPath: {Path: legacyFallbackPath, MetadataPath: filepath.Join(legacyConfigDir, "fallback", ".metadata-123.json"), Project: "backend"},
+		customFallbackPath: {Path: customFallbackPath, Project: "frontend"},
+	}}
+	indexBytes, err := json.Marshal(index)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(filepath.Join(legacyConfigDir, "fallback", FallbackIndexFileName), indexBytes, 0600))
+
+	// without XDG variables, the legacy dir is used
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "relative/paths/are/ignored")
+	setDefaultConfigDir()
+	assert.Equal(t, legacyConfigDir, UserConfigDir)
+	assert.Equal(t, filepath.Join(legacyConfigDir, "fallback"), UserFallbackDir)
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	setDefaultConfigDir()
+	assert.Equal(t, filepath.Join(home, ".config", "doppler"), UserConfigDir)
+	assert.Equal(t, filepath.Join(home, ".config", "doppler", configFileName), UserConfigFile)
+	assert.Equal(t, filepath.Join(home, ".cache", "doppler", "fallback"), UserFallbackDir)
+	assert.Equal(t, UserFallbackDir, UserMetadataDir)
+
+	migrateLegacyConfigDir()
+	assert.True(t, utils.Exists(UserConfigFile))
+	assert.True(t, utils.Exists(filepath.Join(UserConfigDir, tokenKeyFileName)))
+	assert.True(t, utils.Exists(filepath.Join(UserFallbackDir, ".secrets-123.json")))
+	assert.False(t, utils.Exists(legacyConfigDir))
+
+	// the fallback index refers to the fallback files' new location
+	indexBytes, err = os.ReadFile(filepath.Join(UserFallbackDir, FallbackIndexFileName))
+	assert.Nil(t, err)
+	index = models.FallbackIndex{}
+	assert.Nil(t, json.Unmarshal(indexBytes, &index))
+	migratedPath := filepath.Join(UserFallbackDir, ".secrets-123.json")
+	assert.Len(t, index.Files, 2)
+	if assert.Contains(t, index.Files, migratedPath) {
+		assert.Equal(t, migratedPath, index.Files[migratedPath].Path)
+		assert.Equal(t, filepath.Join(UserFallbackDir, ".metadata-123.json"), index.Files[migratedPath].MetadataPath)
+		assert.Equal(t, "backend", index.Files[migratedPath].Project)
+	}
+	assert.Equal(t, customFallbackPath, index.Files[customFallbackPath].Path)
+
+	// an explicitly specified config dir is used for everything
+	SetConfigDir(filepath.Join(home, "custom"))
+	assert.False(t, usingDefaultConfigDir)
+	assert.Equal(t, filepath.Join(home, "custom", "fallback"), UserFallbackDir)
+}
+
+func TestMoveDirContents(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(src, "a"), []byte("a"), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(src, "b"), []byte("b"), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dst, "b"), []byte("existing"), 0600))
+
+	// files that weren't moved are reported so that the source isn't removed
+	assert.False(t, moveDirContents(src, dst, func(name string) bool { return true }))
+	assert.False(t, utils.Exists(filepath.Join(src, "a")))
+	assert.True(t, utils.Exists(filepath.Join(src, "b")))
+
+	assert.True(t, moveDirContents(src, dst, func(name string) bool { return name != "b" }))
+	assert.False(t, moveDirContents(filepath.Join(src, "missing"), dst, func(name string) bool { return true }))
+}
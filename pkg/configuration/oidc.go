@@ -0,0 +1,116 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// GetOIDCSession the OIDC session for the token at the specified scope
+func GetOIDCSession(scope string) (models.OIDCSession, bool) {
+	normalizedScope, err := NormalizeScope(scope)
+	if err != nil {
+		utils.HandleError(err, fmt.Sprintf("Invalid scope: %s", scope))
+	}
+
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	session, exists := configContents.OIDCSessions[normalizedScope]
+	return session, exists
+}
+
+// SetOIDCSession saves the OIDC session for the token at the specified scope. The token must be set first, as
+// setting a token removes the scope's existing session.
+func SetOIDCSession(scope string, session models.OIDCSession) {
+	normalizedScope, err := NormalizeScope(scope)
+	if err != nil {
+		utils.HandleError(err, fmt.Sprintf("Invalid scope: %s", scope))
+	}
+
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	if configContents.OIDCSessions == nil {
+		configContents.OIDCSessions = map[string]models.OIDCSession{}
+	}
+	configContents.OIDCSessions[normalizedScope] = session
+	writeConfig(configContents)
+}
+
+// UpdateOIDCSessionToken saves the refreshed token for the OIDC session at the specified scope. Tokens are refreshed
+// in the background, so the config file is re-read under a lock to keep changes made since it was loaded.
+func UpdateOIDCSessionToken(scope string, token string, expiresAt time.Time) Error {
+	normalizedScope, err := NormalizeScope(scope)
+	if err != nil {
+		return Error{Err: err, Message: fmt.Sprintf("Invalid scope: %s", scope)}
+	}
+
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	lock, err := utils.LockFile(configLockPath(), true)
+	if err != nil {
+		return Error{Err: err, Message: "Unable to lock config file"}
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			utils.LogDebugError(err)
+		}
+	}()
+
+	config, uid, gid, readErr := tryReadConfig()
+	if !readErr.IsNil() {
+		return readErr
+	}
+
+	session, exists := config.OIDCSessions[normalizedScope]
+	if !exists {
+		return Error{}
+	}
+
+	configUid, configGid = uid, gid
+
+	scoped := config.Scoped[normalizedScope]
+	previousToken := scoped.Token
+	scoped.Token = storedTokenValue(token)
+	config.Scoped[normalizedScope] = scoped
+
+	session.ExpiresAt = expiresAt
+	config.OIDCSessions[normalizedScope] = session
+
+	// the previous token is only removed from the keyring once the config file no longer references it
+	if writeErr := tryWriteConfig(config); !writeErr.IsNil() {
+		if scoped.Token != previousToken {
+			deleteStoredToken(scoped.Token)
+		}
+		return writeErr
+	}
+	configContents = config
+	if scoped.Token != previousToken {
+		deleteStoredToken(previousToken)
+	}
+	return Error{}
+}
+
+// configLockPath the lock file coordinating updates to the config file between processes
+func configLockPath() string {
+	return UserConfigFile + ".lock"
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/zalando/go-keyring"
+)
+
+func TestOIDCSession(t *testing.T) {
+	keyring.MockInit()
+	originalConfigDir := UserConfigDir
+	SetConfigDir(t.TempDir())
+	originalConfigContents := configContents
+	defer func() {
+		SetConfigDir(originalConfigDir)
+		configContents = originalConfigContents
+	}()
+	configContents = models.ConfigFile{Scoped: map[string]models.FileScopedOptions{}}
+
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	Set("/", map[string]string{models.ConfigToken.String(): "dp.said.123"})
+	SetOIDCSession("/", models.OIDCSession{Identity: "identity", ExpiresAt: expiresAt, Provider: models.OIDCProviderGitHub})
+
+	session, exists := GetOIDCSession("/")
+	assert.True(t, exists)
+	assert.Equal(t, "identity", session.Identity)
+	assert.True(t, session.CanRefresh())
+
+	// refreshing replaces the token and expiration
+	refreshedAt := expiresAt.Add(time.Hour)
+	err := UpdateOIDCSessionToken("/", "dp.said.456", refreshedAt)
+	assert.True(t, err.IsNil())
+	session, _ = GetOIDCSession("/")
+	assert.Equal(t, refreshedAt, session.ExpiresAt)
+	assert.Equal(t, "dp.said.456", Get("/").Token.Value)
+
+	// refreshing keeps changes another process made to the config file since it was loaded
+	stale, _, _ := readConfig()
+	Set("/work", map[string]string{models.ConfigAPIHost.String(): "https://api.example.com"})
+	configContents = stale
+	err = UpdateOIDCSessionToken("/", "dp.said.789", refreshedAt)
+	assert.True(t, err.IsNil())
+	LoadConfig()
+	assert.Equal(t, "https://api.example.com", Get("/work").APIHost.Value)
+	assert.Equal(t, "dp.said.789", Get("/").Token.Value)
+
+	// an unreadable config file is reported rather than exiting, as refreshes happen in the background
+	assert.NoError(t, os.WriteFile(UserConfigFile, []byte("scoped: ["), 0600))
+	err = UpdateOIDCSessionToken("/", "dp.said.xyz", refreshedAt)
+	assert.False(t, err.IsNil())
+	assert.Equal(t, "dp.said.789", Get("/").Token.Value)
+	writeConfig(configContents)
+
+	// the session is removed when the token is replaced or removed
+	Set("/", map[string]string{models.ConfigToken.String(): "dp.said.abc"})
+	_, exists = GetOIDCSession("/")
+	assert.False(t, exists)
+
+	SetOIDCSession("/", models.OIDCSession{Identity: "identity", ExpiresAt: expiresAt})
+	Unset("/", []string{models.ConfigToken.String()})
+	_, exists = GetOIDCSession("/")
+	assert.False(t, exists)
+
+	// sessions can't be refreshed when the OIDC token was specified explicitly
+	assert.False(t, models.OIDCSession{Identity: "identity", ExpiresAt: expiresAt}.CanRefresh())
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+var profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// IsValidProfileName whether the name can be used for a profile
+func IsValidProfileName(name string) bool {
+	return profileNameRegex.MatchString(name)
+}
+
+// IsValidProfileOption whether the specified key can be stored in a profile
+func IsValidProfileOption(key string) bool {
+	_, exists := models.ProfileOptionsMap(models.ProfileOptions{})[key]
+	return exists
+}
+
+// Profiles get all profiles. Tokens are not retrieved from the system keyring.
+func Profiles() map[string]models.ProfileOptions {
+	profiles := map[string]models.ProfileOptions{}
+	for name, profile := range configContents.Profiles {
+		profiles[name] = profile
+	}
+	return profiles
+}
+
+// ProfileExists whether a profile with the specified name exists
+func ProfileExists(name string) bool {
+	_, exists := configContents.Profiles[name]
+	return exists
+}
+
+// SetProfile creates the profile if it doesn't exist, and sets the specified options on it
+func SetProfile(name string, options map[string]string) {
+	if !IsValidProfileName(name) {
+		utils.ErrExit(fmt.Errorf("invalid profile name %s", name), utils.ExitCodeValidation, "Profile names may only contain letters, numbers, periods, underscores, and dashes")
+	}
+
+	if configContents.Profiles == nil {
+		configContents.Profiles = map[string]models.ProfileOptions{}
+	}
+	profile := configContents.Profiles[name]
+
+	for key, value := range options {
+		if !IsValidProfileOption(key) {
+			utils.ErrExit(errors.New("invalid profile option "+key), utils.ExitCodeValidation, "")
+		}
+
+		if key == models.ConfigToken.String() {
+			value = storeToken(value, profile.Token)
+		}
+
+		setProfileValue(&profile, key, value)
+	}
+
+	configContents.Profiles[name] = profile
+	writeConfig(configContents)
+}
+
+// RemoveProfile deletes the profile, its token, and any references to it from scoped configs
+func RemoveProfile(name string) {
+	profile, exists := configContents.Profiles[name]
+	if !exists {
+		utils.ErrExit(fmt.Errorf("profile %s does not exist", name), utils.ExitCodeNotFound)
+	}
+
+	deleteStoredToken(profile.Token)
+	delete(configContents.Profiles, name)
+
+	for scope, options := range configContents.Scoped {
+		if options.Profile != name {
+			continue
+		}
+
+		utils.LogDebug(fmt.Sprintf("Removing profile from scope %s", scope))
+		options.Profile = ""
+		if options == (models.FileScopedOptions{}) {
+			delete(configContents.Scoped, scope)
+		} else {
+			configContents.Scoped[scope] = options
+		}
+	}
+
+	writeConfig(configContents)
+}
+
+// applyProfile sets the profile's options on the config. Unless override is specified, options are only
+// set if the profile is referenced from a more specific scope than the one the existing value came from.
+func applyProfile(conf *models.ScopedOptions, profile models.ProfileOptions, scope string, override bool) {
+	pairs := models.ScopedOptionsMap(conf)
+	for name, value := range models.ProfileOptionsMap(profile) {
+		if value == "" {
+			continue
+		}
+
+		pair := pairs[name]
+		if !override && *pair != (models.ScopedOption{}) && len(pair.Scope) >= len(scope) {
+			continue
+		}
+
+		if name == models.ConfigToken.String() {
+			token, err := ResolveToken(value)
+			if !err.IsNil() {
+				utils.HandleError(err.Unwrap(), err.Message)
+			}
+			value = token
+		}
+
+		pair.Value = value
+		pair.Scope = scope
+		pair.Source = models.ProfileSource.String()
+	}
+}
+
+// setProfileValue set the value for the specified key in the profile
+func setProfileValue(profile *models.ProfileOptions, key string, value string) {
+	if key == models.ConfigToken.String() {
+		(*profile).Token = value
+	} else if key == models.ConfigAPIHost.String() {
+		(*profile).APIHost = value
+	} else if key == models.ConfigDashboardHost.String() {
+		(*profile).DashboardHost = value
+	} else if key == models.ConfigVerifyTLS.String() {
+		(*profile).VerifyTLS = value
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyProfile(t *testing.T) {
+	profile := models.ProfileOptions{Token: "dp.pt.profile", APIHost: "https://api.acme.internal", VerifyTLS: "false"}
+	scopedConfig := func() models.ScopedOptions {
+		return models.ScopedOptions{
+			Token:         models.ScopedOption{Value: "dp.pt.scoped", Scope: "/home/user/acme", Source: models.ConfigFileSource.String()},
+			DashboardHost: models.ScopedOption{Value: "https://dashboard.acme.internal", Scope: "/", Source: models.ConfigFileSource.String()},
+		}
+	}
+
+	// options from a more specific scope take precedence over the profile
+	conf := scopedConfig()
+	applyProfile(&conf, profile, "/home/user", false)
+	assert.Equal(t, "dp.pt.scoped", conf.Token.Value)
+	assert.Equal(t, "https://api.acme.internal", conf.APIHost.Value)
+	assert.Equal(t, "/home/user", conf.APIHost.Scope)
+	assert.Equal(t, models.ProfileSource.String(), conf.APIHost.Source)
+	assert.Equal(t, "false", conf.VerifyTLS.Value)
+	assert.Equal(t, "https://dashboard.acme.internal", conf.DashboardHost.Value)
+
+	// the profile takes precedence over options from less specific scopes
+	conf = scopedConfig()
+	applyProfile(&conf, profile, "/home/user/acme/api", false)
+	assert.Equal(t, "dp.pt.profile", conf.Token.Value)
+	assert.Equal(t, "/home/user/acme/api", conf.Token.Scope)
+
+	// options set directly on the same scope take precedence over the profile
+	conf = scopedConfig()
+	applyProfile(&conf, profile, "/home/user/acme", false)
+	assert.Equal(t, "dp.pt.scoped", conf.Token.Value)
+
+	// an explicitly selected profile takes precedence, but unset profile options are left untouched
+	conf = scopedConfig()
+	applyProfile(&conf, profile, "/", true)
+	assert.Equal(t, "dp.pt.profile", conf.Token.Value)
+	assert.Equal(t, "https://dashboard.acme.internal", conf.DashboardHost.Value)
+}
+
+func TestIsValidProfileName(t *testing.T) {
+	assert.True(t, IsValidProfileName("acme"))
+	assert.True(t, IsValidProfileName("acme-self_hosted.2"))
+	assert.False(t, IsValidProfileName(""))
+	assert.False(t, IsValidProfileName("-acme"))
+	assert.False(t, IsValidProfileName("acme/prod"))
+	assert.False(t, IsValidProfileName("acme prod"))
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// replaced with the name of the directory matched by the scope (e.g. enclave.config: '{dir}')
+const scopeDirPlaceholder = "{dir}"
+
+const globChars = "*?["
+
+// IsGlobScope whether the scope contains glob patterns (e.g. /work/mono/services/*)
+func IsGlobScope(scope string) bool {
+	return strings.ContainsAny(scope, globChars)
+}
+
+// MatchScope whether the scope applies to the directory, returning the directory matched by the scope.
+// Literal scopes match the directory and its descendants. Glob scopes match the directory if its leading
+// path components match the pattern, so '/work/mono/services/*' matches '/work/mono/services/api/src'
+// and returns '/work/mono/services/api'.
+func MatchScope(scope string, dir string) (string, bool) {
+	separator := string(filepath.Separator)
+
+	if !IsGlobScope(scope) {
+		// both paths must end in / to prevent partial match (e.g. /test matching /test123)
+		scopePath := scope
+		if !strings.HasSuffix(scopePath, separator) {
+			scopePath = scopePath + separator
+		}
+		dirPath := dir
+		if !strings.HasSuffix(dirPath, separator) {
+			dirPath = dirPath + separator
+		}
+		return scope, strings.HasPrefix(dirPath, scopePath)
+	}
+
+	scopeParts := strings.Split(strings.TrimSuffix(scope, separator), separator)
+	dirParts := strings.Split(strings.TrimSuffix(dir, separator), separator)
+	if len(dirParts) < len(scopeParts) {
+		return "", false
+	}
+
+	matchedDir := strings.Join(dirParts[:len(scopeParts)], separator)
+	matches, err := filepath.Match(strings.TrimSuffix(scope, separator), matchedDir)
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Invalid glob scope %s", scope))
+		return "", false
+	}
+	return matchedDir, matches
+}
+
+// isMoreSpecificScope whether a matched scope takes precedence over another. Deeper directories take precedence,
+// then literal scopes over glob scopes, then globs with fewer wildcards. Remaining ties are broken by comparing
+// the globs, so that resolution is deterministic.
+func isMoreSpecificScope(scope string, rule string, otherScope string, otherRule string) bool {
+	if len(scope) != len(otherScope) {
+		return len(scope) > len(otherScope)
+	}
+	if (rule == "") != (otherRule == "") {
+		return rule == ""
+	}
+
+	wildcards := strings.Count(rule, "*") + strings.Count(rule, "?")
+	otherWildcards := strings.Count(otherRule, "*") + strings.Count(otherRule, "?")
+	if wildcards != otherWildcards {
+		return wildcards < otherWildcards
+	}
+	return rule < otherRule
+}
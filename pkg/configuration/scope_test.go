@@ -0,0 +1,91 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchScope(t *testing.T) {
+	matched, matches := MatchScope("/work/mono", "/work/mono/services/api")
+	assert.True(t, matches)
+	assert.Equal(t, "/work/mono", matched)
+
+	_, matches = MatchScope("/work/mono", "/work/monorepo")
+	assert.False(t, matches)
+
+	matched, matches = MatchScope("/", "/work")
+	assert.True(t, matches)
+	assert.Equal(t, "/", matched)
+
+	matched, matches = MatchScope("/work/mono/services/*", "/work/mono/services/api/src")
+	assert.True(t, matches)
+	assert.Equal(t, "/work/mono/services/api", matched)
+
+	matched, matches = MatchScope("/work/mono/services/*", "/work/mono/services/api")
+	assert.True(t, matches)
+	assert.Equal(t, "/work/mono/services/api", matched)
+
+	_, matches = MatchScope("/work/mono/services/*", "/work/mono/services")
+	assert.False(t, matches)
+
+	_, matches = MatchScope("/work/mono/services/*", "/")
+	assert.False(t, matches)
+
+	matched, matches = MatchScope("/work/*/services/api-?", "/work/mono/services/api-1")
+	assert.True(t, matches)
+	assert.Equal(t, "/work/mono/services/api-1", matched)
+
+	_, matches = MatchScope("/work/[", "/work/mono")
+	assert.False(t, matches)
+}
+
+func TestGetGlobScope(t *testing.T) {
+	originalConfigContents := configContents
+	defer func() { configContents = originalConfigContents }()
+
+	configContents = models.ConfigFile{
+		Scoped: map[string]models.FileScopedOptions{
+			"/":                      {APIHost: "https://api.doppler.com"},
+			"/work/mono":             {EnclaveProject: "mono", EnclaveConfig: "dev"},
+			"/work/mono/services/*":  {EnclaveConfig: "dev_{dir}"},
+			"/work/mono/services/?*": {EnclaveProject: "services", EnclaveConfig: "unused"},
+			"/work/mono/services/db": {EnclaveConfig: "dev_database"},
+		},
+	}
+
+	// globs with fewer wildcards take precedence
+	config := Get("/work/mono/services/api/src")
+	assert.Equal(t, "dev_api", config.EnclaveConfig.Value)
+	assert.Equal(t, "/work/mono/services/api", config.EnclaveConfig.Scope)
+	assert.Equal(t, "/work/mono/services/*", config.EnclaveConfig.Rule)
+	// deeper directories take precedence
+	assert.Equal(t, "services", config.EnclaveProject.Value)
+	assert.Equal(t, "/work/mono/services/api", config.EnclaveProject.Scope)
+	assert.Equal(t, "https://api.doppler.com", config.APIHost.Value)
+
+	// literal scopes take precedence over globs matching the same directory
+	config = Get("/work/mono/services/db")
+	assert.Equal(t, "dev_database", config.EnclaveConfig.Value)
+	assert.Equal(t, "", config.EnclaveConfig.Rule)
+
+	config = Get("/work/mono")
+	assert.Equal(t, "dev", config.EnclaveConfig.Value)
+	assert.Equal(t, "mono", config.EnclaveProject.Value)
+}
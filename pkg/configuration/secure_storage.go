@@ -0,0 +1,297 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/crypto"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// tokens that can't be stored in the system keyring are encrypted with a per-machine key
+const encryptedSecretPrefix = "encrypted"
+
+// tokens encrypted directly with the token key, rather than with a key derived from it. the token key is random, so
+// key derivation only adds latency. tokens without this prefix were encrypted by earlier versions using crypto.Encrypt
+const tokenKeyCipherPrefix = "aes256gcm"
+const tokenKeyFileName = ".token-key"
+const tokenKeyBytes = 32
+const keyringCheckID = "keyring-check"
+
+// SecureStorageEnabled whether tokens are stored in the system keyring (or an encrypted file) rather than in plaintext
+func SecureStorageEnabled() bool {
+	if configContents.SecureStorage != nil {
+		return *configContents.SecureStorage
+	}
+	return true
+}
+
+// IsEncryptedSecret checks whether the secret is encrypted with the token key
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, fmt.Sprintf("%s-", encryptedSecretPrefix))
+}
+
+// TokenKeyPath the path of the key used to encrypt tokens when the system keyring is unavailable
+func TokenKeyPath() string {
+	return filepath.Join(UserConfigDir, tokenKeyFileName)
+}
+
+// IsKeyringAvailable whether values can be saved to the system keyring
+func IsKeyringAvailable() bool {
+	if err := SetKeyring(keyringCheckID, "1"); !err.IsNil() {
+		utils.LogDebugError(err.Unwrap())
+		return false
+	}
+	if err := DeleteKeyring(keyringCheckID); !err.IsNil() {
+		utils.LogDebugError(err.Unwrap())
+	}
+	return true
+}
+
+// TokenStorageType how the token value from the config file is stored
+func TokenStorageType(value string) string {
+	if IsKeyringSecret(value) {
+		return models.TokenStorageKeyring
+	}
+	if IsEncryptedSecret(value) {
+		return models.TokenStorageEncrypted
+	}
+	return models.TokenStoragePlaintext
+}
+
+// ResolveToken retrieves the token referenced by the value from the config file
+func ResolveToken(value string) (string, Error) {
+	if IsKeyringSecret(value) {
+		utils.LogDebug(fmt.Sprintf("Retrieving %s from system keyring", models.ConfigToken.String()))
+		return GetKeyring(value)
+	}
+
+	if IsEncryptedSecret(value) {
+		utils.LogDebug(fmt.Sprintf("Decrypting %s with token key", models.ConfigToken.String()))
+		key, err := readTokenKey()
+		if err != nil {
+			return "", Error{Err: err, Message: fmt.Sprintf("Unable to read token key %s", TokenKeyPath())}
+		}
+
+		ciphertext := strings.TrimPrefix(value, encryptedSecretPrefix+"-")
+		var token string
+		if strings.HasPrefix(ciphertext, tokenKeyCipherPrefix+":") {
+			var keyBytes []byte
+			keyBytes, err = hex.DecodeString(key)
+			if err == nil {
+				token, err = crypto.DecryptWithKey(keyBytes, strings.TrimPrefix(ciphertext, tokenKeyCipherPrefix+":"))
+			}
+		} else {
+			token, err = crypto.Decrypt(key, []byte(ciphertext))
+		}
+		if err != nil {
+			return "", Error{Err: err, Message: "Unable to decrypt token"}
+		}
+		return token, Error{}
+	}
+
+	return value, Error{}
+}
+
+// storeToken saves the token to secure storage, returning the value that should be written to the config file.
+// The previous token is removed from the system keyring once it has been replaced.
+func storeToken(token string, previousToken string) string {
+	value := storedTokenValue(token)
+	if previousToken != value {
+		deleteStoredToken(previousToken)
+	}
+
+	return value
+}
+
+// storedTokenValue saves the token to secure storage when it's enabled, returning the value that should be written
+// to the config file
+func storedTokenValue(token string) string {
+	if SecureStorageEnabled() && token != "" {
+		return secureToken(token)
+	}
+	return token
+}
+
+// secureToken saves the token to the system keyring, or encrypts it with the token key if the keyring is unavailable
+func secureToken(token string) string {
+	utils.LogDebug(fmt.Sprintf("Saving %s to system keyring", models.ConfigToken.String()))
+	uuid, err := utils.UUID()
+	if err != nil {
+		utils.HandleError(err, "Unable to generate UUID for keyring")
+	}
+	id := GenerateKeyringID(uuid)
+
+	controllerError := SetKeyring(id, token)
+	if controllerError.IsNil() {
+		return id
+	}
+	utils.LogDebugError(controllerError.Unwrap())
+	utils.LogDebug(controllerError.Message)
+
+	utils.LogDebug(fmt.Sprintf("Encrypting %s with token key", models.ConfigToken.String()))
+	key, err := tokenKey()
+	if err != nil {
+		utils.LogDebugError(err)
+		utils.LogWarning("Unable to securely store token, saving it in plaintext")
+		return token
+	}
+	keyBytes, err := hex.DecodeString(key)
+	if err != nil {
+		utils.LogDebugError(err)
+		utils.LogWarning("Unable to securely store token, saving it in plaintext")
+		return token
+	}
+	ciphertext, err := crypto.EncryptWithKey(keyBytes, []byte(token))
+	if err != nil {
+		utils.LogDebugError(err)
+		utils.LogWarning("Unable to securely store token, saving it in plaintext")
+		return token
+	}
+
+	return fmt.Sprintf("%s-%s:%s", encryptedSecretPrefix, tokenKeyCipherPrefix, ciphertext)
+}
+
+// deleteStoredToken removes the token from the system keyring, if it's stored there
+func deleteStoredToken(value string) {
+	if !IsKeyringSecret(value) {
+		return
+	}
+
+	utils.LogDebug("Removing previous token from system keyring")
+	if controllerError := DeleteKeyring(value); !controllerError.IsNil() {
+		utils.LogDebugError(controllerError.Unwrap())
+		utils.LogDebug(controllerError.Message)
+	}
+}
+
+func readTokenKey() (string, error) {
+	bytes, err := os.ReadFile(TokenKeyPath()) // #nosec G304
+	if err != nil {
+		return "", err
+	}
+
+	key := strings.TrimSpace(string(bytes))
+	if key == "" {
+		return "", errors.New("token key file is empty")
+	}
+	return key, nil
+}
+
+// tokenKey reads the token key, generating it if it doesn't exist
+func tokenKey() (string, error) {
+	key, err := readTokenKey()
+	if err == nil || !os.IsNotExist(err) {
+		return key, err
+	}
+
+	buffer := make([]byte, tokenKeyBytes)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	key = hex.EncodeToString(buffer)
+
+	utils.LogDebug(fmt.Sprintf("Saving token key to %s", TokenKeyPath()))
+	if err := utils.WriteFile(TokenKeyPath(), []byte(key), 0600); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// EnableSecureStorage enables secure storage, moving all plaintext tokens into secure storage.
+// Encrypted tokens are moved into the system keyring if it's now available.
+func EnableSecureStorage() {
+	enabled := true
+	configContents.SecureStorage = &enabled
+
+	migrateTokens(func(value string) bool { return !IsKeyringSecret(value) })
+}
+
+// DisableSecureStorage disables secure storage, moving all tokens out of secure storage and into the config file
+func DisableSecureStorage() {
+	enabled := false
+	configContents.SecureStorage = &enabled
+
+	migrateTokens(func(value string) bool { return IsKeyringSecret(value) || IsEncryptedSecret(value) })
+}
+
+// migrateTokens re-stores each token that matches the filter, using the current storage setting
+func migrateTokens(filter func(value string) bool) {
+	migrate := func(value string, location string) string {
+		if value == "" || !filter(value) {
+			return value
+		}
+
+		token, err := ResolveToken(value)
+		if !err.IsNil() {
+			utils.LogDebugError(err.Unwrap())
+			utils.LogWarning(fmt.Sprintf("Unable to migrate token for %s. %s", location, err.Message))
+			return value
+		}
+
+		utils.LogDebug(fmt.Sprintf("Migrating token for %s", location))
+		return storeToken(token, value)
+	}
+
+	for scope, options := range configContents.Scoped {
+		options.Token = migrate(options.Token, fmt.Sprintf("scope %s", scope))
+		configContents.Scoped[scope] = options
+	}
+	for name, profile := range configContents.Profiles {
+		profile.Token = migrate(profile.Token, fmt.Sprintf("profile %s", name))
+		configContents.Profiles[name] = profile
+	}
+
+	writeConfig(configContents)
+}
+
+// GetSecureStorageStatus the secure storage setting, and how each token in the config file is stored
+func GetSecureStorageStatus() models.SecureStorageStatus {
+	status := models.SecureStorageStatus{
+		Enabled:          SecureStorageEnabled(),
+		KeyringAvailable: IsKeyringAvailable(),
+		Tokens:           []models.TokenStorage{},
+	}
+
+	for scope, options := range configContents.Scoped {
+		if options.Token != "" {
+			status.Tokens = append(status.Tokens, models.TokenStorage{Scope: scope, Storage: TokenStorageType(options.Token)})
+		}
+	}
+	for name, profile := range configContents.Profiles {
+		if profile.Token != "" {
+			status.Tokens = append(status.Tokens, models.TokenStorage{Profile: name, Storage: TokenStorageType(profile.Token)})
+		}
+	}
+
+	sort.Slice(status.Tokens, func(a, b int) bool {
+		if status.Tokens[a].Profile != status.Tokens[b].Profile {
+			return status.Tokens[a].Profile < status.Tokens[b].Profile
+		}
+		return status.Tokens[a].Scope < status.Tokens[b].Scope
+	})
+
+	return status
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/crypto"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/zalando/go-keyring"
+)
+
+func TestStoreToken(t *testing.T) {
+	originalConfigDir := UserConfigDir
+	SetConfigDir(t.TempDir())
+	defer SetConfigDir(originalConfigDir)
+	defer func() { configContents.SecureStorage = nil }()
+
+	token := "dp.pt.123"
+
+	// keyring
+	keyring.MockInit()
+	value := storeToken(token, "")
+	assert.True(t, IsKeyringSecret(value))
+	assert.Equal(t, models.TokenStorageKeyring, TokenStorageType(value))
+	resolved, err := ResolveToken(value)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, token, resolved)
+
+	// replacing the token removes the previous one from the keyring
+	newValue := storeToken("dp.pt.456", value)
+	_, err = GetKeyring(value)
+	assert.False(t, err.IsNil())
+	resolved, err = ResolveToken(newValue)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, "dp.pt.456", resolved)
+
+	// encrypted file when the keyring is unavailable
+	keyring.MockInitWithError(errors.New("keyring unavailable"))
+	value = storeToken(token, "")
+	assert.True(t, IsEncryptedSecret(value))
+	assert.Equal(t, models.TokenStorageEncrypted, TokenStorageType(value))
+	assert.NotContains(t, value, token)
+	assert.True(t, strings.HasPrefix(value, encryptedSecretPrefix+"-"+tokenKeyCipherPrefix+":"))
+	resolved, err = ResolveToken(value)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, token, resolved)
+
+	// tokens encrypted by earlier versions are encrypted with a key derived from the token key
+	key, keyErr := readTokenKey()
+	assert.NoError(t, keyErr)
+	ciphertext, encryptErr := crypto.Encrypt(key, []byte(token), models.Base64EncodingPrefix)
+	assert.NoError(t, encryptErr)
+	resolved, err = ResolveToken(encryptedSecretPrefix + "-" + ciphertext)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, token, resolved)
+
+	// plaintext when secure storage is disabled
+	disabled := false
+	configContents.SecureStorage = &disabled
+	value = storeToken(token, "")
+	assert.Equal(t, token, value)
+	assert.Equal(t, models.TokenStoragePlaintext, TokenStorageType(value))
+	resolved, err = ResolveToken(value)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, token, resolved)
+}
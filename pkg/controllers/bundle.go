@@ -0,0 +1,157 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/DopplerHQ/cli/pkg/models"
+)
+
+// the first line of every bundle, identifying the file type
+const bundleHeader = "doppler-bundle"
+
+// A bundle consists of the header line, a line of JSON metadata, and the age-encrypted bundle contents.
+// The metadata is stored in plaintext so that it can be inspected without an identity; the encrypted
+// contents include a copy of it, which is authoritative.
+
+// EncryptBundle encrypts the bundle to each of the recipients
+func EncryptBundle(bundle models.Bundle, recipients []string) ([]byte, Error) {
+	if len(recipients) == 0 {
+		return nil, Error{Err: errors.New("at least one recipient must be specified"), Message: "Unable to create bundle"}
+	}
+
+	ageRecipients, err := age.ParseRecipients(strings.NewReader(strings.Join(recipients, "\n")))
+	if err != nil {
+		return nil, Error{Err: err, Message: "Unable to parse bundle recipients"}
+	}
+
+	bundle.Version = models.BundleVersion
+	bundle.Recipients = recipients
+
+	metadata, err := json.Marshal(bundle.BundleMetadata)
+	if err != nil {
+		return nil, Error{Err: err, Message: "Unable to marshal bundle metadata"}
+	}
+	payload, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, Error{Err: err, Message: "Unable to marshal bundle"}
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(bundleHeader + "\n")
+	buffer.Write(metadata)
+	buffer.WriteString("\n")
+
+	armorWriter := armor.NewWriter(&buffer)
+	writer, err := age.Encrypt(armorWriter, ageRecipients...)
+	if err != nil {
+		return nil, Error{Err: err, Message: "Unable to encrypt bundle"}
+	}
+	if _, err := writer.Write(payload); err != nil {
+		return nil, Error{Err: err, Message: "Unable to encrypt bundle"}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, Error{Err: err, Message: "Unable to encrypt bundle"}
+	}
+	if err := armorWriter.Close(); err != nil {
+		return nil, Error{Err: err, Message: "Unable to encrypt bundle"}
+	}
+	buffer.WriteString("\n")
+
+	return buffer.Bytes(), Error{}
+}
+
+// ReadBundleMetadata parses the bundle's plaintext metadata, returning it along with the encrypted contents
+func ReadBundleMetadata(data []byte) (models.BundleMetadata, []byte, Error) {
+	parts := bytes.SplitN(data, []byte("\n"), 3)
+	if len(parts) != 3 || strings.TrimSpace(string(parts[0])) != bundleHeader {
+		return models.BundleMetadata{}, nil, Error{Err: errors.New("file is not a Doppler bundle"), Message: "Unable to read bundle"}
+	}
+
+	var metadata models.BundleMetadata
+	if err := json.Unmarshal(parts[1], &metadata); err != nil {
+		return models.BundleMetadata{}, nil, Error{Err: err, Message: "Unable to parse bundle metadata"}
+	}
+	if metadata.Version != models.BundleVersion {
+		return models.BundleMetadata{}, nil, Error{Err: fmt.Errorf("unsupported bundle version %q", metadata.Version), Message: "Unable to read bundle"}
+	}
+
+	return metadata, parts[2], Error{}
+}
+
+// DecryptBundle decrypts the bundle using one of the identities, which are in the age identity file format
+func DecryptBundle(data []byte, identities []byte) (models.Bundle, Error) {
+	metadata, ciphertext, e := ReadBundleMetadata(data)
+	if !e.IsNil() {
+		return models.Bundle{}, e
+	}
+
+	ageIdentities, err := age.ParseIdentities(bytes.NewReader(identities))
+	if err != nil {
+		return models.Bundle{}, Error{Err: err, Message: "Unable to parse identity file"}
+	}
+
+	reader, err := age.Decrypt(armor.NewReader(bytes.NewReader(ciphertext)), ageIdentities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return models.Bundle{}, Error{Err: err, Message: "Unable to decrypt bundle. The identity does not match any of the bundle's recipients"}
+		}
+		return models.Bundle{}, Error{Err: err, Message: "Unable to decrypt bundle"}
+	}
+	payload, err := io.ReadAll(reader)
+	if err != nil {
+		return models.Bundle{}, Error{Err: err, Message: "Unable to decrypt bundle"}
+	}
+
+	var bundle models.Bundle
+	if err := json.Unmarshal(payload, &bundle); err != nil {
+		return models.Bundle{}, Error{Err: err, Message: "Unable to parse bundle"}
+	}
+
+	// the plaintext metadata isn't authenticated, so ensure it hasn't been modified
+	expected, err := json.Marshal(bundle.BundleMetadata)
+	if err != nil {
+		return models.Bundle{}, Error{Err: err, Message: "Unable to marshal bundle metadata"}
+	}
+	actual, err := json.Marshal(metadata)
+	if err != nil {
+		return models.Bundle{}, Error{Err: err, Message: "Unable to marshal bundle metadata"}
+	}
+	if !bytes.Equal(expected, actual) {
+		return models.Bundle{}, Error{Err: errors.New("bundle metadata does not match its encrypted contents"), Message: "Bundle has been modified"}
+	}
+
+	return bundle, Error{}
+}
+
+// GenerateBundleIdentity generates a new identity, returning it and its public key
+func GenerateBundleIdentity() (string, string, Error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", Error{Err: err, Message: "Unable to generate identity"}
+	}
+
+	return identity.String(), identity.Recipient().String(), Error{}
+}
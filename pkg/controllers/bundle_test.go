@@ -0,0 +1,72 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundle(t *testing.T) {
+	identityA, recipientA, Err := GenerateBundleIdentity()
+	require.True(t, Err.IsNil())
+	identityB, recipientB, Err := GenerateBundleIdentity()
+	require.True(t, Err.IsNil())
+	identityC, _, Err := GenerateBundleIdentity()
+	require.True(t, Err.IsNil())
+
+	expiresAt := time.Now().UTC().Add(time.Hour)
+	bundle := models.Bundle{
+		BundleMetadata: models.BundleMetadata{Project: "backend", Config: "prd", CreatedAt: time.Now().UTC(), ExpiresAt: &expiresAt},
+		Secrets:        map[string]string{"API_KEY": "123"},
+	}
+	data, Err := EncryptBundle(bundle, []string{recipientA, recipientB})
+	require.True(t, Err.IsNil())
+
+	metadata, _, Err := ReadBundleMetadata(data)
+	require.True(t, Err.IsNil())
+	assert.Equal(t, "backend", metadata.Project)
+	assert.Equal(t, []string{recipientA, recipientB}, metadata.Recipients)
+	assert.False(t, metadata.IsExpired(time.Now()))
+	assert.True(t, metadata.IsExpired(expiresAt.Add(time.Second)))
+
+	// each recipient can decrypt the bundle
+	for _, identity := range []string{identityA, identityB} {
+		decrypted, Err := DecryptBundle(data, []byte("# comment\n"+identity+"\n"))
+		require.True(t, Err.IsNil())
+		assert.Equal(t, bundle.Secrets, decrypted.Secrets)
+		assert.Equal(t, "prd", decrypted.Config)
+	}
+
+	_, Err = DecryptBundle(data, []byte(identityC))
+	assert.False(t, Err.IsNil())
+
+	// the plaintext metadata can't be modified
+	modified := bytes.Replace(data, []byte(`"config":"prd"`), []byte(`"config":"dev"`), 1)
+	_, Err = DecryptBundle(modified, []byte(identityA))
+	assert.False(t, Err.IsNil())
+
+	_, _, Err = ReadBundleMetadata([]byte("not a bundle\n{}\n"))
+	assert.False(t, Err.IsNil())
+
+	_, Err = EncryptBundle(bundle, []string{"not-a-recipient"})
+	assert.False(t, Err.IsNil())
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// the default version of the kubectl exec credential API, when kubectl doesn't specify one
+const defaultKubectlExecCredentialAPIVersion = "client.authentication.k8s.io/v1"
+
+// CredentialHelperFields the credentials provided for each protocol
+var CredentialHelperFields = map[string][]models.CredentialField{
+	models.CredentialHelperGit: {
+		{Name: "username", DefaultSecret: "GIT_USERNAME"},
+		{Name: "password", DefaultSecret: "GIT_PASSWORD", Required: true},
+	},
+	models.CredentialHelperDocker: {
+		{Name: "username", DefaultSecret: "DOCKER_USERNAME", Required: true},
+		{Name: "secret", DefaultSecret: "DOCKER_PASSWORD", Required: true},
+	},
+	models.CredentialHelperAWS: {
+		{Name: "access-key-id", DefaultSecret: "AWS_ACCESS_KEY_ID", Required: true},
+		{Name: "secret-access-key", DefaultSecret: "AWS_SECRET_ACCESS_KEY", Required: true},
+		{Name: "session-token", DefaultSecret: "AWS_SESSION_TOKEN"},
+	},
+	models.CredentialHelperKubectl: {
+		{Name: "token", DefaultSecret: "KUBERNETES_TOKEN"},
+		{Name: "client-certificate", DefaultSecret: "KUBERNETES_CLIENT_CERTIFICATE"},
+		{Name: "client-key", DefaultSecret: "KUBERNETES_CLIENT_KEY"},
+	},
+	models.CredentialHelperTerraform: {
+		{Name: "token", DefaultSecret: "TERRAFORM_TOKEN", Required: true},
+	},
+}
+
+// CredentialFieldNames the names of the protocol's credentials
+func CredentialFieldNames(protocol string) []string {
+	var names []string
+	for _, field := range CredentialHelperFields[protocol] {
+		names = append(names, field.Name)
+	}
+	return names
+}
+
+// ReadCredentialMappingFile reads the protocol's mapping from the file. The file maps each protocol's credentials
+// to secret names, e.g. {"docker": {"username": "REGISTRY_USER", "secret": "REGISTRY_PASSWORD"}}
+func ReadCredentialMappingFile(path string, protocol string) (map[string]string, Error) {
+	bytes, err := ioutil.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, Error{Err: err, Message: "Unable to read credential mapping file"}
+	}
+
+	var mappings map[string]map[string]string
+	if err := yaml.Unmarshal(bytes, &mappings); err != nil {
+		return nil, Error{Err: err, Message: "Unable to parse credential mapping file"}
+	}
+	for name := range mappings {
+		if !models.IsValidCredentialHelperProtocol(name) {
+			return nil, Error{Err: fmt.Errorf("invalid protocol %s. Valid protocols are %v", name, models.CredentialHelperProtocols), Message: "Unable to parse credential mapping file"}
+		}
+	}
+
+	return mappings[protocol], Error{}
+}
+
+// ResolveCredentialMapping the secret each of the protocol's credentials is read from. Mappings are applied in order,
+// with later mappings taking precedence over earlier ones and over the default secret names.
+func ResolveCredentialMapping(protocol string, mappings ...map[string]string) (map[string]models.CredentialSecret, error) {
+	fields := CredentialHelperFields[protocol]
+	resolved := map[string]models.CredentialSecret{}
+	for _, field := range fields {
+		resolved[field.Name] = models.CredentialSecret{Name: field.DefaultSecret, Required: field.Required}
+	}
+
+	for _, mapping := range mappings {
+		for name, secret := range mapping {
+			if _, exists := resolved[name]; !exists {
+				return nil, fmt.Errorf("invalid %s credential %s. Valid credentials are %s", protocol, name, strings.Join(CredentialFieldNames(protocol), ", "))
+			}
+			if secret == "" {
+				return nil, fmt.Errorf("no secret specified for %s credential %s", protocol, name)
+			}
+			resolved[name] = models.CredentialSecret{Name: secret, Required: true}
+		}
+	}
+
+	return resolved, nil
+}
+
+// CredentialSecretNames the names of the secrets the credentials are read from, sorted and deduplicated
+func CredentialSecretNames(mapping map[string]models.CredentialSecret) []string {
+	var names []string
+	for _, secret := range mapping {
+		if !utils.Contains(names, secret.Name) {
+			names = append(names, secret.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CredentialValues the value of each credential. Credentials whose secret doesn't exist are omitted, unless they're required.
+func CredentialValues(mapping map[string]models.CredentialSecret, secrets map[string]string) (map[string]string, error) {
+	values := map[string]string{}
+	var missing []string
+	for name, secret := range mapping {
+		value, exists := secrets[secret.Name]
+		if !exists {
+			if secret.Required {
+				missing = append(missing, secret.Name)
+			}
+			continue
+		}
+		values[name] = value
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("Could not find requested secrets: %s", strings.Join(missing, ", "))
+	}
+	return values, nil
+}
+
+// GitCredential the response to a git credential helper's get action. Git's credential protocol is line-based, so
+// values containing a newline or NUL are rejected, as git itself does.
+func GitCredential(values map[string]string) (string, error) {
+	var lines []string
+	for _, name := range []string{"username", "password"} {
+		value := values[name]
+		if strings.ContainsAny(value, "\n\x00") {
+			return "", fmt.Errorf("git credential %s must not contain a newline or NUL character", name)
+		}
+		if value != "" || name == "password" {
+			lines = append(lines, fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// DockerCredential the response to a docker credential helper's get action
+func DockerCredential(serverURL string, values map[string]string) models.DockerCredential {
+	return models.DockerCredential{ServerURL: serverURL, Username: values["username"], Secret: values["secret"]}
+}
+
+// AWSCredential the output of an AWS credential_process
+func AWSCredential(values map[string]string) models.AWSCredential {
+	return models.AWSCredential{Version: 1, AccessKeyID: values["access-key-id"], SecretAccessKey: values["secret-access-key"], SessionToken: values["session-token"]}
+}
+
+// KubectlExecCredential the output of a kubectl exec credential plugin. Either a token, or a client certificate and key, are required.
+func KubectlExecCredential(values map[string]string) (models.KubectlExecCredential, error) {
+	status := models.KubectlExecCredentialStatus{Token: values["token"], ClientCertificateData: values["client-certificate"], ClientKeyData: values["client-key"]}
+	if status.Token == "" && (status.ClientCertificateData == "" || status.ClientKeyData == "") {
+		return models.KubectlExecCredential{}, errors.New("either a token, or a client certificate and client key, must be provided")
+	}
+
+	return models.KubectlExecCredential{APIVersion: kubectlExecCredentialAPIVersion(), Kind: "ExecCredential", Status: status}, nil
+}
+
+// kubectlExecCredentialAPIVersion the API version kubectl requested via KUBERNETES_EXEC_INFO
+func kubectlExecCredentialAPIVersion() string {
+	execInfo := os.Getenv("KUBERNETES_EXEC_INFO")
+	if execInfo == "" {
+		return defaultKubectlExecCredentialAPIVersion
+	}
+
+	var info struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal([]byte(execInfo), &info); err != nil || info.APIVersion == "" {
+		utils.LogDebug("Unable to parse KUBERNETES_EXEC_INFO")
+		return defaultKubectlExecCredentialAPIVersion
+	}
+	return info.APIVersion
+}
+
+// TerraformCredential the response to a terraform credentials helper's get action
+func TerraformCredential(values map[string]string) models.TerraformCredential {
+	return models.TerraformCredential{Token: values["token"]}
+}
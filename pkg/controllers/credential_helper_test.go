@@ -0,0 +1,116 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCredentialMapping(t *testing.T) {
+	mapping, err := ResolveCredentialMapping(models.CredentialHelperAWS)
+	require.NoError(t, err)
+	assert.Equal(t, models.CredentialSecret{Name: "AWS_ACCESS_KEY_ID", Required: true}, mapping["access-key-id"])
+	assert.Equal(t, models.CredentialSecret{Name: "AWS_SESSION_TOKEN", Required: false}, mapping["session-token"])
+
+	// later mappings take precedence, and mapped secrets are always required
+	mapping, err = ResolveCredentialMapping(models.CredentialHelperAWS, map[string]string{"session-token": "FILE_TOKEN", "access-key-id": "FILE_KEY"}, map[string]string{"session-token": "FLAG_TOKEN"})
+	require.NoError(t, err)
+	assert.Equal(t, models.CredentialSecret{Name: "FILE_KEY", Required: true}, mapping["access-key-id"])
+	assert.Equal(t, models.CredentialSecret{Name: "FLAG_TOKEN", Required: true}, mapping["session-token"])
+	assert.Equal(t, []string{"AWS_SECRET_ACCESS_KEY", "FILE_KEY", "FLAG_TOKEN"}, CredentialSecretNames(mapping))
+
+	_, err = ResolveCredentialMapping(models.CredentialHelperGit, map[string]string{"token": "GITHUB_TOKEN"})
+	assert.Error(t, err)
+	_, err = ResolveCredentialMapping(models.CredentialHelperGit, map[string]string{"password": ""})
+	assert.Error(t, err)
+}
+
+func TestCredentialValues(t *testing.T) {
+	mapping, err := ResolveCredentialMapping(models.CredentialHelperGit, map[string]string{"password": "GITHUB_TOKEN"})
+	require.NoError(t, err)
+
+	// optional credentials are omitted when their default secret doesn't exist
+	values, err := CredentialValues(mapping, map[string]string{"GITHUB_TOKEN": "ghp_123"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "ghp_123"}, values)
+	credential, err := GitCredential(values)
+	require.NoError(t, err)
+	assert.Equal(t, "password=ghp_123\n", credential)
+
+	values, err = CredentialValues(mapping, map[string]string{"GITHUB_TOKEN": "ghp_123", "GIT_USERNAME": "octocat"})
+	require.NoError(t, err)
+	credential, err = GitCredential(values)
+	require.NoError(t, err)
+	assert.Equal(t, "username=octocat\npassword=ghp_123\n", credential)
+
+	_, err = CredentialValues(mapping, map[string]string{"GIT_PASSWORD": "ghp_123"})
+	assert.EqualError(t, err, "Could not find requested secrets: GITHUB_TOKEN")
+}
+
+func TestGitCredentialRejectsMultilineValues(t *testing.T) {
+	// values can't inject additional attributes into git's line-based protocol
+	for _, values := range []map[string]string{
+		{"password": "ghp_123\nurl=https://attacker.example.com"},
+		{"password": "ghp_123\n"},
+		{"username": "octocat\npassword=hunter2", "password": "ghp_123"},
+		{"password": "ghp_\x00123"},
+	} {
+		_, err := GitCredential(values)
+		assert.Error(t, err)
+	}
+}
+
+func TestReadCredentialMappingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("docker:\n  username: REGISTRY_USERNAME\n  secret: REGISTRY_PASSWORD\n"), 0600))
+
+	mapping, err := ReadCredentialMappingFile(path, models.CredentialHelperDocker)
+	require.True(t, err.IsNil())
+	assert.Equal(t, map[string]string{"username": "REGISTRY_USERNAME", "secret": "REGISTRY_PASSWORD"}, mapping)
+
+	mapping, err = ReadCredentialMappingFile(path, models.CredentialHelperGit)
+	require.True(t, err.IsNil())
+	assert.Empty(t, mapping)
+
+	require.NoError(t, os.WriteFile(path, []byte("npm:\n  token: NPM_TOKEN\n"), 0600))
+	_, err = ReadCredentialMappingFile(path, models.CredentialHelperDocker)
+	assert.False(t, err.IsNil())
+}
+
+func TestKubectlExecCredential(t *testing.T) {
+	t.Setenv("KUBERNETES_EXEC_INFO", "")
+
+	credential, err := KubectlExecCredential(map[string]string{"token": "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, "client.authentication.k8s.io/v1", credential.APIVersion)
+	assert.Equal(t, "ExecCredential", credential.Kind)
+	assert.Equal(t, "abc", credential.Status.Token)
+
+	t.Setenv("KUBERNETES_EXEC_INFO", `{"apiVersion":"client.authentication.k8s.io/v1beta1","kind":"ExecCredential"}`)
+	credential, err = KubectlExecCredential(map[string]string{"client-certificate": "cert", "client-key": "key"})
+	require.NoError(t, err)
+	assert.Equal(t, "client.authentication.k8s.io/v1beta1", credential.APIVersion)
+	assert.Equal(t, "cert", credential.Status.ClientCertificateData)
+
+	_, err = KubectlExecCredential(map[string]string{"client-certificate": "cert"})
+	assert.Error(t, err)
+}
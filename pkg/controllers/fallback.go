@@ -16,20 +16,27 @@ limitations under the License.
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
 	"github.com/DopplerHQ/cli/pkg/crypto"
 	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/telemetry"
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"gopkg.in/yaml.v3"
 )
 
+const fallbackPayloadVersion = "1"
+const fallbackLocksDir = "locks"
+
 func GenerateFallbackFileHash(token string, project string, config string, format models.SecretsFormat, nameTransformer *models.SecretsNameTransformer, secretNames []string) string {
 	parts := []string{token}
 	if project != "" && config != "" {
@@ -97,13 +104,14 @@ func MetadataFile(path string) (models.SecretsFileMetadata, Error) {
 }
 
 // WriteMetadataFile writes the contents of the metadata file
-func WriteMetadataFile(path string, etag string, hash string) Error {
+func WriteMetadataFile(path string, etag string, hash string, syncedAt time.Time) Error {
 	utils.LogDebug(fmt.Sprintf("Writing ETag to metadata file %s", path))
 
 	metadata := models.SecretsFileMetadata{
-		Version: "1",
-		ETag:    etag,
-		Hash:    hash,
+		Version:  "1",
+		ETag:     etag,
+		Hash:     hash,
+		SyncedAt: syncedAt,
 	}
 
 	metadataBytes, err := yaml.Marshal(metadata)
@@ -118,18 +126,27 @@ func WriteMetadataFile(path string, etag string, hash string) Error {
 	return Error{}
 }
 
-// SecretsCacheFileBytes reads the raw contents of the cache file
-func SecretsCacheFileBytes(path string, passphrase string) ([]byte, Error) {
+// SecretsCacheFileBytes reads the raw contents of the cache file. If a hash is specified, the cache file must match it.
+func SecretsCacheFileBytes(path string, passphrase string, hash string) ([]byte, Error) {
 	utils.LogDebug(fmt.Sprintf("Using fallback file for cache %s", path))
 
+	span := telemetry.StartSpan("fallback.read", map[string]interface{}{"doppler.fallback.cache": true})
+	defer span.End()
+
 	if _, err := os.Stat(path); err != nil {
 		return nil, Error{Err: err, Message: "Unable to stat cache file"}
 	}
 
+	unlock := lockFallbackFile(path, false)
 	response, err := ioutil.ReadFile(path) // #nosec G304
+	unlock()
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to read cache file"}
 	}
+	// the cache file may have been replaced since its ETag was read
+	if hash != "" && crypto.Hash(string(response)) != hash {
+		return nil, Error{Err: errors.New("cache file has changed"), Message: "Cache file failed hash check"}
+	}
 
 	utils.LogDebug("Decrypting cache file")
 	decryptedSecrets, err := crypto.Decrypt(passphrase, response)
@@ -137,5 +154,114 @@ func SecretsCacheFileBytes(path string, passphrase string) ([]byte, Error) {
 		return nil, Error{Err: err, Message: "Unable to decrypt cache file"}
 	}
 
-	return []byte(decryptedSecrets), Error{}
+	fileVersion, _ := models.FileVersion(string(response))
+	secrets, _ := UnwrapFallbackPayload([]byte(decryptedSecrets), fileVersion)
+	return secrets, Error{}
+}
+
+// markMetadataFileSynced records in the metadata file that the fallback file's contents were confirmed to be current.
+// The sync time is stored outside the fallback file so that it can be updated without re-encrypting the secrets.
+func markMetadataFileSynced(metadataPath string, fallbackPath string, hash string) Error {
+	unlock := lockFallbackFile(fallbackPath, true)
+	defer unlock()
+
+	metadata, Err := MetadataFile(metadataPath)
+	if !Err.IsNil() {
+		return Err
+	}
+	// another process may have written newer secrets since the fallback file was read
+	if metadata.Hash != hash {
+		utils.LogDebug("Fallback file has changed, not marking it as synced")
+		return Error{}
+	}
+
+	return WriteMetadataFile(metadataPath, metadata.ETag, metadata.Hash, time.Now().UTC())
+}
+
+// fallbackFileSyncedAt the last time the fallback file's contents were confirmed to be current, according to its
+// metadata file. Returns the zero time if the metadata file doesn't describe the fallback file's current contents.
+func fallbackFileSyncedAt(metadataPath string, contents []byte) time.Time {
+	if metadataPath == "" {
+		return time.Time{}
+	}
+
+	metadata, Err := MetadataFile(metadataPath)
+	if !Err.IsNil() || metadata.Hash != crypto.Hash(string(contents)) {
+		return time.Time{}
+	}
+	return metadata.SyncedAt
+}
+
+// lockFallbackFile acquires an advisory lock coordinating access to the fallback file and its metadata file
+// between processes, returning a function that releases it. Locking is best effort; if the lock can't be
+// acquired, the caller proceeds without it. Lock files are never deleted, as a process waiting on a removed
+// lock file would hold its lock at the same time as one locking a newly created file at the same path.
+func lockFallbackFile(path string, exclusive bool) func() {
+	dir := filepath.Join(configuration.UserFallbackDir, fallbackLocksDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		utils.LogDebugError(err)
+		return func() {}
+	}
+
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	// lock files are kept in the fallback directory, even when the fallback file is elsewhere
+	lock, err := utils.LockFile(filepath.Join(dir, crypto.Hash(path)+".lock"), exclusive)
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Unable to lock %s", path))
+		utils.LogDebugError(err)
+		return func() {}
+	}
+
+	return func() {
+		if err := lock.Unlock(); err != nil {
+			utils.LogDebugError(err)
+		}
+	}
+}
+
+// wrapFallbackPayload wraps the secrets in an envelope that records when they were written
+func wrapFallbackPayload(secrets []byte, writtenAt time.Time) ([]byte, error) {
+	return json.Marshal(models.FallbackPayload{Version: fallbackPayloadVersion, WrittenAt: writtenAt, Secrets: secrets})
+}
+
+// UnwrapFallbackPayload extracts the secrets from the decrypted contents of a fallback file with the specified file
+// version. Legacy file versions only contain the secrets, so their write time is unknown and returned as zero.
+func UnwrapFallbackPayload(contents []byte, fileVersion int) ([]byte, time.Time) {
+	if !models.FileVersions[fileVersion].HasPayload {
+		return contents, time.Time{}
+	}
+
+	var payload models.FallbackPayload
+	if err := json.Unmarshal(contents, &payload); err != nil {
+		utils.LogDebugError(err)
+		return contents, time.Time{}
+	}
+
+	return payload.Secrets, payload.WrittenAt
+}
+
+// checkFallbackFileAge enforces the max age of a fallback file, either failing with the exit code or warning when it's exceeded
+func checkFallbackFileAge(writtenAt time.Time, maxAge time.Duration, staleAction string, exitCode int) {
+	if maxAge <= 0 {
+		return
+	}
+
+	var msg string
+	if writtenAt.IsZero() {
+		msg = "The age of the fallback file is unknown, as it was written by an older version of the CLI"
+	} else if age := time.Since(writtenAt); age > maxAge {
+		msg = fmt.Sprintf("The fallback file is %s old, which exceeds the max age of %s", age.Round(time.Second), maxAge)
+	} else {
+		utils.LogDebug(fmt.Sprintf("Fallback file was written %s ago", age.Round(time.Second)))
+		return
+	}
+
+	if staleAction == models.FallbackStaleWarn {
+		utils.LogWarning(fmt.Sprintf("%s. Its secrets may be out of date.", msg))
+		return
+	}
+
+	utils.ErrExit(errors.New(msg), exitCode, "Refusing to use stale fallback file", "Use '--fallback-stale=warn' to use the fallback file anyway.")
 }
@@ -0,0 +1,365 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/crypto"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// FallbackIndexPath the path of the fallback index file
+func FallbackIndexPath() string {
+	return filepath.Join(configuration.UserFallbackDir, configuration.FallbackIndexFileName)
+}
+
+// IsFallbackIndexFile whether the path refers to the fallback index file
+func IsFallbackIndexFile(path string) bool {
+	return filepath.Base(path) == configuration.FallbackIndexFileName
+}
+
+// DefaultFallbackPassphrase the passphrase used to encrypt a fallback file when none is specified
+func DefaultFallbackPassphrase(token string, project string, config string) string {
+	if project != "" && config != "" {
+		return fmt.Sprintf("%s:%s:%s", token, project, config)
+	}
+
+	return token
+}
+
+// ReadFallbackIndex reads the fallback index. A missing index is treated as empty.
+func ReadFallbackIndex() (models.FallbackIndex, Error) {
+	index := models.FallbackIndex{Version: "1", Files: map[string]models.FallbackIndexEntry{}}
+
+	path := FallbackIndexPath()
+	bytes, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, Error{}
+		}
+		return index, Error{Err: err, Message: "Unable to read fallback index"}
+	}
+
+	if err := json.Unmarshal(bytes, &index); err != nil {
+		return index, Error{Err: err, Message: "Unable to parse fallback index"}
+	}
+	if index.Files == nil {
+		index.Files = map[string]models.FallbackIndexEntry{}
+	}
+
+	return index, Error{}
+}
+
+func modifyFallbackIndex(fn func(index *models.FallbackIndex)) Error {
+	unlock := lockFallbackFile(FallbackIndexPath(), true)
+	defer unlock()
+
+	index, Err := ReadFallbackIndex()
+	if !Err.IsNil() {
+		// an unreadable index shouldn't prevent new entries from being recorded
+		utils.LogDebugError(Err.Unwrap())
+		utils.LogDebug(Err.Message)
+		index = models.FallbackIndex{Version: "1", Files: map[string]models.FallbackIndexEntry{}}
+	}
+
+	fn(&index)
+
+	bytes, err := json.Marshal(index)
+	if err != nil {
+		return Error{Err: err, Message: "Unable to marshal fallback index"}
+	}
+	if err := utils.WriteFile(FallbackIndexPath(), bytes, utils.RestrictedFilePerms()); err != nil {
+		return Error{Err: err, Message: "Unable to write fallback index"}
+	}
+
+	return Error{}
+}
+
+// UpdateFallbackIndex records the entry in the fallback index, replacing any existing entry for the same file
+func UpdateFallbackIndex(entry models.FallbackIndexEntry) Error {
+	utils.LogDebug(fmt.Sprintf("Updating fallback index for %s", entry.Path))
+	return modifyFallbackIndex(func(index *models.FallbackIndex) {
+		index.Files[entry.Path] = entry
+	})
+}
+
+// MarkFallbackFileSynced records that the fallback file's contents were confirmed to be current
+func MarkFallbackFileSynced(path string) Error {
+	return modifyFallbackIndex(func(index *models.FallbackIndex) {
+		if entry, ok := index.Files[path]; ok {
+			entry.SyncedAt = time.Now().UTC()
+			index.Files[path] = entry
+		}
+	})
+}
+
+// RemoveFromFallbackIndex removes the entries for the specified fallback files
+func RemoveFromFallbackIndex(paths ...string) Error {
+	return modifyFallbackIndex(func(index *models.FallbackIndex) {
+		for _, path := range paths {
+			delete(index.Files, path)
+		}
+	})
+}
+
+// isFallbackFileName whether the file name matches that of a fallback file created in the default directory
+func isFallbackFileName(name string) bool {
+	return strings.HasSuffix(name, ".json") && (strings.HasPrefix(name, ".secrets-") || strings.HasPrefix(name, ".run-"))
+}
+
+// isInFallbackDir whether the path is within the fallback directory
+func isInFallbackDir(path string) bool {
+	dir := configuration.UserFallbackDir
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fallbackFileID the hash portion of a fallback file's name
+func fallbackFileID(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(name, ".secrets-"), ".run-"), ".json")
+}
+
+// ListFallbackFiles lists the fallback files in the index along with any unindexed fallback files in the default directory
+func ListFallbackFiles() ([]models.FallbackFileInfo, Error) {
+	index, Err := ReadFallbackIndex()
+	if !Err.IsNil() {
+		return nil, Err
+	}
+
+	var files []models.FallbackFileInfo
+	seen := map[string]bool{}
+	for path, entry := range index.Files {
+		stat, err := os.Stat(path)
+		if err != nil {
+			// the file has been removed since it was indexed
+			utils.LogDebug(fmt.Sprintf("Skipping indexed fallback file %s", path))
+			utils.LogDebugError(err)
+			continue
+		}
+
+		seen[path] = true
+		files = append(files, models.FallbackFileInfo{
+			FallbackIndexEntry: entry,
+			ID:                 fallbackFileID(filepath.Base(path)),
+			Name:               filepath.Base(path),
+			Size:               stat.Size(),
+			ModifiedAt:         stat.ModTime().UTC(),
+			Indexed:            true,
+			External:           !isInFallbackDir(path),
+		})
+	}
+
+	entries, err := os.ReadDir(configuration.UserFallbackDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, Error{Err: err, Message: "Unable to read fallback directory"}
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !isFallbackFileName(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(configuration.UserFallbackDir, entry.Name())
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+		if seen[path] {
+			continue
+		}
+
+		stat, err := entry.Info()
+		if err != nil {
+			utils.LogDebugError(err)
+			continue
+		}
+		files = append(files, models.FallbackFileInfo{
+			FallbackIndexEntry: models.FallbackIndexEntry{Path: path},
+			ID:                 fallbackFileID(entry.Name()),
+			Name:               entry.Name(),
+			Size:               stat.Size(),
+			ModifiedAt:         stat.ModTime().UTC(),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Project != files[j].Project {
+			return files[i].Project < files[j].Project
+		}
+		if files[i].Config != files[j].Config {
+			return files[i].Config < files[j].Config
+		}
+		return files[i].Path < files[j].Path
+	})
+
+	return files, Error{}
+}
+
+// FindFallbackFile finds the fallback file matching the specified path, file name, or hash prefix
+func FindFallbackFile(files []models.FallbackFileInfo, query string) (models.FallbackFileInfo, Error) {
+	absQuery := query
+	if absPath, err := filepath.Abs(query); err == nil {
+		absQuery = absPath
+	}
+
+	var matches []models.FallbackFileInfo
+	for _, file := range files {
+		if file.Path == absQuery || file.Name == query {
+			return file, Error{}
+		}
+		if strings.HasPrefix(file.ID, query) {
+			matches = append(matches, file)
+		}
+	}
+
+	if len(matches) == 1 {
+		return matches[0], Error{}
+	}
+	if len(matches) > 1 {
+		return models.FallbackFileInfo{}, Error{Err: fmt.Errorf("%d fallback files match '%s'", len(matches), query), Message: "Please specify a longer prefix"}
+	}
+	return models.FallbackFileInfo{}, Error{Err: fmt.Errorf("Unable to find fallback file '%s'", query)}
+}
+
+// InspectFallbackFile populates the file's encryption details without decrypting it
+func InspectFallbackFile(file models.FallbackFileInfo) (models.FallbackFileInfo, Error) {
+	bytes, err := os.ReadFile(file.Path) // #nosec G304
+	if err != nil {
+		return file, Error{Err: err, Message: "Unable to read fallback file"}
+	}
+
+	encryptedFile, err := models.ParseEncryptedFile(string(bytes))
+	if err != nil {
+		return file, Error{Err: err, Message: "Unable to parse fallback file"}
+	}
+
+	file.FileVersion = encryptedFile.Version
+	file.Encoding = encryptedFile.Encoding
+	file.KDF = encryptedFile.KDF
+	if encryptedFile.KDF == models.Argon2idKDF {
+		file.KDFParams = encryptedFile.Argon2id.String()
+	} else {
+		file.KDFRounds = encryptedFile.NumRounds
+	}
+
+	if file.MetadataPath != "" {
+		if metadata, Err := MetadataFile(file.MetadataPath); Err.IsNil() && metadata.Hash == crypto.Hash(string(bytes)) {
+			file.ETag = metadata.ETag
+		}
+	}
+
+	return file, Error{}
+}
+
+// VerifyFallbackFile attempts to decrypt the fallback file with the specified passphrase
+func VerifyFallbackFile(file models.FallbackFileInfo, passphrase string) Error {
+	bytes, err := os.ReadFile(file.Path) // #nosec G304
+	if err != nil {
+		return Error{Err: err, Message: "Unable to read fallback file"}
+	}
+
+	decrypted, err := crypto.Decrypt(passphrase, bytes)
+	if err != nil {
+		return Error{Err: err, Message: "Unable to decrypt fallback file"}
+	}
+	fileVersion, _ := models.FileVersion(string(bytes))
+	secrets, _ := UnwrapFallbackPayload([]byte(decrypted), fileVersion)
+	if !json.Valid(secrets) && file.Format == models.JSON.String() {
+		return Error{Err: errors.New("Decrypted contents are not valid JSON"), Message: "Fallback file is corrupt"}
+	}
+
+	return Error{}
+}
+
+// RekeyFallbackFile re-encrypts the fallback file in place using the specified key derivation function
+func RekeyFallbackFile(file models.FallbackFileInfo, passphrase string, kdf string) Error {
+	unlock := lockFallbackFile(file.Path, true)
+	defer unlock()
+
+	bytes, err := os.ReadFile(file.Path) // #nosec G304
+	if err != nil {
+		return Error{Err: err, Message: "Unable to read fallback file"}
+	}
+
+	decrypted, err := crypto.Decrypt(passphrase, bytes)
+	if err != nil {
+		return Error{Err: err, Message: "Unable to decrypt fallback file"}
+	}
+
+	// legacy files are upgraded to the payload envelope, preserving their unknown write time
+	fileVersion, _ := models.FileVersion(string(bytes))
+	secrets, writtenAt := UnwrapFallbackPayload([]byte(decrypted), fileVersion)
+	payload, err := wrapFallbackPayload(secrets, writtenAt)
+	if err != nil {
+		return Error{Err: err, Message: "Unable to encode fallback file"}
+	}
+
+	encrypted, err := crypto.EncryptPayloadWithKDF(passphrase, payload, models.Base64EncodingPrefix, kdf)
+	if err != nil {
+		return Error{Err: err, Message: "Unable to encrypt fallback file"}
+	}
+
+	utils.LogDebug(fmt.Sprintf("Writing to fallback file %s", file.Path))
+	if err := utils.WriteFile(file.Path, []byte(encrypted), utils.RestrictedFilePerms()); err != nil {
+		return Error{Err: err, Message: "Unable to write fallback file"}
+	}
+
+	// the metadata file references the fallback file by hash, so update it to keep the cache valid
+	if file.MetadataPath != "" {
+		if metadata, Err := MetadataFile(file.MetadataPath); Err.IsNil() && metadata.Hash == crypto.Hash(string(bytes)) {
+			if Err := WriteMetadataFile(file.MetadataPath, metadata.ETag, crypto.Hash(encrypted), metadata.SyncedAt); !Err.IsNil() {
+				utils.LogDebugError(Err.Unwrap())
+				utils.LogDebug(Err.Message)
+			}
+		}
+	}
+
+	return Error{}
+}
+
+// DeleteFallbackFile deletes the fallback file and its metadata file, and removes it from the index
+func DeleteFallbackFile(file models.FallbackFileInfo) Error {
+	unlock := lockFallbackFile(file.Path, true)
+	defer unlock()
+
+	utils.LogDebug(fmt.Sprintf("Deleting fallback file %s", file.Path))
+	if err := os.Remove(file.Path); err != nil && !os.IsNotExist(err) {
+		return Error{Err: err, Message: fmt.Sprintf("Unable to delete fallback file %s", file.Path)}
+	}
+
+	if file.MetadataPath != "" {
+		utils.LogDebug(fmt.Sprintf("Deleting metadata file %s", file.MetadataPath))
+		if err := os.Remove(file.MetadataPath); err != nil && !os.IsNotExist(err) {
+			utils.LogDebugError(err)
+		}
+	}
+
+	if file.Indexed {
+		return RemoveFromFallbackIndex(file.Path)
+	}
+	return Error{}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+const fallbackKeyringID = "fallback-key"
+const fallbackKeyFileName = ".fallback-key"
+const fallbackKeyBytes = 32
+
+// the fallback key is cached after it's first loaded so that it's only read from the keyring once
+var fallbackKey string
+
+// FallbackKeyringEnabled whether fallback files should be encrypted with this machine's fallback key
+func FallbackKeyringEnabled(config models.ScopedOptions) bool {
+	return utils.GetBool(config.FallbackKeyring.Value, false)
+}
+
+// FallbackKeyPath the path of the key file used when the system keyring is unavailable
+func FallbackKeyPath() string {
+	return filepath.Join(configuration.UserConfigDir, fallbackKeyFileName)
+}
+
+// FallbackKey the random key used to encrypt fallback files on this machine, generating it if necessary.
+// The key is stored in the system keyring, or in a key file readable only by the current user if the keyring is unavailable.
+// A new key is only generated when neither the keyring nor the key file contains one.
+func FallbackKey() (string, Error) {
+	if fallbackKey != "" {
+		return fallbackKey, Error{}
+	}
+
+	key, exists, err := readFallbackKey()
+	if !err.IsNil() {
+		return "", err
+	}
+	if exists {
+		fallbackKey = key
+		return fallbackKey, Error{}
+	}
+
+	// concurrent processes may both find that there's no key, so the key is generated under a lock. otherwise each
+	// could save a different key, leaving fallback files encrypted with whichever key was overwritten undecryptable
+	lock, lockErr := utils.LockFile(fallbackKeyLockPath(), true)
+	if lockErr != nil {
+		return "", Error{Err: lockErr, Message: "Unable to lock fallback key"}
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			utils.LogDebugError(err)
+		}
+	}()
+
+	// another process may have generated the key while we waited for the lock
+	key, exists, err = readFallbackKey()
+	if !err.IsNil() {
+		return "", err
+	}
+	if exists {
+		fallbackKey = key
+		return fallbackKey, Error{}
+	}
+
+	key, err = generateFallbackKey()
+	if !err.IsNil() {
+		return "", err
+	}
+	fallbackKey = key
+	return fallbackKey, Error{}
+}
+
+// fallbackKeyLockPath the path of the lock file coordinating generation of the fallback key between processes
+func fallbackKeyLockPath() string {
+	return FallbackKeyPath() + ".lock"
+}
+
+// readFallbackKey reads the fallback key from the keyring or the key file, returning whether it exists.
+// An error is returned if it can't be determined whether the key exists.
+func readFallbackKey() (string, bool, Error) {
+	key, keyringErr := configuration.GetKeyring(fallbackKeyringID)
+	if keyringErr.IsNil() {
+		utils.LogDebug("Using fallback key from system keyring")
+		return key, true, Error{}
+	}
+	utils.LogDebugError(keyringErr.Unwrap())
+
+	keyPath := FallbackKeyPath()
+	if bytes, err := os.ReadFile(keyPath); err == nil { // #nosec G304
+		key = strings.TrimSpace(string(bytes))
+		if key == "" {
+			return "", false, Error{Err: errors.New("fallback key file is empty"), Message: fmt.Sprintf("Unable to read fallback key file %s", keyPath)}
+		}
+		utils.LogDebug(fmt.Sprintf("Using fallback key file %s", keyPath))
+		return key, true, Error{}
+	} else if !os.IsNotExist(err) {
+		return "", false, Error{Err: err, Message: fmt.Sprintf("Unable to read fallback key file %s", keyPath)}
+	}
+
+	// only report the key as missing when we know there isn't an existing one. if the keyring is merely inaccessible
+	// (e.g. locked), generating a new key would make all existing fallback files undecryptable.
+	if !configuration.IsKeyringNotFound(keyringErr) && !configuration.IsKeyringUnsupported(keyringErr) {
+		return "", false, Error{Err: keyringErr.Unwrap(), Message: "Unable to retrieve fallback key from system keyring"}
+	}
+	return "", false, Error{}
+}
+
+// generateFallbackKey generates a new fallback key and saves it to the keyring, or to the key file if the keyring is unavailable
+func generateFallbackKey() (string, Error) {
+	buffer := make([]byte, fallbackKeyBytes)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", Error{Err: err, Message: "Unable to generate fallback key"}
+	}
+	key := hex.EncodeToString(buffer)
+
+	utils.LogDebug("Saving fallback key to system keyring")
+	keyringErr := configuration.SetKeyring(fallbackKeyringID, key)
+	if keyringErr.IsNil() {
+		return key, Error{}
+	}
+	utils.LogDebugError(keyringErr.Unwrap())
+	utils.LogDebug(keyringErr.Message)
+
+	keyPath := FallbackKeyPath()
+	utils.LogDebug(fmt.Sprintf("Saving fallback key to %s", keyPath))
+	if err := utils.WriteFile(keyPath, []byte(key), 0600); err != nil {
+		return "", Error{Err: err, Message: fmt.Sprintf("Unable to write fallback key file %s", keyPath)}
+	}
+	return key, Error{}
+}
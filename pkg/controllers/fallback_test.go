@@ -16,10 +16,19 @@ limitations under the License.
 package controllers
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/crypto"
 	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
 )
 
 type fallbackFileHashTestCase struct {
@@ -67,3 +76,204 @@ func TestGenerateFallbackFileHash(t *testing.T) {
 	}
 
 }
+
+func TestFallbackIndex(t *testing.T) {
+	origFallbackDir := configuration.UserFallbackDir
+	configuration.UserFallbackDir = t.TempDir()
+	defer func() { configuration.UserFallbackDir = origFallbackDir }()
+
+	const token = "dp.st.abc"
+	passphrase := DefaultFallbackPassphrase(token, "backend", "dev")
+	encrypted, err := crypto.Encrypt(passphrase, []byte(`{"A":"B"}`), "base64")
+	require.NoError(t, err)
+
+	indexedPath := filepath.Join(configuration.UserFallbackDir, ".secrets-aaaa1111.json")
+	unindexedPath := filepath.Join(configuration.UserFallbackDir, ".secrets-bbbb2222.json")
+	missingPath := filepath.Join(configuration.UserFallbackDir, ".secrets-cccc3333.json")
+	require.NoError(t, os.WriteFile(indexedPath, []byte(encrypted), 0600))
+	require.NoError(t, os.WriteFile(unindexedPath, []byte(encrypted), 0600))
+
+	syncedAt := time.Now().UTC().Add(-time.Hour)
+	for _, path := range []string{indexedPath, missingPath} {
+		Err := UpdateFallbackIndex(models.FallbackIndexEntry{Path: path, Project: "backend", Config: "dev", Format: "json", TokenHash: crypto.Hash(token), WrittenAt: syncedAt, SyncedAt: syncedAt})
+		require.True(t, Err.IsNil())
+	}
+
+	files, Err := ListFallbackFiles()
+	require.True(t, Err.IsNil())
+	require.Len(t, files, 2)
+	// files without an index entry sort first as they have no project
+	assert.Equal(t, "bbbb2222", files[0].ID)
+	assert.False(t, files[0].Indexed)
+	assert.Equal(t, "aaaa1111", files[1].ID)
+	assert.True(t, files[1].Indexed)
+	assert.Equal(t, "backend", files[1].Project)
+	assert.False(t, files[1].External)
+
+	file, Err := FindFallbackFile(files, "aaaa")
+	require.True(t, Err.IsNil())
+	assert.Equal(t, indexedPath, file.Path)
+	_, Err = FindFallbackFile(files, "dddd")
+	assert.False(t, Err.IsNil())
+
+	Err = VerifyFallbackFile(file, passphrase)
+	assert.True(t, Err.IsNil())
+	Err = VerifyFallbackFile(file, "wrong")
+	assert.False(t, Err.IsNil())
+
+	Err = MarkFallbackFileSynced(indexedPath)
+	require.True(t, Err.IsNil())
+	index, Err := ReadFallbackIndex()
+	require.True(t, Err.IsNil())
+	assert.True(t, index.Files[indexedPath].SyncedAt.After(syncedAt))
+
+	Err = DeleteFallbackFile(file)
+	require.True(t, Err.IsNil())
+	assert.NoFileExists(t, indexedPath)
+	index, Err = ReadFallbackIndex()
+	require.True(t, Err.IsNil())
+	assert.NotContains(t, index.Files, indexedPath)
+
+	// files written to a path specified with --fallback are outside the fallback directory
+	externalPath := filepath.Join(t.TempDir(), "secrets.json")
+	require.NoError(t, os.WriteFile(externalPath, []byte(encrypted), 0600))
+	Err = UpdateFallbackIndex(models.FallbackIndexEntry{Path: externalPath, Project: "backend", Config: "dev"})
+	require.True(t, Err.IsNil())
+	files, Err = ListFallbackFiles()
+	require.True(t, Err.IsNil())
+	file, Err = FindFallbackFile(files, externalPath)
+	require.True(t, Err.IsNil())
+	assert.True(t, file.External)
+}
+
+func TestRekeyFallbackFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".secrets-aaaa1111.json")
+	metadataPath := filepath.Join(dir, ".metadata-aaaa1111.json")
+
+	const passphrase = "passphrase"
+	encrypted, err := crypto.Encrypt(passphrase, []byte(`{"A":"B"}`), "base64")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(encrypted), 0600))
+	Err := WriteMetadataFile(metadataPath, "etag", crypto.Hash(encrypted), time.Time{})
+	require.True(t, Err.IsNil())
+
+	file := models.FallbackFileInfo{FallbackIndexEntry: models.FallbackIndexEntry{Path: path, MetadataPath: metadataPath, Format: "json"}}
+	Err = RekeyFallbackFile(file, "wrong", models.Argon2idKDF)
+	require.False(t, Err.IsNil())
+
+	Err = RekeyFallbackFile(file, passphrase, models.Argon2idKDF)
+	require.True(t, Err.IsNil())
+
+	file, Err = InspectFallbackFile(file)
+	require.True(t, Err.IsNil())
+	// the legacy contents are upgraded to the payload envelope, which older CLI versions can't decrypt
+	assert.Equal(t, 7, file.FileVersion)
+	assert.Equal(t, models.Argon2idKDF, file.KDF)
+	// the metadata file's hash is updated so the cache remains valid
+	assert.Equal(t, "etag", file.ETag)
+
+	Err = VerifyFallbackFile(file, passphrase)
+	assert.True(t, Err.IsNil())
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	decrypted, err := crypto.Decrypt(passphrase, contents)
+	require.NoError(t, err)
+	secrets, writtenAt := UnwrapFallbackPayload([]byte(decrypted), file.FileVersion)
+	assert.Equal(t, `{"A":"B"}`, string(secrets))
+	assert.True(t, writtenAt.IsZero())
+}
+
+func TestFallbackKey(t *testing.T) {
+	origConfigDir := configuration.UserConfigDir
+	configuration.UserConfigDir = t.TempDir()
+	defer func() {
+		configuration.UserConfigDir = origConfigDir
+		fallbackKey = ""
+	}()
+
+	// the key is stored in the keyring when available
+	keyring.MockInit()
+	fallbackKey = ""
+	key, Err := FallbackKey()
+	require.True(t, Err.IsNil())
+	assert.Len(t, key, fallbackKeyBytes*2)
+	assert.NoFileExists(t, FallbackKeyPath())
+
+	fallbackKey = ""
+	stored, Err := FallbackKey()
+	require.True(t, Err.IsNil())
+	assert.Equal(t, key, stored)
+
+	// a new key isn't generated when the keyring can't be accessed, as the existing key may be stored there
+	keyring.MockInitWithError(errors.New("keyring unavailable"))
+	fallbackKey = ""
+	_, Err = FallbackKey()
+	assert.False(t, Err.IsNil())
+	assert.NoFileExists(t, FallbackKeyPath())
+
+	// the key is stored in a key file when the keyring is unsupported
+	keyring.MockInitWithError(keyring.ErrUnsupportedPlatform)
+	fallbackKey = ""
+	fileKey, Err := FallbackKey()
+	require.True(t, Err.IsNil())
+	assert.NotEqual(t, key, fileKey)
+
+	info, err := os.Stat(FallbackKeyPath())
+	require.NoError(t, err)
+	if !utils.IsWindows() {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	fallbackKey = ""
+	stored, Err = FallbackKey()
+	require.True(t, Err.IsNil())
+	assert.Equal(t, fileKey, stored)
+
+	// an existing key file is used when the keyring can't be accessed
+	keyring.MockInitWithError(errors.New("keyring unavailable"))
+	fallbackKey = ""
+	stored, Err = FallbackKey()
+	require.True(t, Err.IsNil())
+	assert.Equal(t, fileKey, stored)
+
+	// a key generated by another process while waiting for the lock is used rather than generating a new one
+	keyring.MockInitWithError(keyring.ErrUnsupportedPlatform)
+	require.NoError(t, os.Remove(FallbackKeyPath()))
+	fallbackKey = ""
+	lock, err := utils.LockFile(fallbackKeyLockPath(), true)
+	require.NoError(t, err)
+	result := make(chan string)
+	go func() {
+		key, _ := FallbackKey()
+		result <- key
+	}()
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(FallbackKeyPath(), []byte("other-process-key"), 0600))
+	require.NoError(t, lock.Unlock())
+	assert.Equal(t, "other-process-key", <-result)
+}
+
+func TestFallbackPayload(t *testing.T) {
+	secrets := []byte(`{"A":"B"}`)
+	writtenAt := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	payload, err := wrapFallbackPayload(secrets, writtenAt)
+	require.NoError(t, err)
+
+	for _, fileVersion := range []int{6, 7} {
+		unwrapped, unwrappedAt := UnwrapFallbackPayload(payload, fileVersion)
+		assert.Equal(t, secrets, unwrapped)
+		assert.True(t, writtenAt.Equal(unwrappedAt))
+	}
+
+	// legacy files only contain the secrets, even if they resemble an envelope
+	for _, legacy := range []string{`{"A":"B"}`, "A=B\n", `{"A":"B","written_at":"2020-01-01T00:00:00Z"}`, `{"DOPPLER_FALLBACK_VERSION":"1","SECRETS":"x"}`, string(payload)} {
+		for _, fileVersion := range []int{1, 4, 5} {
+			unwrapped, unwrappedAt := UnwrapFallbackPayload([]byte(legacy), fileVersion)
+			assert.Equal(t, legacy, string(unwrapped))
+			assert.True(t, unwrappedAt.IsZero())
+		}
+	}
+}
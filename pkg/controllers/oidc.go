@@ -0,0 +1,306 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/http"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// OIDCTokenRefreshMargin how long before it expires an OIDC session's token is refreshed
+const OIDCTokenRefreshMargin = 5 * time.Minute
+
+// how long to wait before retrying a failed refresh
+const oidcTokenRefreshRetryInterval = 30 * time.Second
+
+// the minimum time between successful refreshes, so that short-lived tokens don't cause back-to-back refreshes
+const oidcTokenMinRefreshInterval = 10 * time.Second
+
+// the environment variable the OIDC token is read from in GitLab CI. GitLab exposes tokens under the names
+// configured via the job's id_tokens, so a different name can be specified via --token-env.
+const defaultGitLabTokenEnv = "DOPPLER_ID_TOKEN"
+
+// DetectOIDCProvider the CI provider the CLI is running within, or an empty string if none is detected
+func DetectOIDCProvider() string {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		return models.OIDCProviderGitHub
+	}
+	if os.Getenv("GITLAB_CI") == "true" {
+		return models.OIDCProviderGitLab
+	}
+	if os.Getenv("CIRCLECI") == "true" {
+		return models.OIDCProviderCircleCI
+	}
+	if os.Getenv("BUILDKITE") == "true" {
+		return models.OIDCProviderBuildkite
+	}
+	return ""
+}
+
+// OIDCProviderSupportsAudience whether the audience of the provider's token can be specified when requesting it.
+// Other providers set the audience via their pipeline or organization config.
+func OIDCProviderSupportsAudience(provider string) bool {
+	return provider == models.OIDCProviderGitHub || provider == models.OIDCProviderBuildkite
+}
+
+// FetchOIDCToken retrieves an OIDC token from the CI provider. The token env var overrides the environment
+// variable the token is read from, for providers that expose the token via an environment variable.
+func FetchOIDCToken(provider string, audience string, tokenEnv string) (string, Error) {
+	switch provider {
+	case models.OIDCProviderGitHub:
+		requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+		requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+		if requestURL == "" || requestToken == "" {
+			return "", Error{Err: errors.New("ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN must be set"), Message: "Unable to request OIDC token from GitHub Actions. Ensure the workflow has the 'id-token: write' permission"}
+		}
+
+		utils.LogDebug("Requesting OIDC token from GitHub Actions")
+		token, err := http.GetGitHubActionsIDToken(requestURL, requestToken, audience)
+		if !err.IsNil() {
+			return "", Error{Err: err.Unwrap(), Message: err.Message}
+		}
+		return token, Error{}
+	case models.OIDCProviderGitLab:
+		if tokenEnv == "" {
+			tokenEnv = defaultGitLabTokenEnv
+		}
+		return oidcTokenFromEnv(provider, tokenEnv)
+	case models.OIDCProviderCircleCI:
+		if tokenEnv != "" {
+			return oidcTokenFromEnv(provider, tokenEnv)
+		}
+		if os.Getenv("CIRCLE_OIDC_TOKEN_V2") != "" {
+			return oidcTokenFromEnv(provider, "CIRCLE_OIDC_TOKEN_V2")
+		}
+		return oidcTokenFromEnv(provider, "CIRCLE_OIDC_TOKEN")
+	case models.OIDCProviderBuildkite:
+		args := []string{"oidc", "request-token"}
+		if audience != "" {
+			args = append(args, "--audience", audience)
+		}
+
+		utils.LogDebug("Requesting OIDC token from Buildkite agent")
+		// #nosec G204
+		output, err := exec.Command("buildkite-agent", args...).Output()
+		if err != nil {
+			return "", Error{Err: err, Message: "Unable to request OIDC token via 'buildkite-agent oidc request-token'"}
+		}
+		token := strings.TrimSpace(string(output))
+		if token == "" {
+			return "", Error{Err: errors.New("buildkite-agent did not return a token"), Message: "Unable to request OIDC token from Buildkite"}
+		}
+		return token, Error{}
+	}
+
+	return "", Error{Err: fmt.Errorf("invalid OIDC provider %s. Valid providers are %v", provider, models.OIDCProviders)}
+}
+
+func oidcTokenFromEnv(provider string, name string) (string, Error) {
+	utils.LogDebug(fmt.Sprintf("Reading OIDC token from %s", name))
+	token := strings.TrimSpace(os.Getenv(name))
+	if token == "" {
+		return "", Error{Err: fmt.Errorf("environment variable %s is not set", name), Message: fmt.Sprintf("Unable to read OIDC token from %s", provider)}
+	}
+	return token, Error{}
+}
+
+// GetOIDCAuthToken exchanges an OIDC token for a service account identity token
+func GetOIDCAuthToken(apiHost string, verifyTLS bool, identity string, oidcToken string) (models.OIDCAuthToken, Error) {
+	response, httpErr := http.GetOIDCAuthToken(apiHost, verifyTLS, identity, oidcToken)
+	if !httpErr.IsNil() {
+		return models.OIDCAuthToken{}, Error{Err: httpErr.Unwrap(), Message: httpErr.Message}
+	}
+
+	token, ok := response["token"].(string)
+	if !ok {
+		utils.LogDebug(fmt.Sprintf("Unexpected type mismatch for auth token, expected string, got %T", response["token"]))
+		return models.OIDCAuthToken{}, Error{Err: errors.New("Unable to parse API response")}
+	}
+	expiresAt, ok := response["expires_at"].(string)
+	if !ok {
+		utils.LogDebug(fmt.Sprintf("Unexpected type mismatch for auth token expiration, expected string, got %T", response["expires_at"]))
+		return models.OIDCAuthToken{}, Error{Err: errors.New("Unable to parse API response")}
+	}
+	expiration, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Unable to parse auth token expiration %q", expiresAt))
+		return models.OIDCAuthToken{}, Error{Err: errors.New("Unable to parse API response")}
+	}
+	dashboard, ok := response["dashboard_url"].(string)
+	if !ok {
+		utils.LogDebug(fmt.Sprintf("Unexpected type mismatch for dashboard url, expected string, got %T", response["dashboard_url"]))
+		return models.OIDCAuthToken{}, Error{Err: errors.New("Unable to parse API response")}
+	}
+
+	return models.OIDCAuthToken{Token: token, ExpiresAt: expiration, DashboardURL: dashboard}, Error{}
+}
+
+// RefreshOIDCToken creates a new identity token for the session, using a new OIDC token from the session's provider
+func RefreshOIDCToken(apiHost string, verifyTLS bool, session models.OIDCSession) (models.OIDCAuthToken, Error) {
+	if !session.CanRefresh() {
+		return models.OIDCAuthToken{}, Error{Err: errors.New("the OIDC session can't be refreshed"), Message: "Run 'doppler oidc login' to authenticate again"}
+	}
+
+	oidcToken, err := FetchOIDCToken(session.Provider, session.Audience, session.TokenEnv)
+	if !err.IsNil() {
+		return models.OIDCAuthToken{}, err
+	}
+	return GetOIDCAuthToken(apiHost, verifyTLS, session.Identity, oidcToken)
+}
+
+// OIDCTokenRefresher keeps the token of an OIDC session current by refreshing it before it expires.
+// Refreshed tokens are saved to the config file.
+type OIDCTokenRefresher struct {
+	mutex       sync.RWMutex
+	token       string
+	session     models.OIDCSession
+	refreshedAt time.Time
+	scope       string
+	apiHost     string
+	verifyTLS   bool
+}
+
+// NewOIDCTokenRefresher creates a refresher for the config's token. Returns nil if the token doesn't belong to a
+// refreshable OIDC session.
+func NewOIDCTokenRefresher(config models.ScopedOptions) *OIDCTokenRefresher {
+	token := config.Token.Value
+	if !strings.HasPrefix(token, "dp.said.") || config.Token.Source != models.ConfigFileSource.String() {
+		return nil
+	}
+
+	// a token set at a glob scope has its session saved at the glob, not at the matched dir
+	scope := config.Token.Scope
+	if config.Token.Rule != "" {
+		scope = config.Token.Rule
+	}
+
+	session, exists := configuration.GetOIDCSession(scope)
+	if !exists || !session.CanRefresh() {
+		return nil
+	}
+
+	return &OIDCTokenRefresher{
+		token:     token,
+		session:   session,
+		scope:     scope,
+		apiHost:   config.APIHost.Value,
+		verifyTLS: utils.GetBool(config.VerifyTLS.Value, true),
+	}
+}
+
+// Token the current token
+func (r *OIDCTokenRefresher) Token() string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.token
+}
+
+// ExpiresAt when the current token expires
+func (r *OIDCTokenRefresher) ExpiresAt() time.Time {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.session.ExpiresAt
+}
+
+// NeedsRefresh whether the current token expires within the refresh margin
+func (r *OIDCTokenRefresher) NeedsRefresh() bool {
+	return time.Until(r.ExpiresAt()) < OIDCTokenRefreshMargin
+}
+
+// Refresh creates a new token and saves it to the config file
+func (r *OIDCTokenRefresher) Refresh() Error {
+	utils.LogDebug("Refreshing OIDC token")
+	authToken, err := RefreshOIDCToken(r.apiHost, r.verifyTLS, r.session)
+	if !err.IsNil() {
+		return err
+	}
+
+	r.mutex.Lock()
+	r.token = authToken.Token
+	r.session.ExpiresAt = authToken.ExpiresAt
+	r.refreshedAt = time.Now()
+	r.mutex.Unlock()
+
+	// the refreshed token is used regardless, so failing to save it only affects later invocations
+	if err := configuration.UpdateOIDCSessionToken(r.scope, authToken.Token, authToken.ExpiresAt); !err.IsNil() {
+		utils.LogDebugError(err.Unwrap())
+		utils.LogWarning("Unable to save refreshed OIDC token to the config file")
+	}
+	utils.LogDebug(fmt.Sprintf("Refreshed OIDC token, token expires at %s", authToken.ExpiresAt.Format(time.RFC3339)))
+	return Error{}
+}
+
+// nextRefreshWait how long to wait before refreshing the token. Tokens are refreshed once less than the refresh margin
+// or half of their lifetime remains, whichever is shorter.
+func (r *OIDCTokenRefresher) nextRefreshWait() time.Duration {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// the lifetime of a token that wasn't refreshed by this process is unknown, so its remaining lifetime is used
+	lifetime := time.Until(r.session.ExpiresAt)
+	if !r.refreshedAt.IsZero() {
+		lifetime = r.session.ExpiresAt.Sub(r.refreshedAt)
+	}
+	margin := OIDCTokenRefreshMargin
+	if lifetime/2 < margin {
+		margin = lifetime / 2
+	}
+	if margin < 0 {
+		margin = 0
+	}
+
+	wait := time.Until(r.session.ExpiresAt.Add(-margin))
+	if !r.refreshedAt.IsZero() {
+		if minWait := time.Until(r.refreshedAt.Add(oidcTokenMinRefreshInterval)); wait < minWait {
+			wait = minWait
+		}
+	}
+	return wait
+}
+
+// Start refreshes the token in the background before it expires. A failed refresh is retried until the token expires.
+func (r *OIDCTokenRefresher) Start() {
+	go func() {
+		warned := false
+		for {
+			if wait := r.nextRefreshWait(); wait > 0 {
+				time.Sleep(wait)
+			}
+
+			if err := r.Refresh(); !err.IsNil() {
+				utils.LogDebugError(err.Unwrap())
+				utils.LogDebug(fmt.Sprintf("Unable to refresh OIDC token, retrying in %v", oidcTokenRefreshRetryInterval))
+				if !warned && time.Now().After(r.ExpiresAt()) {
+					utils.LogWarning("Unable to refresh OIDC token before it expired")
+					warned = true
+				}
+				time.Sleep(oidcTokenRefreshRetryInterval)
+			} else {
+				warned = false
+			}
+		}
+	}()
+}
@@ -0,0 +1,198 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func clearCIEnv(t *testing.T) {
+	for _, name := range []string{"GITHUB_ACTIONS", "GITLAB_CI", "CIRCLECI", "BUILDKITE", "ACTIONS_ID_TOKEN_REQUEST_URL", "ACTIONS_ID_TOKEN_REQUEST_TOKEN", "DOPPLER_ID_TOKEN", "CIRCLE_OIDC_TOKEN", "CIRCLE_OIDC_TOKEN_V2"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestDetectOIDCProvider(t *testing.T) {
+	clearCIEnv(t)
+	assert.Equal(t, "", DetectOIDCProvider())
+
+	t.Setenv("BUILDKITE", "true")
+	assert.Equal(t, models.OIDCProviderBuildkite, DetectOIDCProvider())
+	t.Setenv("CIRCLECI", "true")
+	assert.Equal(t, models.OIDCProviderCircleCI, DetectOIDCProvider())
+	t.Setenv("GITLAB_CI", "true")
+	assert.Equal(t, models.OIDCProviderGitLab, DetectOIDCProvider())
+	t.Setenv("GITHUB_ACTIONS", "true")
+	assert.Equal(t, models.OIDCProviderGitHub, DetectOIDCProvider())
+}
+
+func TestFetchOIDCTokenGitHub(t *testing.T) {
+	clearCIEnv(t)
+
+	_, err := FetchOIDCToken(models.OIDCProviderGitHub, "", "")
+	assert.False(t, err.IsNil())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "bearer request-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "2.0", r.URL.Query().Get("api-version"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"count":1,"value":"jwt-for-` + r.URL.Query().Get("audience") + `"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/token?api-version=2.0")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+	token, err := FetchOIDCToken(models.OIDCProviderGitHub, "https://api.doppler.com", "")
+	require.True(t, err.IsNil(), err.Unwrap())
+	assert.Equal(t, "jwt-for-https://api.doppler.com", token)
+
+	token, err = FetchOIDCToken(models.OIDCProviderGitHub, "", "")
+	require.True(t, err.IsNil(), err.Unwrap())
+	assert.Equal(t, "jwt-for-", token)
+}
+
+func TestFetchOIDCTokenFromEnv(t *testing.T) {
+	clearCIEnv(t)
+
+	_, err := FetchOIDCToken(models.OIDCProviderGitLab, "", "")
+	assert.False(t, err.IsNil())
+
+	t.Setenv("DOPPLER_ID_TOKEN", "gitlab-jwt")
+	token, err := FetchOIDCToken(models.OIDCProviderGitLab, "", "")
+	require.True(t, err.IsNil())
+	assert.Equal(t, "gitlab-jwt", token)
+
+	t.Setenv("CUSTOM_ID_TOKEN", "custom-jwt")
+	token, err = FetchOIDCToken(models.OIDCProviderGitLab, "", "CUSTOM_ID_TOKEN")
+	require.True(t, err.IsNil())
+	assert.Equal(t, "custom-jwt", token)
+
+	t.Setenv("CIRCLE_OIDC_TOKEN", "circleci-jwt")
+	token, err = FetchOIDCToken(models.OIDCProviderCircleCI, "", "")
+	require.True(t, err.IsNil())
+	assert.Equal(t, "circleci-jwt", token)
+
+	t.Setenv("CIRCLE_OIDC_TOKEN_V2", "circleci-jwt-v2")
+	token, err = FetchOIDCToken(models.OIDCProviderCircleCI, "", "")
+	require.True(t, err.IsNil())
+	assert.Equal(t, "circleci-jwt-v2", token)
+}
+
+func TestFetchOIDCTokenBuildkite(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script to stub buildkite-agent")
+	}
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"jwt-for-$4\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "buildkite-agent"), []byte(script), 0700)) // #nosec G306
+	t.Setenv("PATH", dir)
+
+	token, err := FetchOIDCToken(models.OIDCProviderBuildkite, "https://api.doppler.com", "")
+	require.True(t, err.IsNil(), err.Unwrap())
+	assert.Equal(t, "jwt-for-https://api.doppler.com", token)
+}
+
+func TestRefreshOIDCToken(t *testing.T) {
+	clearCIEnv(t)
+
+	ciServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"value":"ci-jwt"}`))
+	}))
+	defer ciServer.Close()
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", ciServer.URL)
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/auth/oidc", r.URL.Path)
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "identity", body["identity"])
+		assert.Equal(t, "ci-jwt", body["token"])
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token":"dp.said.refreshed","expires_at":"2026-01-02T03:04:05Z","dashboard_url":"https://dashboard.doppler.com"}`))
+	}))
+	defer apiServer.Close()
+
+	_, err := RefreshOIDCToken(apiServer.URL, true, models.OIDCSession{Identity: "identity"})
+	assert.False(t, err.IsNil())
+
+	session := models.OIDCSession{Identity: "identity", Provider: models.OIDCProviderGitHub, ExpiresAt: time.Now()}
+	authToken, err := RefreshOIDCToken(apiServer.URL, true, session)
+	require.True(t, err.IsNil(), err.Unwrap())
+	assert.Equal(t, "dp.said.refreshed", authToken.Token)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), authToken.ExpiresAt)
+	assert.Equal(t, "https://dashboard.doppler.com", authToken.DashboardURL)
+}
+
+func TestNewOIDCTokenRefresherGlobScope(t *testing.T) {
+	keyring.MockInit()
+	originalConfigDir := configuration.UserConfigDir
+	configuration.SetConfigDir(t.TempDir())
+	defer configuration.SetConfigDir(originalConfigDir)
+	configuration.Setup()
+	configuration.LoadConfig()
+
+	glob := filepath.Join(t.TempDir(), "services", "*")
+	expiresAt := time.Now().Add(time.Hour)
+	configuration.Set(glob, map[string]string{models.ConfigToken.String(): "dp.said.123"})
+	configuration.SetOIDCSession(glob, models.OIDCSession{Identity: "identity", Provider: models.OIDCProviderGitHub, ExpiresAt: expiresAt})
+
+	config := configuration.Get(filepath.Join(filepath.Dir(glob), "api"))
+	require.Equal(t, "dp.said.123", config.Token.Value)
+	refresher := NewOIDCTokenRefresher(config)
+	require.NotNil(t, refresher)
+	assert.Equal(t, glob, refresher.scope)
+	assert.Equal(t, expiresAt, refresher.ExpiresAt())
+}
+
+func TestOIDCTokenRefresherNextRefreshWait(t *testing.T) {
+	now := time.Now()
+	assertWait := func(expected time.Duration, refresher *OIDCTokenRefresher) {
+		assert.InDelta(t, expected.Seconds(), refresher.nextRefreshWait().Seconds(), 1)
+	}
+
+	// long-lived tokens are refreshed within the refresh margin of expiring
+	assertWait(55*time.Minute, &OIDCTokenRefresher{session: models.OIDCSession{ExpiresAt: now.Add(time.Hour)}, refreshedAt: now})
+	assertWait(10*time.Minute, &OIDCTokenRefresher{session: models.OIDCSession{ExpiresAt: now.Add(15 * time.Minute)}})
+
+	// short-lived tokens are refreshed halfway through their lifetime
+	assertWait(2*time.Minute, &OIDCTokenRefresher{session: models.OIDCSession{ExpiresAt: now.Add(4 * time.Minute)}, refreshedAt: now})
+	assertWait(time.Minute, &OIDCTokenRefresher{session: models.OIDCSession{ExpiresAt: now.Add(2 * time.Minute)}})
+
+	// expired tokens are refreshed immediately, but not more often than the minimum interval
+	assert.LessOrEqual(t, (&OIDCTokenRefresher{session: models.OIDCSession{ExpiresAt: now.Add(-time.Minute)}}).nextRefreshWait(), time.Duration(0))
+	assertWait(oidcTokenMinRefreshInterval, &OIDCTokenRefresher{session: models.OIDCSession{ExpiresAt: now}, refreshedAt: now})
+}
@@ -16,13 +16,21 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 	"gopkg.in/yaml.v3"
 )
 
@@ -32,49 +40,291 @@ const repoConfigFileName = "doppler.yaml"
 // ymlRepoConfigFileName (doppler.yml)
 const ymlRepoConfigFileName = "doppler.yml"
 
-// RepoConfig Reads the configuration file (doppler.yaml) if exists and returns the set configuration
-func RepoConfig() (models.MultiRepoConfig, Error) {
+// repoConfigSchemaName the ID used when loading the JSON schema for the repo config file
+const repoConfigSchemaName = "urn:doppler:repo-config"
 
-	repoConfigFile := filepath.Join("./", repoConfigFileName)
-	ymlRepoConfigFile := filepath.Join("./", ymlRepoConfigFileName)
+//go:embed schema/doppler.schema.json
+var repoConfigSchema []byte
 
-	if utils.Exists(repoConfigFile) {
-		utils.LogDebug(fmt.Sprintf("Reading repo config file %s", repoConfigFile))
+// RepoConfig Reads the configuration file (doppler.yaml) if exists and returns the set configuration.
+// If no file is specified, the current directory and its parents are searched for the file.
+func RepoConfig(file string) (models.MultiRepoConfig, Error) {
+	repoConfigFile := file
+	if repoConfigFile == "" {
+		var ymlRepoConfigFile string
+		repoConfigFile, ymlRepoConfigFile = findRepoConfigFile()
 
-		yamlFile, err := ioutil.ReadFile(repoConfigFile) // #nosec G304
+		if repoConfigFile == "" && ymlRepoConfigFile != "" {
+			utils.LogWarning(fmt.Sprintf("Found %s file, please rename to %s for repo configuration", ymlRepoConfigFile, repoConfigFileName))
+			return models.MultiRepoConfig{}, Error{}
+		}
+	} else if !utils.Exists(repoConfigFile) {
+		var e Error
+		e.Err = fmt.Errorf("repo config file %s does not exist", repoConfigFile)
+		e.Message = "Unable to read doppler repo config file"
+		return models.MultiRepoConfig{}, e
+	}
+
+	if repoConfigFile == "" {
+		// If no config file exists, then this is for an interactive setup, so
+		// return a MultiRepoConfig object containing an empty ProjectConfig object
+		var repoConfig models.MultiRepoConfig
+		repoConfig.Setup = append(repoConfig.Setup, models.ProjectConfig{Path: configuration.Scope})
+		return repoConfig, Error{}
+	}
+
+	utils.LogDebug(fmt.Sprintf("Reading repo config file %s", repoConfigFile))
+
+	yamlFile, err := ioutil.ReadFile(repoConfigFile) // #nosec G304
+
+	if err != nil {
+		var e Error
+		e.Err = err
+		e.Message = "Unable to read doppler repo config file"
+		return models.MultiRepoConfig{}, e
+	}
+
+	var repoConfig models.MultiRepoConfig
 
-		if err != nil {
+	if err := yaml.Unmarshal(yamlFile, &repoConfig); err != nil {
+		// Try parsing old repoConfig format (i.e., no slice) for backwards compatibility
+		var oldRepoConfig models.RepoConfig
+		if err := yaml.Unmarshal(yamlFile, &oldRepoConfig); err != nil {
 			var e Error
 			e.Err = err
-			e.Message = "Unable to read doppler repo config file"
+			e.Message = "Unable to parse doppler repo config file"
 			return models.MultiRepoConfig{}, e
+		} else {
+			repoConfig.Setup = append(repoConfig.Setup, oldRepoConfig.Setup)
 		}
+	}
 
-		var repoConfig models.MultiRepoConfig
+	repoConfig.File, _ = filepath.Abs(repoConfigFile)
+	return repoConfig, Error{}
+}
+
+// RepoConfigPath resolves the path of a repo config entry relative to the directory containing the repo config file
+func RepoConfigPath(repoConfig models.MultiRepoConfig, path string) string {
+	if repoConfig.File != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(repoConfig.File), path)
+	}
+
+	expandedPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return expandedPath
+}
+
+// findRepoConfigFile searches the current directory and its parents for the repo config file, stopping at the
+// git root or the filesystem boundary. If a doppler.yml file is found instead, its path is returned separately.
+func findRepoConfigFile() (string, string) {
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		utils.LogDebugError(err)
+		return "", ""
+	}
 
-		if err := yaml.Unmarshal(yamlFile, &repoConfig); err != nil {
-			// Try parsing old repoConfig format (i.e., no slice) for backwards compatibility
-			var oldRepoConfig models.RepoConfig
-			if err := yaml.Unmarshal(yamlFile, &oldRepoConfig); err != nil {
-				var e Error
-				e.Err = err
-				e.Message = "Unable to parse doppler repo config file"
-				return models.MultiRepoConfig{}, e
-			} else {
-				repoConfig.Setup = append(repoConfig.Setup, oldRepoConfig.Setup)
-				return repoConfig, Error{}
-			}
+	for {
+		repoConfigFile := filepath.Join(dir, repoConfigFileName)
+		if utils.Exists(repoConfigFile) {
+			return repoConfigFile, ""
+		}
+		ymlRepoConfigFile := filepath.Join(dir, ymlRepoConfigFileName)
+		if utils.Exists(ymlRepoConfigFile) {
+			return "", ymlRepoConfigFile
 		}
 
-		return repoConfig, Error{}
-	} else if utils.Exists(ymlRepoConfigFile) {
-		utils.LogWarning(fmt.Sprintf("Found %s file, please rename to %s for repo configuration", ymlRepoConfigFile, repoConfigFileName))
-	} else {
-		// If no config file exists, then this is for an interactive setup, so
-		// return a MultiRepoConfig object containing an empty ProjectConfig object
-		var repoConfig models.MultiRepoConfig
-		repoConfig.Setup = append(repoConfig.Setup, models.ProjectConfig{Path: configuration.Scope})
-		return repoConfig, Error{}
+		if utils.Exists(filepath.Join(dir, ".git")) {
+			utils.LogDebug(fmt.Sprintf("Stopping search for repo config file at git root %s", dir))
+			return "", ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		if !utils.SameFilesystem(dir, parent) {
+			utils.LogDebug(fmt.Sprintf("Stopping search for repo config file at filesystem boundary %s", dir))
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// ValidateRepoConfig validates the repo config file (doppler.yaml), if it exists, against the repo config JSON schema.
+// If no file is specified, the current directory and its parents are searched for the file.
+func ValidateRepoConfig(file string) Error {
+	repoConfigFile := file
+	if repoConfigFile == "" {
+		repoConfigFile, _ = findRepoConfigFile()
+		if repoConfigFile == "" {
+			return Error{}
+		}
+	}
+
+	yamlFile, err := ioutil.ReadFile(repoConfigFile) // #nosec G304
+	if err != nil {
+		return Error{Err: err, Message: "Unable to read doppler repo config file"}
+	}
+
+	return validateRepoConfig(yamlFile)
+}
+
+func validateRepoConfig(yamlFile []byte) Error {
+	var contents interface{}
+	if err := yaml.Unmarshal(yamlFile, &contents); err != nil {
+		return Error{Err: err, Message: "Unable to parse doppler repo config file"}
+	}
+	// an empty file is valid
+	if contents == nil {
+		return Error{}
+	}
+
+	// convert to JSON so the values have the types expected by the validator
+	jsonContents, err := json.Marshal(contents)
+	if err != nil {
+		return Error{Err: err, Message: "Unable to parse doppler repo config file"}
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(jsonContents))
+	if err != nil {
+		return Error{Err: err, Message: "Unable to parse doppler repo config file"}
+	}
+
+	schema, err := jsonschema.UnmarshalJSON(bytes.NewReader(repoConfigSchema))
+	if err != nil {
+		return Error{Err: err, Message: "Unable to parse repo config schema"}
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(repoConfigSchemaName, schema); err != nil {
+		return Error{Err: err, Message: "Unable to load repo config schema"}
+	}
+	compiledSchema, err := compiler.Compile(repoConfigSchemaName)
+	if err != nil {
+		return Error{Err: err, Message: "Unable to load repo config schema"}
+	}
+
+	if err := compiledSchema.Validate(instance); err != nil {
+		return Error{Err: err, Message: fmt.Sprintf("Invalid repo config file (%s)", repoConfigFileName)}
+	}
+	return Error{}
+}
+
+// GitBranch the git branch checked out in the specified directory
+func GitBranch(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD") // #nosec G204 nosemgrep: semgrep_configs.prohibit-exec-command
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	branch := strings.TrimSpace(string(output))
+	// a detached HEAD isn't on any branch
+	if branch == "HEAD" {
+		return "", nil
+	}
+	return branch, nil
+}
+
+// MatchBranchConfig the config of the first branch pattern matching the branch
+func MatchBranchConfig(branches []models.BranchConfig, branch string) (string, bool) {
+	if branch == "" {
+		return "", false
+	}
+
+	for _, branchConfig := range branches {
+		if matched, err := path.Match(branchConfig.Pattern, branch); err == nil && matched {
+			return branchConfig.Config, true
+		}
+	}
+	return "", false
+}
+
+// RepoConfigForBranch returns the repo config with the config replaced by the one matching the git branch of the directory, if any
+func RepoConfigForBranch(repo models.ProjectConfig, dir string) models.ProjectConfig {
+	if len(repo.Branches) == 0 {
+		return repo
+	}
+
+	branch, err := GitBranch(dir)
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Unable to determine git branch of %s", dir))
+		utils.LogDebugError(err)
+		return repo
+	}
+
+	if config, ok := MatchBranchConfig(repo.Branches, branch); ok {
+		utils.LogDebug(fmt.Sprintf("Using config %s for git branch %s", config, branch))
+		repo.Config = config
+	}
+	return repo
+}
+
+// RepoConfigForDir returns the repo config entry with the most specific path containing the directory
+func RepoConfigForDir(repoConfig models.MultiRepoConfig, dir string) (models.ProjectConfig, bool) {
+	var match models.ProjectConfig
+	matchedPath := ""
+	found := false
+	for _, repo := range repoConfig.Setup {
+		repoPath := RepoConfigPath(repoConfig, repo.Path)
+		if dir != repoPath && !strings.HasPrefix(dir, repoPath+string(filepath.Separator)) {
+			continue
+		}
+		if !found || len(repoPath) > len(matchedPath) {
+			match = repo
+			matchedPath = repoPath
+			found = true
+		}
+	}
+	return match, found
+}
+
+// MissingRequiredSecrets the required secrets that don't exist
+func MissingRequiredSecrets(required []string, existing []string) []string {
+	var missing []string
+	for _, name := range required {
+		if !utils.Contains(existing, name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// RunHook runs the hook command in the specified directory
+func RunHook(command string, dir string) error {
+	shell := [2]string{"sh", "-c"}
+	if utils.IsWindows() {
+		shell = [2]string{"cmd", "/C"}
+	}
+
+	cmd := exec.Command(shell[0], shell[1], command) // #nosec G204 nosemgrep: semgrep_configs.prohibit-exec-command
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// RepoRunConfig the 'doppler run' defaults from the repo config file (doppler.yaml) for the directory, if any.
+// If no file is specified, the current directory and its parents are searched for the file.
+func RepoRunConfig(dir string, file string) models.RunConfig {
+	if file == "" {
+		if file, _ = findRepoConfigFile(); file == "" {
+			return models.RunConfig{}
+		}
+	}
+
+	repoConfig, err := RepoConfig(file)
+	if !err.IsNil() {
+		utils.LogDebug(err.Message)
+		utils.LogDebugError(err.Unwrap())
+		return models.RunConfig{}
+	}
+
+	repo, found := RepoConfigForDir(repoConfig, dir)
+	if !found {
+		return models.RunConfig{}
 	}
-	return models.MultiRepoConfig{}, Error{}
+	return repo.Run
 }
@@ -0,0 +1,166 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRepoConfig(t *testing.T) {
+	valid := []string{
+		"",
+		"setup:\n  project: cli\n  config: dev\n",
+		"setup:\n  - project: cli\n    config: dev\n    path: .\n  - project: example\n    config: stg\n    path: example/\n",
+		"flags:\n  analytics: false\n",
+		`setup:
+  - project: cli
+    config: dev
+    branches:
+      - pattern: main
+        config: prd
+      - pattern: release/*
+        config: stg
+    required-secrets: [DATABASE_URL, API_KEY]
+    run:
+      command: npm start
+      name-transformer: lower-snake
+      mount: secrets.json
+    hooks:
+      post-setup:
+        - npm install
+`,
+	}
+	for _, contents := range valid {
+		err := validateRepoConfig([]byte(contents))
+		assert.True(t, err.IsNil(), "expected valid repo config:\n%s\n%v", contents, err.Unwrap())
+	}
+
+	invalid := []string{
+		"setup: invalid\n",
+		"setup:\n  - project: cli\n    unknown: true\n",
+		"setup:\n  - project: cli\n    branches:\n      - pattern: main\n",
+		"setup:\n  - project: cli\n    required-secrets: DATABASE_URL\n",
+		"setup:\n  - project: cli\n    run:\n      command: [npm, start]\n",
+		"flags:\n  analytics: maybe\n",
+	}
+	for _, contents := range invalid {
+		err := validateRepoConfig([]byte(contents))
+		assert.False(t, err.IsNil(), "expected invalid repo config:\n%s", contents)
+	}
+}
+
+func TestMatchBranchConfig(t *testing.T) {
+	branches := []models.BranchConfig{
+		{Pattern: "main", Config: "prd"},
+		{Pattern: "release/*", Config: "stg"},
+		{Pattern: "*", Config: "dev"},
+	}
+
+	tests := []struct {
+		branch string
+		config string
+		ok     bool
+	}{
+		{"main", "prd", true},
+		{"release/1.0", "stg", true},
+		{"feature", "dev", true},
+		// * doesn't match across path separators
+		{"feature/login", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		config, ok := MatchBranchConfig(branches, test.branch)
+		assert.Equal(t, test.ok, ok, test.branch)
+		assert.Equal(t, test.config, config, test.branch)
+	}
+}
+
+func TestRepoConfigForDir(t *testing.T) {
+	repoConfig := models.MultiRepoConfig{Setup: []models.ProjectConfig{
+		{Project: "cli", Path: "."},
+		{Project: "example", Path: "example/"},
+	}}
+	root, _ := filepath.Abs(".")
+
+	repo, found := RepoConfigForDir(repoConfig, root)
+	assert.True(t, found)
+	assert.Equal(t, "cli", repo.Project)
+
+	repo, found = RepoConfigForDir(repoConfig, filepath.Join(root, "example", "nested"))
+	assert.True(t, found)
+	assert.Equal(t, "example", repo.Project)
+
+	repo, found = RepoConfigForDir(repoConfig, filepath.Join(root, "examples"))
+	assert.True(t, found)
+	assert.Equal(t, "cli", repo.Project)
+
+	_, found = RepoConfigForDir(repoConfig, filepath.Dir(root))
+	assert.False(t, found)
+}
+
+func TestMissingRequiredSecrets(t *testing.T) {
+	assert.Equal(t, []string{"B"}, MissingRequiredSecrets([]string{"A", "B"}, []string{"A", "C"}))
+	assert.Nil(t, MissingRequiredSecrets([]string{"A"}, []string{"A"}))
+}
+
+func TestRepoConfigDiscovery(t *testing.T) {
+	// resolve symlinks so paths match the working directory (e.g. /tmp on macOS)
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	repo := filepath.Join(root, "repo")
+	subdir := filepath.Join(repo, "services", "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0700))
+	require.NoError(t, os.MkdirAll(subdir, 0700))
+	// files outside the git root are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(root, "doppler.yaml"), []byte("setup:\n  project: outside\n"), 0600))
+
+	t.Chdir(subdir)
+	repoConfig, Err := RepoConfig("")
+	require.True(t, Err.IsNil())
+	assert.Equal(t, "", repoConfig.File)
+	require.Len(t, repoConfig.Setup, 1)
+	assert.Equal(t, "", repoConfig.Setup[0].Project)
+
+	contents := "setup:\n  - project: backend\n    path: services/api\n  - project: frontend\n    path: web\n"
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "doppler.yaml"), []byte(contents), 0600))
+
+	repoConfig, Err = RepoConfig("")
+	require.True(t, Err.IsNil())
+	assert.Equal(t, filepath.Join(repo, "doppler.yaml"), repoConfig.File)
+	require.Len(t, repoConfig.Setup, 2)
+	assert.Equal(t, subdir, RepoConfigPath(repoConfig, repoConfig.Setup[0].Path))
+	assert.Equal(t, filepath.Join(repo, "web"), RepoConfigPath(repoConfig, repoConfig.Setup[1].Path))
+
+	match, found := RepoConfigForDir(repoConfig, subdir)
+	assert.True(t, found)
+	assert.Equal(t, "backend", match.Project)
+
+	// an explicit file is used as is
+	repoConfig, Err = RepoConfig(filepath.Join(root, "doppler.yaml"))
+	require.True(t, Err.IsNil())
+	require.Len(t, repoConfig.Setup, 1)
+	assert.Equal(t, "outside", repoConfig.Setup[0].Project)
+	assert.Equal(t, root, RepoConfigPath(repoConfig, repoConfig.Setup[0].Path))
+
+	_, Err = RepoConfig(filepath.Join(root, "missing.yaml"))
+	assert.False(t, Err.IsNil())
+}
@@ -33,8 +33,8 @@ import (
 	"github.com/DopplerHQ/cli/pkg/crypto"
 	"github.com/DopplerHQ/cli/pkg/http"
 	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/telemetry"
 	"github.com/DopplerHQ/cli/pkg/utils"
-	"github.com/spf13/cobra"
 	"gopkg.in/gookit/color.v1"
 )
 
@@ -74,6 +74,13 @@ type FallbackOptions struct {
 	ExclusiveFlag      string
 	ExitOnWriteFailure bool
 	Passphrase         string
+	KDF                string
+	// the max age of the fallback file when reading from it. 0 disables the check
+	MaxAge      time.Duration
+	StaleAction string
+	// the token the fallback file's default passphrase is derived from, when it differs from the token used to
+	// fetch secrets (e.g. after refreshing an OIDC token). defaults to the fetching token
+	Token string
 }
 
 type MountOptions struct {
@@ -410,21 +417,23 @@ func PrepareSecrets(dopplerSecrets map[string]string, secretsBytes []byte, origi
 }
 
 // FetchSecrets from Doppler and handle fallback file.
-// It returns a tuple of the raw response bytes and a boolean of whether the result was from a cache/fallback file.
+// It returns the raw response bytes, whether the result was from a cache/fallback file, and whether the fallback
+// file was used because the Doppler API was unavailable.
 // The caller is responsible for parsing the bytes if needed (e.g., JSON to map for env injection).
-func FetchSecrets(localConfig models.ScopedOptions, enableCache bool, fallbackOpts FallbackOptions, metadataPath string, nameTransformer *models.SecretsNameTransformer, dynamicSecretsTTL time.Duration, format models.SecretsFormat, secretNames []string) ([]byte, bool) {
+func FetchSecrets(localConfig models.ScopedOptions, enableCache bool, fallbackOpts FallbackOptions, metadataPath string, nameTransformer *models.SecretsNameTransformer, dynamicSecretsTTL time.Duration, format models.SecretsFormat, secretNames []string) ([]byte, bool, bool) {
 	if fallbackOpts.Exclusive {
 		if !fallbackOpts.Enable {
-			utils.HandleError(errors.New("Conflict: unable to specify --no-fallback with " + fallbackOpts.ExclusiveFlag))
+			utils.ErrExit(errors.New("Conflict: unable to specify --no-fallback with "+fallbackOpts.ExclusiveFlag), utils.ExitCodeValidation)
 		}
-		return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, fallbackOpts.Passphrase, false), true
+		return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, metadataPath, fallbackOpts.Passphrase, fallbackOpts.MaxAge, fallbackOpts.StaleAction, false, utils.ExitCodeError), true, false
 	}
 
 	// this scenario likely isn't possible, but just to be safe, disable using cache when there's no metadata file
 	enableCache = enableCache && metadataPath != ""
 	etag := ""
+	cacheHash := ""
 	if enableCache {
-		etag = getCacheFileETag(metadataPath, fallbackOpts.Path)
+		etag, cacheHash = getCacheFileETag(metadataPath, fallbackOpts.Path)
 	}
 
 	statusCode, respHeaders, response, httpErr := http.DownloadSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, format, nameTransformer, etag, dynamicSecretsTTL, secretNames)
@@ -437,85 +446,177 @@ func FetchSecrets(localConfig models.ScopedOptions, enableCache bool, fallbackOp
 			_ = os.Remove(fallbackOpts.LegacyPath)
 			utils.LogDebug(fmt.Sprintf("Received %v. Deleting (if exists) %v", statusCode, metadataPath))
 			_ = os.Remove(metadataPath)
+			if err := RemoveFromFallbackIndex(fallbackOpts.Path, fallbackOpts.LegacyPath); !err.IsNil() {
+				utils.LogDebugError(err.Unwrap())
+				utils.LogDebug(err.Message)
+			}
 		}
 
 		if fallbackOpts.Enable && canUseFallback {
 			utils.Log("Unable to fetch secrets from the Doppler API")
 			utils.LogError(httpErr.Unwrap())
-			return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, fallbackOpts.Passphrase, false), true
+			// if the fallback file can't be used, exit with the code describing why the API request failed
+			return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, metadataPath, fallbackOpts.Passphrase, fallbackOpts.MaxAge, fallbackOpts.StaleAction, false, utils.ExitCodeForError(httpErr.Unwrap())), true, true
 		}
 		utils.HandleError(httpErr.Unwrap(), httpErr.Message)
 	}
 
+	if enableCache {
+		if statusCode == 304 {
+			telemetry.AddCounter("doppler.cache.hits", 1, nil)
+		} else {
+			telemetry.AddCounter("doppler.cache.misses", 1, nil)
+		}
+	}
+
+	writeFallbackFile := fallbackOpts.Enable && !fallbackOpts.Readonly && nameTransformer == nil
 	if enableCache && statusCode == 304 {
 		utils.LogDebug("Using cached secrets from fallback file")
-		cache, err := SecretsCacheFileBytes(fallbackOpts.Path, fallbackOpts.Passphrase)
-		if !err.IsNil() {
-			utils.LogDebugError(err.Unwrap())
-			utils.LogDebug(err.Message)
+		cache, err := SecretsCacheFileBytes(fallbackOpts.Path, fallbackOpts.Passphrase, cacheHash)
+		if err.IsNil() {
+			// the API confirmed the secrets are current, which resets the fallback file's age
+			if writeFallbackFile {
+				if err := markMetadataFileSynced(metadataPath, fallbackOpts.Path, cacheHash); !err.IsNil() {
+					utils.LogDebugError(err.Unwrap())
+					utils.LogDebug(err.Message)
+				}
+			}
+			if err := MarkFallbackFileSynced(fallbackOpts.Path); !err.IsNil() {
+				utils.LogDebugError(err.Unwrap())
+				utils.LogDebug(err.Message)
+			}
 
-			// we have to exit here as we don't have any secrets
-			utils.HandleError(err.Unwrap(), err.Message)
+			return cache, true, false
 		}
 
-		return cache, true
+		utils.LogDebugError(err.Unwrap())
+		utils.LogDebug(err.Message)
+
+		// the cache file may have been encrypted with a different passphrase (e.g. before enabling the fallback keyring),
+		// so fetch the secrets again without the ETag
+		utils.LogDebug("Fetching secrets without using cache")
+		statusCode, respHeaders, response, httpErr = http.DownloadSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, format, nameTransformer, "", dynamicSecretsTTL, secretNames)
+		if !httpErr.IsNil() {
+			// we have to exit here as we don't have any secrets
+			utils.HandleError(httpErr.Unwrap(), httpErr.Message)
+		}
 	}
 
-	writeFallbackFile := fallbackOpts.Enable && !fallbackOpts.Readonly && nameTransformer == nil
 	if writeFallbackFile {
-		utils.LogDebug("Encrypting secrets")
-		encryptedResponse, err := crypto.Encrypt(fallbackOpts.Passphrase, response, "base64")
-		if err != nil {
-			utils.HandleError(err, "Unable to encrypt your secrets. No fallback file has been written.")
-		}
+		writeFallbackSecrets(localConfig, fallbackOpts, metadataPath, enableCache, format, response, respHeaders.Get("etag"))
+	}
 
-		utils.LogDebug(fmt.Sprintf("Writing to fallback file %s", fallbackOpts.Path))
-		if err := utils.WriteFile(fallbackOpts.Path, []byte(encryptedResponse), utils.RestrictedFilePerms()); err != nil {
-			utils.Log("Unable to write to fallback file")
-			if fallbackOpts.ExitOnWriteFailure {
-				utils.HandleError(err, "", strings.Join(WriteFailureMessage(), "\n"))
-			} else {
-				utils.LogDebugError(err)
-			}
+	return response, false, false
+}
+
+// writeFallbackSecrets encrypts the secrets to the fallback file and updates its metadata file and index entry
+func writeFallbackSecrets(localConfig models.ScopedOptions, fallbackOpts FallbackOptions, metadataPath string, enableCache bool, format models.SecretsFormat, secrets []byte, etag string) {
+	span := telemetry.StartSpan("fallback.write", nil)
+	defer span.End()
+
+	writtenAt := time.Now().UTC()
+	payload, err := wrapFallbackPayload(secrets, writtenAt)
+	if err != nil {
+		utils.HandleError(err, "Unable to encode your secrets. No fallback file has been written.")
+	}
+
+	utils.LogDebug("Encrypting secrets")
+	kdf := fallbackOpts.KDF
+	if kdf == "" {
+		kdf = models.Pbkdf2KDF
+	}
+	encryptedResponse, err := crypto.EncryptPayloadWithKDF(fallbackOpts.Passphrase, payload, "base64", kdf)
+	if err != nil {
+		utils.HandleError(err, "Unable to encrypt your secrets. No fallback file has been written.")
+	}
+
+	// the fallback file and metadata file must be updated together so that the metadata's hash matches the fallback file
+	unlock := lockFallbackFile(fallbackOpts.Path, true)
+
+	utils.LogDebug(fmt.Sprintf("Writing to fallback file %s", fallbackOpts.Path))
+	if err := utils.WriteFile(fallbackOpts.Path, []byte(encryptedResponse), utils.RestrictedFilePerms()); err != nil {
+		utils.Log("Unable to write to fallback file")
+		span.RecordError(err)
+		if fallbackOpts.ExitOnWriteFailure {
+			utils.HandleError(err, "", strings.Join(WriteFailureMessage(), "\n"))
+		} else {
+			utils.LogDebugError(err)
 		}
+	}
 
-		if enableCache {
-			if etag := respHeaders.Get("etag"); etag != "" {
-				hash := crypto.Hash(encryptedResponse)
+	if enableCache {
+		if etag != "" {
+			hash := crypto.Hash(encryptedResponse)
 
-				if err := WriteMetadataFile(metadataPath, etag, hash); !err.IsNil() {
-					utils.LogDebugError(err.Unwrap())
-					utils.LogDebug(err.Message)
-				}
-			} else {
-				utils.LogDebug("API response does not contain ETag")
+			if err := WriteMetadataFile(metadataPath, etag, hash, writtenAt); !err.IsNil() {
+				utils.LogDebugError(err.Unwrap())
+				utils.LogDebug(err.Message)
 			}
+		} else {
+			utils.LogDebug("API response does not contain ETag")
 		}
 	}
+	unlock()
 
-	return response, false
+	usesFallbackKey := false
+	if FallbackKeyringEnabled(localConfig) {
+		key, err := FallbackKey()
+		usesFallbackKey = err.IsNil() && fallbackOpts.Passphrase == key
+	}
+
+	fallbackToken := localConfig.Token.Value
+	if fallbackOpts.Token != "" {
+		fallbackToken = fallbackOpts.Token
+	}
+	entry := models.FallbackIndexEntry{
+		Path:             fallbackOpts.Path,
+		Project:          localConfig.EnclaveProject.Value,
+		Config:           localConfig.EnclaveConfig.Value,
+		Format:           format.String(),
+		TokenHash:        crypto.Hash(fallbackToken),
+		CustomPassphrase: !usesFallbackKey && fallbackOpts.Passphrase != DefaultFallbackPassphrase(fallbackToken, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value),
+		FallbackKey:      usesFallbackKey,
+		WrittenAt:        writtenAt,
+		SyncedAt:         writtenAt,
+	}
+	if enableCache {
+		entry.MetadataPath = metadataPath
+	}
+	if err := UpdateFallbackIndex(entry); !err.IsNil() {
+		utils.LogDebugError(err.Unwrap())
+		utils.LogDebug(err.Message)
+	}
 }
 
-func Run(cmd *cobra.Command, args []string, env []string, forwardSignals bool) (*exec.Cmd, error) {
+// Run runs the command string if specified, otherwise the args
+func Run(command string, args []string, env []string, forwardSignals bool) (*exec.Cmd, error) {
 	var c *exec.Cmd
 	var err error
 
-	if cmd.Flags().Changed("command") {
-		command := cmd.Flag("command").Value.String()
+	span := telemetry.StartSpan("process.launch", nil)
+	defer span.End()
+
+	if command != "" {
 		c, err = utils.RunCommandString(command, env, os.Stdin, os.Stdout, os.Stderr, forwardSignals)
 	} else {
 		c, err = utils.RunCommand(args, env, os.Stdin, os.Stdout, os.Stderr, forwardSignals)
 	}
+	span.RecordError(err)
 
 	return c, err
 }
 
-func readFallbackFile(path string, legacyPath string, passphrase string, silent bool) []byte {
+// readFallbackFile reads the secrets from the fallback file. If the fallback file doesn't exist or is stale, the CLI exits with the specified code.
+func readFallbackFile(path string, legacyPath string, metadataPath string, passphrase string, maxAge time.Duration, staleAction string, silent bool, exitCode int) []byte {
 	// avoid re-logging if re-running for legacy file
 	// TODO remove this when removing legacy path support
 	if !silent {
 		utils.Log("Reading secrets from fallback file")
+		telemetry.AddCounter("doppler.fallback.reads", 1, nil)
 	}
+
+	span := telemetry.StartSpan("fallback.read", nil)
+	defer span.End()
 	utils.LogDebug(fmt.Sprintf("Using fallback file %s", path))
 
 	if _, err := os.Stat(path); err != nil {
@@ -523,10 +624,10 @@ func readFallbackFile(path string, legacyPath string, passphrase string, silent
 			// attempt to read from the legacy path, in case the fallback file was created with an older version of the CLI
 			// TODO remove this when releasing CLI v4 (DPLR-435)
 			if legacyPath != "" {
-				return readFallbackFile(legacyPath, "", passphrase, true)
+				return readFallbackFile(legacyPath, "", "", passphrase, maxAge, staleAction, true, exitCode)
 			}
 
-			utils.HandleError(errors.New("The fallback file does not exist"))
+			utils.ErrExit(errors.New("The fallback file does not exist"), exitCode)
 		}
 
 		utils.HandleError(err, "Unable to read fallback file")
@@ -559,7 +660,17 @@ func readFallbackFile(path string, legacyPath string, passphrase string, silent
 		utils.HandleError(err, "Unable to decrypt fallback file", strings.Join(msg, "\n"))
 	}
 
-	return []byte(decryptedSecrets)
+	fileVersion, _ := models.FileVersion(string(response))
+	secrets, writtenAt := UnwrapFallbackPayload([]byte(decryptedSecrets), fileVersion)
+	if maxAge > 0 {
+		// the API may have confirmed the secrets are current since they were written
+		if syncedAt := fallbackFileSyncedAt(metadataPath, response); syncedAt.After(writtenAt) {
+			writtenAt = syncedAt
+		}
+	}
+	checkFallbackFileAge(writtenAt, maxAge, staleAction, exitCode)
+
+	return secrets
 }
 
 func WriteFailureMessage() []string {
@@ -594,16 +705,20 @@ func ParseSecrets(response []byte) (map[string]string, error) {
 	return secrets, err
 }
 
-func getCacheFileETag(metadataPath string, cachePath string) string {
+// getCacheFileETag returns the ETag of the cache file, along with the hash of the cache file it was verified against
+func getCacheFileETag(metadataPath string, cachePath string) (string, string) {
+	unlock := lockFallbackFile(cachePath, false)
+	defer unlock()
+
 	metadata, Err := MetadataFile(metadataPath)
 	if !Err.IsNil() {
 		utils.LogDebugError(Err.Unwrap())
 		utils.LogDebug(Err.Message)
-		return ""
+		return "", ""
 	}
 
 	if metadata.Hash == "" {
-		return metadata.ETag
+		return metadata.ETag, ""
 	}
 
 	// verify hash
@@ -613,11 +728,11 @@ func getCacheFileETag(metadataPath string, cachePath string) string {
 		hash := crypto.Hash(cacheFileContents)
 
 		if hash == metadata.Hash {
-			return metadata.ETag
+			return metadata.ETag, hash
 		}
 
 		utils.LogDebug("Fallback file failed hash check, ignoring cached secrets")
 	}
 
-	return ""
+	return "", ""
 }
@@ -17,14 +17,23 @@ limitations under the License.
 package controllers
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/crypto"
+	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type dangerousSecretNameTestCase struct {
@@ -173,3 +182,136 @@ func TestPrepareSecretsMountsRawBytes(t *testing.T) {
 	assert.NoError(t, readErr)
 	assert.Equal(t, secretsBytes, content, "mounted file should contain raw bytes from backend")
 }
+
+func TestFetchSecretsConcurrent(t *testing.T) {
+	origFallbackDir := configuration.UserFallbackDir
+	configuration.UserFallbackDir = t.TempDir()
+	defer func() { configuration.UserFallbackDir = origFallbackDir }()
+
+	// the secrets change every few requests, so concurrent fetches receive a mix of new secrets and 304s
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := atomic.AddInt64(&requests, 1) / 2
+		etag := fmt.Sprintf(`"v%d"`, version)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"VERSION":"%d"}`, version)))
+	}))
+	defer server.Close()
+
+	localConfig := models.ScopedOptions{
+		Token:          models.ScopedOption{Value: "dp.pt.123"},
+		APIHost:        models.ScopedOption{Value: server.URL},
+		VerifyTLS:      models.ScopedOption{Value: "true"},
+		EnclaveProject: models.ScopedOption{Value: "backend"},
+		EnclaveConfig:  models.ScopedOption{Value: "dev"},
+	}
+	fallbackPath := filepath.Join(configuration.UserFallbackDir, ".secrets-test.json")
+	metadataPath := filepath.Join(configuration.UserFallbackDir, ".metadata-test.json")
+	fallbackOpts := FallbackOptions{
+		Enable:             true,
+		Path:               fallbackPath,
+		ExitOnWriteFailure: true,
+		Passphrase:         "passphrase",
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				response, _, _ := FetchSecrets(localConfig, true, fallbackOpts, metadataPath, nil, 0, models.JSON, nil)
+				secrets, err := ParseSecrets(response)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, secrets["VERSION"])
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the metadata file must describe the fallback file that was last written
+	metadata, Err := MetadataFile(metadataPath)
+	require.True(t, Err.IsNil())
+	contents, err := os.ReadFile(fallbackPath)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Hash(string(contents)), metadata.Hash)
+
+	secrets, Err := SecretsCacheFileBytes(fallbackPath, fallbackOpts.Passphrase, metadata.Hash)
+	require.True(t, Err.IsNil())
+	assert.Equal(t, metadata.ETag, fmt.Sprintf(`"v%s"`, strings.TrimSuffix(strings.TrimPrefix(string(secrets), `{"VERSION":"`), `"}`)))
+}
+
+func TestFetchSecretsNotModifiedResetsFallbackAge(t *testing.T) {
+	origFallbackDir := configuration.UserFallbackDir
+	configuration.UserFallbackDir = t.TempDir()
+	defer func() { configuration.UserFallbackDir = origFallbackDir }()
+
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"A":"B"}`))
+	}))
+	defer server.Close()
+
+	localConfig := models.ScopedOptions{
+		Token:          models.ScopedOption{Value: "dp.pt.123"},
+		APIHost:        models.ScopedOption{Value: server.URL},
+		VerifyTLS:      models.ScopedOption{Value: "true"},
+		EnclaveProject: models.ScopedOption{Value: "backend"},
+		EnclaveConfig:  models.ScopedOption{Value: "dev"},
+	}
+	fallbackPath := filepath.Join(configuration.UserFallbackDir, ".secrets-test.json")
+	metadataPath := filepath.Join(configuration.UserFallbackDir, ".metadata-test.json")
+	fallbackOpts := FallbackOptions{
+		Enable:             true,
+		Path:               fallbackPath,
+		ExitOnWriteFailure: true,
+		Passphrase:         "passphrase",
+		MaxAge:             time.Hour,
+		StaleAction:        models.FallbackStaleFail,
+	}
+
+	// simulate a fallback file that was written long ago and whose secrets haven't changed since
+	payload, err := wrapFallbackPayload([]byte(`{"A":"B"}`), time.Now().UTC().Add(-2*time.Hour))
+	require.NoError(t, err)
+	encrypted, err := crypto.EncryptPayloadWithKDF(fallbackOpts.Passphrase, payload, "base64", models.Pbkdf2KDF)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(fallbackPath, []byte(encrypted), 0600))
+	Err := WriteMetadataFile(metadataPath, `"v1"`, crypto.Hash(encrypted), time.Time{})
+	require.True(t, Err.IsNil())
+
+	response, fromCache, usedFallback := FetchSecrets(localConfig, true, fallbackOpts, metadataPath, nil, 0, models.JSON, nil)
+	assert.Equal(t, `{"A":"B"}`, string(response))
+	assert.True(t, fromCache)
+	assert.False(t, usedFallback)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&requests))
+
+	// the fallback file isn't rewritten, but its sync time is recorded in the metadata file
+	contents, err := os.ReadFile(fallbackPath)
+	require.NoError(t, err)
+	assert.Equal(t, encrypted, string(contents))
+	metadata, Err := MetadataFile(metadataPath)
+	require.True(t, Err.IsNil())
+	assert.Equal(t, `"v1"`, metadata.ETag)
+	assert.Equal(t, crypto.Hash(string(contents)), metadata.Hash)
+	assert.WithinDuration(t, time.Now(), metadata.SyncedAt, time.Minute)
+
+	// reading the fallback file offline succeeds, as it's within the max age
+	fallbackOpts.Exclusive = true
+	fallbackOpts.ExclusiveFlag = "--fallback-only"
+	response, fromCache, _ = FetchSecrets(localConfig, true, fallbackOpts, metadataPath, nil, 0, models.JSON, nil)
+	assert.Equal(t, `{"A":"B"}`, string(response))
+	assert.True(t, fromCache)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&requests))
+}
@@ -257,7 +257,7 @@ func InstallUpdate(version string) {
 			}
 
 			utils.LogDebug("Executing winget in background, CLI is now exiting")
-			os.Exit(0)
+			utils.Exit(0)
 		} else {
 			utils.HandleError(fmt.Errorf("updates are not supported when installed via scoop. Please install the Doppler CLI via winget or update manually via `scoop update doppler`"))
 		}
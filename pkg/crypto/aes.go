@@ -31,11 +31,16 @@ import (
 	"time"
 
 	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/telemetry"
 	"github.com/DopplerHQ/cli/pkg/utils"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
 )
 
 var currentFileVersion = models.FileVersions[4].Version
+var currentArgon2idFileVersion = models.FileVersions[5].Version
+var currentPayloadFileVersion = models.FileVersions[6].Version
+var currentArgon2idPayloadFileVersion = models.FileVersions[7].Version
 
 func deriveKey(passphrase string, salt []byte, numRounds int) ([]byte, []byte, error) {
 	if salt == nil {
@@ -51,10 +56,69 @@ func deriveKey(passphrase string, salt []byte, numRounds int) ([]byte, []byte, e
 	return pbkdf2.Key([]byte(passphrase), salt, numRounds, 32, sha256.New), salt, nil
 }
 
+func deriveArgon2idKey(passphrase string, salt []byte, params models.Argon2idParams) ([]byte, []byte, error) {
+	if salt == nil {
+		// RFC 9106 recommends a 128-bit salt
+		salt = make([]byte, 16)
+		_, err := rand.Read(salt)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return argon2.IDKey([]byte(passphrase), salt, params.Time, params.Memory, params.Threads, 32), salt, nil
+}
+
 // Encrypt plaintext with a passphrase; uses pbkdf2 for key deriv and aes-256-gcm for encryption
 func Encrypt(passphrase string, plaintext []byte, encoding string) (string, error) {
-	key, salt, err := deriveKey(passphrase, nil, models.Pbkdf2Rounds)
+	return EncryptWithKDF(passphrase, plaintext, encoding, models.Pbkdf2KDF)
+}
+
+// EncryptWithKDF encrypts plaintext with a passphrase using the specified key derivation function and aes-256-gcm.
+// Files encrypted with pbkdf2 use file version 4, while files encrypted with argon2id use file version 5.
+func EncryptWithKDF(passphrase string, plaintext []byte, encoding string, kdf string) (string, error) {
+	return encrypt(passphrase, plaintext, encoding, kdf, false)
+}
+
+// EncryptPayloadWithKDF encrypts a versioned payload envelope (e.g. the contents of a fallback file). It's identical to
+// EncryptWithKDF, but uses file version 6 (pbkdf2) or 7 (argon2id) so that older CLI versions, which don't understand
+// the envelope, refuse to decrypt the file rather than misreading the envelope as the plaintext.
+func EncryptPayloadWithKDF(passphrase string, payload []byte, encoding string, kdf string) (string, error) {
+	return encrypt(passphrase, payload, encoding, kdf, true)
+}
+
+func encrypt(passphrase string, plaintext []byte, encoding string, kdf string, isPayload bool) (string, error) {
+	span := telemetry.StartSpan("crypto.encrypt", map[string]interface{}{"crypto.kdf": kdf})
+	defer span.End()
+
+	var key []byte
+	var salt []byte
+	var header string
+	var err error
+	before := time.Now()
+	if kdf == models.Argon2idKDF {
+		params := models.Argon2idParams{Memory: models.Argon2idMemory, Time: models.Argon2idTime, Threads: models.Argon2idThreads}
+		span.SetAttribute("crypto.kdf.params", params.String())
+		key, salt, err = deriveArgon2idKey(passphrase, nil, params)
+		version := currentArgon2idFileVersion
+		if isPayload {
+			version = currentArgon2idPayloadFileVersion
+		}
+		header = fmt.Sprintf("%d:%s:%s", version, encoding, params)
+	} else if kdf == models.Pbkdf2KDF {
+		span.SetAttribute("crypto.kdf.rounds", models.Pbkdf2Rounds)
+		key, salt, err = deriveKey(passphrase, nil, models.Pbkdf2Rounds)
+		version := currentFileVersion
+		if isPayload {
+			version = currentPayloadFileVersion
+		}
+		header = fmt.Sprintf("%d:%s:%d", version, encoding, models.Pbkdf2Rounds)
+	} else {
+		err = fmt.Errorf("Invalid key derivation function, must be one of %v", models.KDFs)
+	}
+	span.SetAttribute("crypto.kdf.duration_ms", time.Since(before))
 	if err != nil {
+		span.RecordError(err)
 		return "", err
 	}
 
@@ -93,7 +157,7 @@ func Encrypt(passphrase string, plaintext []byte, encoding string) (string, erro
 		return "", errors.New("Invalid encoding, must be one of [base64, hex]")
 	}
 
-	s := fmt.Sprintf("%d:%s:%d:%s-%s-%s", currentFileVersion, encoding, models.Pbkdf2Rounds, encodedSalt, encodedIV, encodedData)
+	s := fmt.Sprintf("%s:%s-%s-%s", header, encodedSalt, encodedIV, encodedData)
 	return s, nil
 }
 
@@ -155,26 +219,20 @@ func decodeHex(passphrase string, ciphertext string) ([]byte, []byte, []byte, er
 
 // Decrypt ciphertext with a passphrase.
 // Formats:
-// 1) `version:encoding:numRounds:text` (latest)
-// 2) `encoding:numRounds:text` (legacy)
-// 3) `encoding:text` (legacy)
-// 4) `text` (legacy)
+// 1) `version:encoding:argon2id,m=memory,t=time,p=threads:text` (latest, argon2id)
+// 2) `version:encoding:numRounds:text` (latest, pbkdf2)
+// 3) `encoding:numRounds:text` (legacy)
+// 4) `encoding:text` (legacy)
+// 5) `text` (legacy)
 func Decrypt(passphrase string, ciphertext []byte) (string, error) {
 	var salt []byte
 	var iv []byte
 	var data []byte
 
-	versionN, err := models.FileVersion(string(ciphertext))
-	if err != nil {
-		return "", err
-	}
+	span := telemetry.StartSpan("crypto.decrypt", nil)
+	defer span.End()
 
-	version, ok := models.FileVersions[versionN]
-	if !ok {
-		return "", fmt.Errorf("Invalid version number: %d", versionN)
-	}
-
-	file, err := version.Parse(string(ciphertext))
+	file, err := models.ParseEncryptedFile(string(ciphertext))
 	if err != nil {
 		return "", err
 	}
@@ -193,13 +251,26 @@ func Decrypt(passphrase string, ciphertext []byte) (string, error) {
 		}
 	}
 
+	var key []byte
 	before := time.Now()
-	key, _, err := deriveKey(passphrase, salt, file.NumRounds)
+	if file.KDF == models.Argon2idKDF {
+		key, _, err = deriveArgon2idKey(passphrase, salt, file.Argon2id)
+	} else {
+		key, _, err = deriveKey(passphrase, salt, file.NumRounds)
+	}
 	after := time.Now()
 	if err != nil {
 		return "", err
 	}
-	utils.LogDebug(fmt.Sprintf("PBKDF2 key derivation used %d rounds and took %d ms", file.NumRounds, after.Sub(before).Milliseconds()))
+	span.SetAttribute("crypto.kdf", file.KDF)
+	if file.KDF == models.Argon2idKDF {
+		utils.LogDebug(fmt.Sprintf("Argon2id key derivation used parameters %s and took %d ms", file.Argon2id, after.Sub(before).Milliseconds()))
+		span.SetAttribute("crypto.kdf.params", file.Argon2id.String())
+	} else {
+		utils.LogDebug(fmt.Sprintf("PBKDF2 key derivation used %d rounds and took %d ms", file.NumRounds, after.Sub(before).Milliseconds()))
+		span.SetAttribute("crypto.kdf.rounds", file.NumRounds)
+	}
+	span.SetAttribute("crypto.kdf.duration_ms", after.Sub(before))
 
 	b, err := aes.NewCipher(key)
 	if err != nil {
@@ -213,8 +284,68 @@ func Decrypt(passphrase string, ciphertext []byte) (string, error) {
 
 	data, err = aesgcm.Open(nil, iv, data, nil)
 	if err != nil {
+		span.RecordError(err)
 		return "", err
 	}
 
 	return string(data), nil
 }
+
+// EncryptWithKey encrypts plaintext with aes-256-gcm using the key directly, without key derivation. The key must be
+// 32 random bytes; use Encrypt for passphrases. The result has the format `iv-text`, with both parts base64 encoded.
+func EncryptWithKey(key []byte, plaintext []byte) (string, error) {
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, aesgcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	data := aesgcm.Seal(nil, iv, plaintext, nil)
+	return fmt.Sprintf("%s-%s", base64.StdEncoding.EncodeToString(iv), base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// DecryptWithKey decrypts ciphertext produced by EncryptWithKey
+func DecryptWithKey(key []byte, ciphertext string) (string, error) {
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	arr := strings.SplitN(ciphertext, "-", 2)
+	if len(arr) != 2 {
+		return "", errors.New("Invalid ciphertext")
+	}
+	iv, err := base64.StdEncoding.DecodeString(arr[0])
+	if err != nil {
+		return "", err
+	}
+	if len(iv) != aesgcm.NonceSize() {
+		return "", errors.New("Invalid ciphertext: invalid IV")
+	}
+	data, err := base64.StdEncoding.DecodeString(arr[1])
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := aesgcm.Open(nil, iv, data, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("Invalid key: must be 32 bytes")
+	}
+
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(b)
+}
@@ -16,8 +16,12 @@ limitations under the License.
 package crypto
 
 import (
+	"crypto/rand"
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/models"
 )
 
 const originalPassphrase = "secret"
@@ -104,3 +108,134 @@ func TestEncrypt(t *testing.T) {
 		t.Error("Invalid plaintext when decrypting base64 value")
 	}
 }
+
+func TestEncryptArgon2id(t *testing.T) {
+	ciphertext, err := EncryptWithKDF(originalPassphrase, []byte(originalPlaintext), "base64", models.Argon2idKDF)
+	if err != nil {
+		t.Error("Invalid ciphertext when encrypting value w/ argon2id")
+	}
+	if !strings.HasPrefix(ciphertext, "5:base64:argon2id,m=65536,t=3,p=4:") {
+		t.Errorf("Invalid header when encrypting value w/ argon2id: %s", ciphertext)
+	}
+	if version, err := models.FileVersion(ciphertext); err != nil || version != 5 {
+		t.Error("Invalid file version when encrypting value w/ argon2id")
+	}
+
+	plaintext, err := Decrypt(originalPassphrase, []byte(ciphertext))
+	if err != nil || plaintext != originalPlaintext {
+		t.Error("Invalid plaintext when decrypting argon2id value")
+	}
+
+	_, err = Decrypt("wrong", []byte(ciphertext))
+	if err == nil {
+		t.Error("Expected error when decrypting argon2id value w/ wrong passphrase")
+	}
+
+	// the parameters are authenticated by the derived key, so altering them must fail
+	tampered := strings.Replace(ciphertext, "t=3", "t=2", 1)
+	_, err = Decrypt(originalPassphrase, []byte(tampered))
+	if err == nil {
+		t.Error("Expected error when decrypting argon2id value w/ altered parameters")
+	}
+
+	hexCiphertext, err := EncryptWithKDF(originalPassphrase, []byte(originalPlaintext), "hex", models.Argon2idKDF)
+	if err != nil {
+		t.Error("Invalid ciphertext when encrypting value w/ argon2id and hex encoding")
+	}
+	plaintext, err = Decrypt(originalPassphrase, []byte(hexCiphertext))
+	if err != nil || plaintext != originalPlaintext {
+		t.Error("Invalid plaintext when decrypting argon2id hex value")
+	}
+
+	_, err = EncryptWithKDF(originalPassphrase, []byte(originalPlaintext), "base64", "scrypt")
+	if err == nil {
+		t.Error("Expected error when encrypting value w/ unsupported kdf")
+	}
+}
+
+func TestDecryptInvalidArgon2idParams(t *testing.T) {
+	ciphertextData := "qwbkFMWB7FE=-Ew968YdkAXRb6l46-eA4o9Pf9mSIaOofa8YIEP+FqJ6DwScHsYIObAw3dvKvHbe5SDTzB"
+	invalidParams := []string{
+		"argon2id",
+		"argon2i,m=65536,t=3,p=4",
+		"argon2id,m=65536,t=3",
+		"argon2id,m=abc,t=3,p=4",
+		"argon2id,m=99999999,t=3,p=4",
+		"argon2id,m=65536,t=0,p=4",
+		"argon2id,m=65536,t=3,p=0",
+	}
+
+	for _, params := range invalidParams {
+		ciphertext := fmt.Sprintf("5:base64:%s:%s", params, ciphertextData)
+		if _, err := Decrypt(originalPassphrase, []byte(ciphertext)); err == nil {
+			t.Errorf("Expected error when decrypting value w/ argon2id params %s", params)
+		}
+	}
+}
+
+func TestParseEncryptedFile(t *testing.T) {
+	ciphertext, err := EncryptWithKDF(originalPassphrase, []byte(originalPlaintext), "hex", models.Pbkdf2KDF)
+	if err != nil {
+		t.Error("Invalid ciphertext when encrypting value")
+	}
+	file, err := models.ParseEncryptedFile(ciphertext)
+	if err != nil || file.Version != 4 || file.Encoding != "hex" || file.KDF != models.Pbkdf2KDF || file.NumRounds != models.Pbkdf2Rounds {
+		t.Errorf("Invalid parameters when parsing pbkdf2 value: %+v", file)
+	}
+
+	ciphertext, err = EncryptWithKDF(originalPassphrase, []byte(originalPlaintext), "base64", models.Argon2idKDF)
+	if err != nil {
+		t.Error("Invalid ciphertext when encrypting value w/ argon2id")
+	}
+	file, err = models.ParseEncryptedFile(ciphertext)
+	if err != nil || file.Version != 5 || file.Encoding != "base64" || file.KDF != models.Argon2idKDF || file.Argon2id.Memory != models.Argon2idMemory {
+		t.Errorf("Invalid parameters when parsing argon2id value: %+v", file)
+	}
+
+	// payload envelopes use their own file versions
+	for kdf, version := range map[string]int{models.Pbkdf2KDF: 6, models.Argon2idKDF: 7} {
+		ciphertext, err = EncryptPayloadWithKDF(originalPassphrase, []byte(originalPlaintext), "base64", kdf)
+		if err != nil {
+			t.Errorf("Invalid ciphertext when encrypting payload w/ %s", kdf)
+		}
+		file, err = models.ParseEncryptedFile(ciphertext)
+		if err != nil || file.Version != version || file.KDF != kdf {
+			t.Errorf("Invalid parameters when parsing %s payload: %+v", kdf, file)
+		}
+		plaintext, err := Decrypt(originalPassphrase, []byte(ciphertext))
+		if err != nil || plaintext != originalPlaintext {
+			t.Errorf("Invalid plaintext when decrypting %s payload", kdf)
+		}
+	}
+
+	if _, err := models.ParseEncryptedFile("a:b:c:d:e"); err == nil {
+		t.Error("Expected error when parsing invalid value")
+	}
+}
+
+func TestEncryptWithKey(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := EncryptWithKey(key, []byte(originalPlaintext))
+	if err != nil {
+		t.Error("Invalid ciphertext when encrypting value w/ key")
+	}
+	plaintext, err := DecryptWithKey(key, ciphertext)
+	if err != nil || plaintext != originalPlaintext {
+		t.Error("Invalid plaintext when decrypting value w/ key")
+	}
+
+	wrongKey := make([]byte, 32)
+	if _, err := DecryptWithKey(wrongKey, ciphertext); err == nil {
+		t.Error("Expected error when decrypting value w/ wrong key")
+	}
+	if _, err := EncryptWithKey(key[:16], []byte(originalPlaintext)); err == nil {
+		t.Error("Expected error when encrypting value w/ short key")
+	}
+	if _, err := DecryptWithKey(key, "invalid"); err == nil {
+		t.Error("Expected error when decrypting invalid value")
+	}
+}
@@ -0,0 +1,887 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package devserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+func (s *Server) getMe(w http.ResponseWriter, r *http.Request, a actor) {
+	var workplace Workplace
+	_ = s.store.view(func(data *Data) error {
+		workplace = data.Workplace
+		return nil
+	})
+
+	info := map[string]interface{}{
+		"workplace":    map[string]string{"name": workplace.Name, "slug": workplace.ID},
+		"last_seen_at": now(),
+	}
+	if a.service != nil {
+		info["type"] = "service_token"
+		info["name"] = a.service.Name
+		info["slug"] = a.service.Slug
+		info["created_at"] = a.service.CreatedAt
+		info["token_preview"] = utils.RedactAuthToken(a.service.Key)
+	} else {
+		info["type"] = "personal"
+		info["name"] = a.personal.Name
+		info["slug"] = a.personal.Name
+		info["created_at"] = a.personal.CreatedAt
+		info["token_preview"] = utils.RedactAuthToken(a.personal.Key)
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) getWorkplace(w http.ResponseWriter, r *http.Request, a actor) {
+	var workplace Workplace
+	_ = s.store.view(func(data *Data) error {
+		workplace = data.Workplace
+		return nil
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"workplace": workplaceJSON(workplace)})
+}
+
+func (s *Server) updateWorkplace(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Name         string `json:"name"`
+		BillingEmail string `json:"billing_email"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var workplace Workplace
+	err := s.store.update(func(data *Data) error {
+		if body.Name != "" {
+			data.Workplace.Name = body.Name
+		}
+		if body.BillingEmail != "" {
+			data.Workplace.BillingEmail = body.BillingEmail
+		}
+		workplace = data.Workplace
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"workplace": workplaceJSON(workplace)})
+}
+
+func (s *Server) getLogs(w http.ResponseWriter, r *http.Request, a actor) {
+	// the dev server doesn't record activity
+	writeJSON(w, http.StatusOK, map[string]interface{}{"logs": []interface{}{}, "page": 1, "success": true})
+}
+
+func (s *Server) getProjects(w http.ResponseWriter, r *http.Request, a actor) {
+	var projects []interface{}
+	_ = s.store.view(func(data *Data) error {
+		for _, project := range data.Projects {
+			projects = append(projects, projectJSON(project))
+		}
+		return nil
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"projects": paginate(r, projects), "success": true})
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request, a actor) {
+	var result interface{}
+	err := s.store.view(func(data *Data) error {
+		project, err := data.project(r.URL.Query().Get("project"))
+		if err != nil {
+			return notFound(err)
+		}
+		result = projectJSON(project)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"project": result, "success": true})
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var result interface{}
+	err := s.store.update(func(data *Data) error {
+		project, err := data.createProject(body.Name, body.Description)
+		if err != nil {
+			return err
+		}
+		result = projectJSON(project)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"project": result, "success": true})
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Name        string  `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var result interface{}
+	err := s.store.update(func(data *Data) error {
+		project, err := data.project(r.URL.Query().Get("project"))
+		if err != nil {
+			return notFound(err)
+		}
+		if body.Name != "" && body.Name != project.Name {
+			name := slugify(body.Name)
+			if !validName(name) {
+				return badRequest("Invalid project name '%s'", body.Name)
+			}
+			if _, err := data.project(name); err == nil {
+				return badRequest("A project named '%s' already exists", name)
+			}
+			project.Name = name
+		}
+		if body.Description != nil {
+			project.Description = *body.Description
+		}
+		result = projectJSON(project)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"project": result, "success": true})
+}
+
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request, a actor) {
+	err := s.store.update(func(data *Data) error {
+		name := r.URL.Query().Get("project")
+		for i, project := range data.Projects {
+			if project.Name == name {
+				data.Projects = append(data.Projects[:i], data.Projects[i+1:]...)
+				return nil
+			}
+		}
+		return notFound(fmt.Errorf("Could not find requested project '%s'", name))
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func (s *Server) setProjectNote(w http.ResponseWriter, r *http.Request, a actor) {
+	s.setNote(w, r, r.URL.Query().Get("project"))
+}
+
+func (s *Server) setConfigNote(w http.ResponseWriter, r *http.Request, a actor) {
+	project, _ := configParams(r, a)
+	s.setNote(w, r, project)
+}
+
+// setNote sets a secret's note. As with the Doppler API, notes apply to the secret across all of a project's configs.
+func (s *Server) setNote(w http.ResponseWriter, r *http.Request, projectName string) {
+	var body models.SecretNote
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err := s.store.update(func(data *Data) error {
+		project, err := data.project(projectName)
+		if err != nil {
+			return notFound(err)
+		}
+		if project.Notes == nil {
+			project.Notes = map[string]string{}
+		}
+		if body.Note == "" {
+			delete(project.Notes, body.Secret)
+		} else {
+			project.Notes[body.Secret] = body.Note
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) getEnvironments(w http.ResponseWriter, r *http.Request, a actor) {
+	var environments []interface{}
+	err := s.store.view(func(data *Data) error {
+		project, err := data.project(r.URL.Query().Get("project"))
+		if err != nil {
+			return notFound(err)
+		}
+		for _, environment := range project.Environments {
+			environments = append(environments, environmentJSON(project, environment))
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"environments": paginate(r, environments), "success": true})
+}
+
+func (s *Server) getEnvironment(w http.ResponseWriter, r *http.Request, a actor) {
+	var result interface{}
+	err := s.store.view(func(data *Data) error {
+		project, err := data.project(r.URL.Query().Get("project"))
+		if err != nil {
+			return notFound(err)
+		}
+		slug := r.URL.Query().Get("environment")
+		environment := project.environment(slug)
+		if environment == nil {
+			return notFound(fmt.Errorf("Could not find requested environment '%s'", slug))
+		}
+		result = environmentJSON(project, environment)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"environment": result, "success": true})
+}
+
+func (s *Server) createEnvironment(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Project string `json:"project"`
+		Name    string `json:"name"`
+		Slug    string `json:"slug"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.Project == "" {
+		body.Project = r.URL.Query().Get("project")
+	}
+
+	var result interface{}
+	err := s.store.update(func(data *Data) error {
+		project, err := data.project(body.Project)
+		if err != nil {
+			return notFound(err)
+		}
+		environment, err := project.createEnvironment(body.Slug, body.Name)
+		if err != nil {
+			return err
+		}
+		result = environmentJSON(project, environment)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"environment": result, "success": true})
+}
+
+func (s *Server) renameEnvironment(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Project     string `json:"project"`
+		Environment string `json:"environment"`
+		Name        string `json:"name"`
+		Slug        string `json:"slug"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var result interface{}
+	err := s.store.update(func(data *Data) error {
+		project, err := data.project(body.Project)
+		if err != nil {
+			return notFound(err)
+		}
+		environment := project.environment(body.Environment)
+		if environment == nil {
+			return notFound(fmt.Errorf("Could not find requested environment '%s'", body.Environment))
+		}
+
+		if body.Slug != "" && body.Slug != environment.Slug {
+			if !validName(body.Slug) {
+				return badRequest("Invalid environment slug '%s'", body.Slug)
+			}
+			if project.environment(body.Slug) != nil {
+				return badRequest("An environment with slug '%s' already exists", body.Slug)
+			}
+			// configs are prefixed by their environment's slug
+			for _, config := range project.Configs {
+				if config.Environment == environment.Slug {
+					config.Environment = body.Slug
+					config.Name = body.Slug + strings.TrimPrefix(config.Name, environment.Slug)
+				}
+			}
+			environment.Slug = body.Slug
+		}
+		if body.Name != "" {
+			environment.Name = body.Name
+		}
+		result = environmentJSON(project, environment)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"environment": result, "success": true})
+}
+
+func (s *Server) deleteEnvironment(w http.ResponseWriter, r *http.Request, a actor) {
+	err := s.store.update(func(data *Data) error {
+		project, err := data.project(r.URL.Query().Get("project"))
+		if err != nil {
+			return notFound(err)
+		}
+		slug := r.URL.Query().Get("environment")
+		for i, environment := range project.Environments {
+			if environment.Slug == slug {
+				project.Environments = append(project.Environments[:i], project.Environments[i+1:]...)
+
+				var configs []*Config
+				for _, config := range project.Configs {
+					if config.Environment != slug {
+						configs = append(configs, config)
+					}
+				}
+				project.Configs = configs
+				return nil
+			}
+		}
+		return notFound(fmt.Errorf("Could not find requested environment '%s'", slug))
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func (s *Server) getConfigs(w http.ResponseWriter, r *http.Request, a actor) {
+	var configs []interface{}
+	err := s.store.view(func(data *Data) error {
+		project, err := data.project(r.URL.Query().Get("project"))
+		if err != nil {
+			return notFound(err)
+		}
+		environment := r.URL.Query().Get("environment")
+		for _, config := range project.Configs {
+			if environment == "" || config.Environment == environment {
+				configs = append(configs, configJSON(project, config))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"configs": paginate(r, configs), "success": true})
+}
+
+func (s *Server) getConfig(w http.ResponseWriter, r *http.Request, a actor) {
+	projectName, configName := configParams(r, a)
+
+	var result interface{}
+	err := s.store.view(func(data *Data) error {
+		project, config, err := data.config(projectName, configName)
+		if err != nil {
+			return notFound(err)
+		}
+		result = configJSON(project, config)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"config": result, "success": true})
+}
+
+func (s *Server) createConfig(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Name        string `json:"name"`
+		Environment string `json:"environment"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var result interface{}
+	err := s.store.update(func(data *Data) error {
+		project, err := data.project(r.URL.Query().Get("project"))
+		if err != nil {
+			return notFound(err)
+		}
+		if project.environment(body.Environment) == nil {
+			return notFound(fmt.Errorf("Could not find requested environment '%s'", body.Environment))
+		}
+		config, err := project.createConfig(body.Name, body.Environment, map[string]string{})
+		if err != nil {
+			return err
+		}
+		result = configJSON(project, config)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"config": result, "success": true})
+}
+
+func (s *Server) updateConfig(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var result interface{}
+	err := s.store.update(func(data *Data) error {
+		project, config, err := data.config(r.URL.Query().Get("project"), r.URL.Query().Get("config"))
+		if err != nil {
+			return notFound(err)
+		}
+		if config.Locked {
+			return badRequest("Config '%s' is locked", config.Name)
+		}
+		if config.Name == config.Environment {
+			return badRequest("Root configs cannot be renamed")
+		}
+		if !validConfigName(body.Name, config.Environment) {
+			return badRequest("Config name must be prefixed by the environment's slug, e.g. '%s_example'", config.Environment)
+		}
+		if project.config(body.Name) != nil {
+			return badRequest("A config named '%s' already exists", body.Name)
+		}
+		config.Name = body.Name
+		result = configJSON(project, config)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"config": result, "success": true})
+}
+
+func (s *Server) deleteConfig(w http.ResponseWriter, r *http.Request, a actor) {
+	err := s.store.update(func(data *Data) error {
+		project, config, err := data.config(r.URL.Query().Get("project"), r.URL.Query().Get("config"))
+		if err != nil {
+			return notFound(err)
+		}
+		if config.Locked {
+			return badRequest("Config '%s' is locked", config.Name)
+		}
+		if config.Name == config.Environment {
+			return badRequest("Root configs cannot be deleted")
+		}
+		for i, c := range project.Configs {
+			if c == config {
+				project.Configs = append(project.Configs[:i], project.Configs[i+1:]...)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func (s *Server) lockConfig(locked bool) func(w http.ResponseWriter, r *http.Request, a actor) {
+	return func(w http.ResponseWriter, r *http.Request, a actor) {
+		var result interface{}
+		err := s.store.update(func(data *Data) error {
+			project, config, err := data.config(r.URL.Query().Get("project"), r.URL.Query().Get("config"))
+			if err != nil {
+				return notFound(err)
+			}
+			config.Locked = locked
+			result = configJSON(project, config)
+			return nil
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"config": result, "success": true})
+	}
+}
+
+func (s *Server) cloneConfig(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var result interface{}
+	err := s.store.update(func(data *Data) error {
+		project, config, err := data.config(r.URL.Query().Get("project"), r.URL.Query().Get("config"))
+		if err != nil {
+			return notFound(err)
+		}
+		secrets := map[string]string{}
+		for name, value := range config.Secrets {
+			secrets[name] = value
+		}
+		clone, err := project.createConfig(body.Name, config.Environment, secrets)
+		if err != nil {
+			return err
+		}
+		result = configJSON(project, clone)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"config": result, "success": true})
+}
+
+func (s *Server) ping(w http.ResponseWriter, r *http.Request, a actor) {
+	projectName, configName := configParams(r, a)
+	err := s.store.view(func(data *Data) error {
+		if _, _, err := data.config(projectName, configName); err != nil {
+			return notFound(err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func (s *Server) getServiceTokens(w http.ResponseWriter, r *http.Request, a actor) {
+	tokens := []interface{}{}
+	err := s.store.view(func(data *Data) error {
+		project, config, err := data.config(r.URL.Query().Get("project"), r.URL.Query().Get("config"))
+		if err != nil {
+			return notFound(err)
+		}
+		for _, token := range config.Tokens {
+			// like the Doppler API, the token's key is only returned when it's created
+			tokens = append(tokens, serviceTokenJSON(project, config, token, false))
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tokens": tokens, "success": true})
+}
+
+func (s *Server) createServiceToken(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Name     string `json:"name"`
+		ExpireAt int64  `json:"expire_at"`
+		Access   string `json:"access"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.Name == "" {
+		writeError(w, badRequest("Service token name is required"))
+		return
+	}
+	if body.Access == "" {
+		body.Access = "read"
+	}
+	if body.Access != "read" && body.Access != "read/write" {
+		writeError(w, badRequest("Invalid access '%s'. Must be one of [read, read/write]", body.Access))
+		return
+	}
+
+	var result interface{}
+	err := s.store.update(func(data *Data) error {
+		project, config, err := data.config(r.URL.Query().Get("project"), r.URL.Query().Get("config"))
+		if err != nil {
+			return notFound(err)
+		}
+		token := &ServiceToken{
+			Name:      body.Name,
+			Slug:      randomSlug(),
+			Key:       randomToken(fmt.Sprintf("dp.st.%s.", config.Name)),
+			Access:    body.Access,
+			CreatedAt: now(),
+		}
+		if body.ExpireAt > 0 {
+			token.ExpiresAt = time.Unix(body.ExpireAt, 0).UTC().Format(time.RFC3339)
+		}
+		config.Tokens = append(config.Tokens, token)
+		result = serviceTokenJSON(project, config, token, true)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"token": result, "success": true})
+}
+
+func (s *Server) deleteServiceToken(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Slug  string `json:"slug"`
+		Token string `json:"token"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err := s.store.update(func(data *Data) error {
+		_, config, err := data.config(r.URL.Query().Get("project"), r.URL.Query().Get("config"))
+		if err != nil {
+			return notFound(err)
+		}
+		for i, token := range config.Tokens {
+			if (body.Slug != "" && token.Slug == body.Slug) || (body.Token != "" && token.Key == body.Token) {
+				config.Tokens = append(config.Tokens[:i], config.Tokens[i+1:]...)
+				return nil
+			}
+		}
+		return notFound(fmt.Errorf("Could not find requested service token"))
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// template the format used by 'doppler import'
+type template struct {
+	Projects []struct {
+		Name         string `yaml:"name"`
+		Description  string `yaml:"description"`
+		Environments []struct {
+			Slug    string `yaml:"slug"`
+			Name    string `yaml:"name"`
+			Configs []struct {
+				Slug string `yaml:"slug"`
+			} `yaml:"configs"`
+		} `yaml:"environments"`
+		Secrets map[string]map[string]string `yaml:"secrets"`
+	} `yaml:"projects"`
+}
+
+func (s *Server) importTemplate(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Template string `json:"template"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var t template
+	if err := yaml.Unmarshal([]byte(body.Template), &t); err != nil {
+		writeError(w, badRequest("Invalid template: %s", err))
+		return
+	}
+
+	projects := []interface{}{}
+	err := s.store.update(func(data *Data) error {
+		for _, p := range t.Projects {
+			project, err := data.createProject(p.Name, p.Description)
+			if err != nil {
+				return err
+			}
+			for _, e := range p.Environments {
+				if _, err := project.createEnvironment(e.Slug, e.Name); err != nil {
+					return err
+				}
+				for _, c := range e.Configs {
+					config := project.config(c.Slug)
+					if config == nil {
+						if config, err = project.createConfig(c.Slug, e.Slug, map[string]string{}); err != nil {
+							return err
+						}
+					}
+					for name, value := range p.Secrets[c.Slug] {
+						config.Secrets[name] = value
+					}
+				}
+			}
+			projects = append(projects, projectJSON(project))
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"projects": projects, "success": true})
+}
+
+func (d *Data) createProject(name string, description string) (*Project, error) {
+	slug := slugify(name)
+	if !validName(slug) {
+		return nil, badRequest("Invalid project name '%s'", name)
+	}
+	if _, err := d.project(slug); err == nil {
+		return nil, badRequest("A project named '%s' already exists", slug)
+	}
+
+	project := &Project{Name: slug, Description: description, CreatedAt: now()}
+	d.Projects = append(d.Projects, project)
+	return project, nil
+}
+
+func (p *Project) createEnvironment(slug string, name string) (*Environment, error) {
+	if !validName(slug) {
+		return nil, badRequest("Invalid environment slug '%s'", slug)
+	}
+	if p.environment(slug) != nil {
+		return nil, badRequest("An environment with slug '%s' already exists", slug)
+	}
+	if name == "" {
+		name = slug
+	}
+
+	environment := &Environment{Slug: slug, Name: name, CreatedAt: now()}
+	p.Environments = append(p.Environments, environment)
+	// every environment has a root config with the same name
+	if _, err := p.createConfig(slug, slug, map[string]string{}); err != nil {
+		return nil, err
+	}
+	return environment, nil
+}
+
+func (p *Project) createConfig(name string, environment string, secrets map[string]string) (*Config, error) {
+	if !validConfigName(name, environment) {
+		return nil, badRequest("Config name must be prefixed by the environment's slug, e.g. '%s_example'", environment)
+	}
+	if p.config(name) != nil {
+		return nil, badRequest("A config named '%s' already exists", name)
+	}
+
+	config := &Config{Name: name, Environment: environment, CreatedAt: now(), Secrets: secrets}
+	p.Configs = append(p.Configs, config)
+	return config, nil
+}
+
+func slugify(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "-")
+}
+
+func workplaceJSON(workplace Workplace) map[string]interface{} {
+	return map[string]interface{}{"id": workplace.ID, "name": workplace.Name, "billing_email": workplace.BillingEmail}
+}
+
+func projectJSON(project *Project) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          project.Name,
+		"slug":        project.Name,
+		"name":        project.Name,
+		"description": project.Description,
+		"created_at":  project.CreatedAt,
+	}
+}
+
+func environmentJSON(project *Project, environment *Environment) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               environment.Slug,
+		"slug":             environment.Slug,
+		"name":             environment.Name,
+		"project":          project.Name,
+		"created_at":       environment.CreatedAt,
+		"initial_fetch_at": nil,
+	}
+}
+
+func configJSON(project *Project, config *Config) map[string]interface{} {
+	return map[string]interface{}{
+		"name":             config.Name,
+		"root":             config.Name == config.Environment,
+		"locked":           config.Locked,
+		"environment":      config.Environment,
+		"project":          project.Name,
+		"created_at":       config.CreatedAt,
+		"initial_fetch_at": nil,
+		"last_fetch_at":    nil,
+		"inheritable":      false,
+		"inherits":         []interface{}{},
+		"inheritedBy":      []interface{}{},
+	}
+}
+
+func serviceTokenJSON(project *Project, config *Config, token *ServiceToken, includeKey bool) map[string]interface{} {
+	result := map[string]interface{}{
+		"name":        token.Name,
+		"slug":        token.Slug,
+		"access":      token.Access,
+		"created_at":  token.CreatedAt,
+		"expires_at":  nil,
+		"project":     project.Name,
+		"environment": config.Environment,
+		"config":      config.Name,
+	}
+	if token.ExpiresAt != "" {
+		result["expires_at"] = token.ExpiresAt
+	}
+	if includeKey {
+		result["key"] = token.Key
+	}
+	return result
+}
@@ -0,0 +1,404 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package devserver
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+func (s *Server) getSecrets(w http.ResponseWriter, r *http.Request, a actor) {
+	projectName, configName := configParams(r, a)
+
+	var result map[string]interface{}
+	err := s.store.view(func(data *Data) error {
+		project, config, err := data.config(projectName, configName)
+		if err != nil {
+			return notFound(err)
+		}
+		result = secretsJSON(project, config, filterParam(r))
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"secrets": result, "success": true})
+}
+
+func (s *Server) getSecretNames(w http.ResponseWriter, r *http.Request, a actor) {
+	projectName, configName := configParams(r, a)
+
+	var names []string
+	err := s.store.view(func(data *Data) error {
+		project, config, err := data.config(projectName, configName)
+		if err != nil {
+			return notFound(err)
+		}
+		names = sortedKeys(project.computedSecrets(config))
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"names": names, "success": true})
+}
+
+func (s *Server) setSecrets(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		Secrets        map[string]*string     `json:"secrets"`
+		ChangeRequests []models.ChangeRequest `json:"change_requests"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.modifySecrets(w, r, a, func(config *Config) error {
+		for name, value := range body.Secrets {
+			if value == nil {
+				delete(config.Secrets, name)
+			} else {
+				config.Secrets[name] = *value
+			}
+		}
+
+		for _, change := range body.ChangeRequests {
+			if originalName, ok := change.OriginalName.(string); ok && originalName != "" && originalName != change.Name {
+				value, exists := config.Secrets[originalName]
+				if !exists {
+					return notFound(fmt.Errorf("Could not find requested secret '%s'", originalName))
+				}
+				delete(config.Secrets, originalName)
+				config.Secrets[change.Name] = value
+			}
+
+			if change.ShouldDelete != nil && *change.ShouldDelete {
+				delete(config.Secrets, change.Name)
+				continue
+			}
+
+			switch value := change.Value.(type) {
+			case string:
+				config.Secrets[change.Name] = value
+			case nil:
+				// retain the existing value
+				if _, exists := config.Secrets[change.Name]; !exists {
+					config.Secrets[change.Name] = ""
+				}
+			default:
+				return badRequest("Invalid value for secret '%s'", change.Name)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Server) uploadSecrets(w http.ResponseWriter, r *http.Request, a actor) {
+	var body struct {
+		File string `json:"file"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	secrets, err := parseSecretsFile(body.File)
+	if err != nil {
+		writeError(w, badRequest("Unable to parse file: %s", err))
+		return
+	}
+
+	s.modifySecrets(w, r, a, func(config *Config) error {
+		for name, value := range secrets {
+			config.Secrets[name] = value
+		}
+		return nil
+	})
+}
+
+// modifySecrets applies fn to the requested config, notifies watchers, and responds with the config's secrets
+func (s *Server) modifySecrets(w http.ResponseWriter, r *http.Request, a actor, fn func(config *Config) error) {
+	projectName, configName := configParams(r, a)
+
+	var result map[string]interface{}
+	err := s.store.update(func(data *Data) error {
+		project, config, err := data.config(projectName, configName)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := fn(config); err != nil {
+			return err
+		}
+		s.store.notify(project.Name, config.Name)
+		result = secretsJSON(project, config, nil)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"secrets": result, "success": true})
+}
+
+func (s *Server) downloadSecrets(w http.ResponseWriter, r *http.Request, a actor) {
+	projectName, configName := configParams(r, a)
+
+	var secrets map[string]string
+	err := s.store.view(func(data *Data) error {
+		project, config, err := data.config(projectName, configName)
+		if err != nil {
+			return notFound(err)
+		}
+		secrets = project.computedSecrets(config)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if filter := filterParam(r); filter != nil {
+		for name := range secrets {
+			if !utils.Contains(filter, name) {
+				delete(secrets, name)
+			}
+		}
+	}
+
+	if transformer := r.URL.Query().Get("name_transformer"); transformer != "" {
+		if secrets, err = transformNames(secrets, transformer); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.JSON.String()
+	}
+	body, contentType, err := formatSecrets(secrets, format)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	hash := sha256.Sum256(body)
+	etag := fmt.Sprintf("\"%s\"", hex.EncodeToString(hash[:]))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+func (s *Server) watchSecrets(w http.ResponseWriter, r *http.Request, a actor) {
+	projectName, configName := configParams(r, a)
+
+	err := s.store.view(func(data *Data) error {
+		_, _, err := data.config(projectName, configName)
+		if err != nil {
+			return notFound(err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, fmt.Errorf("Streaming is not supported"))
+		return
+	}
+
+	changes := s.store.subscribe(projectName, configName)
+	defer s.store.unsubscribe(projectName, configName, changes)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(eventType string) {
+		fmt.Fprintf(w, "event: message\ndata: {\"type\":\"%s\"}\n\n", eventType)
+		flusher.Flush()
+	}
+	send("connected")
+
+	ticker := time.NewTicker(s.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changes:
+			send("secrets.update")
+		case <-ticker.C:
+			send("ping")
+		}
+	}
+}
+
+func filterParam(r *http.Request) []string {
+	if !r.URL.Query().Has("secrets") {
+		return nil
+	}
+	filter := []string{}
+	for _, name := range strings.Split(r.URL.Query().Get("secrets"), ",") {
+		if name != "" {
+			filter = append(filter, name)
+		}
+	}
+	return filter
+}
+
+func secretsJSON(project *Project, config *Config, filter []string) map[string]interface{} {
+	computed := project.computedSecrets(config)
+
+	result := map[string]interface{}{}
+	for name, computedValue := range computed {
+		if filter != nil && !utils.Contains(filter, name) {
+			continue
+		}
+
+		raw, ok := config.Secrets[name]
+		if !ok {
+			raw = computedValue
+		}
+		result[name] = map[string]interface{}{
+			"raw":                raw,
+			"computed":           computedValue,
+			"rawVisibility":      "masked",
+			"computedVisibility": "masked",
+			"rawValueType":       map[string]string{"type": "string"},
+			"computedValueType":  map[string]string{"type": "string"},
+			"note":               project.Notes[name],
+		}
+	}
+	return result
+}
+
+func formatSecrets(secrets map[string]string, format string) ([]byte, string, error) {
+	switch format {
+	case models.JSON.String():
+		body, err := json.Marshal(secrets)
+		return body, "application/json", err
+	case models.DOTNET_JSON.String():
+		body, err := json.Marshal(utils.MapToDotNETJSONFormat(secrets))
+		return body, "application/json", err
+	case models.ENV.String():
+		return []byte(strings.Join(utils.MapToEnvFormat(secrets, true), "\n")), "text/plain", nil
+	case models.ENV_NO_QUOTES.String(), models.DOCKER.String():
+		return []byte(strings.Join(utils.MapToEnvFormat(secrets, false), "\n")), "text/plain", nil
+	case models.YAML.String():
+		body, err := yaml.Marshal(secrets)
+		return body, "text/yaml", err
+	default:
+		return nil, "", badRequest("Invalid format '%s'", format)
+	}
+}
+
+func transformNames(secrets map[string]string, transformer string) (map[string]string, error) {
+	var transform func(string) string
+	switch transformer {
+	case models.UpperCamelTransformer.Type:
+		transform = utils.UpperCamel
+	case models.CamelTransformer.Type:
+		transform = func(name string) string {
+			upperCamel := utils.UpperCamel(name)
+			if upperCamel == "" {
+				return upperCamel
+			}
+			return strings.ToLower(upperCamel[0:1]) + upperCamel[1:]
+		}
+	case models.LowerKebabTransformer.Type:
+		transform = func(name string) string { return strings.ReplaceAll(strings.ToLower(name), "_", "-") }
+	case models.LowerSnakeTransformer.Type:
+		transform = strings.ToLower
+	case models.TFVarTransformer.Type:
+		transform = func(name string) string { return "TF_VAR_" + strings.ToLower(name) }
+	case models.DotNETTransformer.Type:
+		transform = utils.DotNETNameTransform
+	case models.DotNETEnvTransformer.Type:
+		transform = func(name string) string {
+			var parts []string
+			for _, part := range strings.Split(name, "__") {
+				parts = append(parts, utils.UpperCamel(part))
+			}
+			return strings.Join(parts, "__")
+		}
+	default:
+		return nil, badRequest("Invalid name transformer '%s'", transformer)
+	}
+
+	transformed := map[string]string{}
+	for name, value := range secrets {
+		transformed[transform(name)] = value
+	}
+	return transformed, nil
+}
+
+// parseSecretsFile parses a JSON object or an env file
+func parseSecretsFile(file string) (map[string]string, error) {
+	secrets := map[string]string{}
+	if strings.HasPrefix(strings.TrimSpace(file), "{") {
+		err := json.Unmarshal([]byte(file), &secrets)
+		return secrets, err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(file))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		name := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			quote := value[0]
+			value = value[1 : len(value)-1]
+			if quote == '"' {
+				value = strings.ReplaceAll(value, "\\\"", "\"")
+				value = strings.ReplaceAll(value, "\\n", "\n")
+				value = strings.ReplaceAll(value, "\\\\", "\\")
+			}
+		}
+		secrets[name] = value
+	}
+	return secrets, scanner.Err()
+}
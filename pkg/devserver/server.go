@@ -0,0 +1,325 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package devserver
+
+// This package implements a local, in-process stand-in for the subset of the Doppler v3 API
+// used by the CLI. It is intended for development and testing only and offers no security guarantees.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// DefaultPingInterval how often keep-alive events are sent on watch streams
+var DefaultPingInterval = 30 * time.Second
+
+// Server an http.Handler implementing the Doppler API
+type Server struct {
+	store        *Store
+	routes       map[string]map[string]handler
+	PingInterval time.Duration
+}
+
+// actor the authenticated caller
+type actor struct {
+	personal *PersonalToken
+	service  *ServiceToken
+	// the project and config a service token is scoped to
+	project string
+	config  string
+}
+
+type handler struct {
+	fn func(w http.ResponseWriter, r *http.Request, a actor)
+	// whether service tokens can access the endpoint
+	allowServiceToken bool
+	// whether the endpoint modifies secrets; disallowed for read-only service tokens
+	write bool
+}
+
+// apiError an error with an associated HTTP status code
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e apiError) Error() string { return e.message }
+
+func badRequest(format string, args ...interface{}) apiError {
+	return apiError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(err error) apiError {
+	return apiError{status: http.StatusNotFound, message: err.Error()}
+}
+
+// NewServer creates a server backed by the specified store
+func NewServer(store *Store) *Server {
+	s := &Server{store: store, PingInterval: DefaultPingInterval}
+
+	s.routes = map[string]map[string]handler{
+		"/v3/me": {
+			"GET": {fn: s.getMe, allowServiceToken: true},
+		},
+		"/v3/workplace": {
+			"GET":  {fn: s.getWorkplace},
+			"POST": {fn: s.updateWorkplace},
+		},
+		"/v3/workplace/template/import": {
+			"POST": {fn: s.importTemplate},
+		},
+		"/v3/logs": {
+			"GET": {fn: s.getLogs},
+		},
+		"/v3/projects": {
+			"GET":  {fn: s.getProjects},
+			"POST": {fn: s.createProject},
+		},
+		"/v3/projects/project": {
+			"GET":    {fn: s.getProject},
+			"POST":   {fn: s.updateProject},
+			"DELETE": {fn: s.deleteProject},
+		},
+		"/v3/projects/project/note": {
+			"POST": {fn: s.setProjectNote},
+		},
+		"/v3/environments": {
+			"GET":  {fn: s.getEnvironments},
+			"POST": {fn: s.createEnvironment},
+		},
+		"/v3/environments/environment": {
+			"GET":    {fn: s.getEnvironment},
+			"PUT":    {fn: s.renameEnvironment},
+			"DELETE": {fn: s.deleteEnvironment},
+		},
+		"/v3/configs": {
+			"GET":  {fn: s.getConfigs},
+			"POST": {fn: s.createConfig},
+		},
+		"/v3/configs/config": {
+			"GET":    {fn: s.getConfig, allowServiceToken: true},
+			"POST":   {fn: s.updateConfig},
+			"DELETE": {fn: s.deleteConfig},
+		},
+		"/v3/configs/config/lock": {
+			"POST": {fn: s.lockConfig(true)},
+		},
+		"/v3/configs/config/unlock": {
+			"POST": {fn: s.lockConfig(false)},
+		},
+		"/v3/configs/config/clone": {
+			"POST": {fn: s.cloneConfig},
+		},
+		"/v3/configs/config/logs": {
+			"GET": {fn: s.getLogs},
+		},
+		"/v3/configs/config/ping": {
+			"GET": {fn: s.ping, allowServiceToken: true},
+		},
+		"/v3/configs/config/secrets": {
+			"GET":  {fn: s.getSecrets, allowServiceToken: true},
+			"POST": {fn: s.setSecrets, allowServiceToken: true, write: true},
+		},
+		"/v3/configs/config/secrets/names": {
+			"GET": {fn: s.getSecretNames, allowServiceToken: true},
+		},
+		"/v3/configs/config/secrets/download": {
+			"GET": {fn: s.downloadSecrets, allowServiceToken: true},
+		},
+		"/v3/configs/config/secrets/watch": {
+			"GET": {fn: s.watchSecrets, allowServiceToken: true},
+		},
+		"/v3/configs/config/secrets/upload": {
+			"POST": {fn: s.uploadSecrets, allowServiceToken: true, write: true},
+		},
+		"/v3/configs/config/secrets/note": {
+			"POST": {fn: s.setConfigNote, allowServiceToken: true, write: true},
+		},
+		"/v3/configs/config/tokens": {
+			"GET":  {fn: s.getServiceTokens},
+			"POST": {fn: s.createServiceToken},
+		},
+		"/v3/configs/config/tokens/token": {
+			"DELETE": {fn: s.deleteServiceToken},
+		},
+	}
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.serve(recorder, r)
+	utils.LogWithFields(fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, recorder.status), utils.LogFields{"method": r.Method, "path": r.URL.Path, "status_code": recorder.status})
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	routes, ok := s.routes[strings.TrimSuffix(r.URL.Path, "/")]
+	if !ok {
+		writeError(w, apiError{status: http.StatusNotFound, message: fmt.Sprintf("The dev server does not support %s", r.URL.Path)})
+		return
+	}
+	h, ok := routes[r.Method]
+	if !ok {
+		writeError(w, apiError{status: http.StatusMethodNotAllowed, message: fmt.Sprintf("Method %s is not allowed", r.Method)})
+		return
+	}
+
+	a, err := s.authenticate(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if a.service != nil {
+		if !h.allowServiceToken {
+			writeError(w, apiError{status: http.StatusForbidden, message: "Service tokens are not allowed to access this endpoint"})
+			return
+		}
+		if h.write && a.service.Access != "read/write" {
+			writeError(w, apiError{status: http.StatusForbidden, message: "This service token has read-only access"})
+			return
+		}
+	}
+
+	h.fn(w, r, a)
+}
+
+func (s *Server) authenticate(r *http.Request) (actor, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		if username, _, ok := r.BasicAuth(); ok {
+			token = username
+		}
+	}
+	if token == "" {
+		return actor{}, apiError{status: http.StatusUnauthorized, message: "Missing auth token"}
+	}
+
+	var a actor
+	found := false
+	_ = s.store.view(func(data *Data) error {
+		for _, personal := range data.Tokens {
+			if personal.Key == token {
+				a = actor{personal: personal}
+				found = true
+				return nil
+			}
+		}
+		for _, project := range data.Projects {
+			for _, config := range project.Configs {
+				for _, service := range config.Tokens {
+					if service.Key == token {
+						a = actor{service: service, project: project.Name, config: config.Name}
+						found = true
+						return nil
+					}
+				}
+			}
+		}
+		return nil
+	})
+
+	if !found {
+		return actor{}, apiError{status: http.StatusUnauthorized, message: "Invalid auth token"}
+	}
+	if a.service != nil && a.service.ExpiresAt != "" {
+		if expiresAt, err := time.Parse(time.RFC3339, a.service.ExpiresAt); err == nil && time.Now().After(expiresAt) {
+			return actor{}, apiError{status: http.StatusUnauthorized, message: "This service token has expired"}
+		}
+	}
+
+	return a, nil
+}
+
+// configParams returns the project and config for the request. Service tokens are always scoped to their own config.
+func configParams(r *http.Request, a actor) (string, string) {
+	if a.service != nil {
+		return a.project, a.config
+	}
+	return r.URL.Query().Get("project"), r.URL.Query().Get("config")
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return badRequest("Missing request body")
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return badRequest("Invalid request body: %s", err)
+	}
+	return nil
+}
+
+// paginate returns the requested page of items using the page and per_page query params
+func paginate[T any](r *http.Request, items []T) []T {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = 20
+	}
+
+	start := (page - 1) * perPage
+	if start >= len(items) {
+		return []T{}
+	}
+	end := start + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		utils.LogDebugError(err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if e, ok := err.(apiError); ok {
+		status = e.status
+	}
+	writeJSON(w, status, map[string]interface{}{"messages": []string{err.Error()}, "success": false})
+}
+
+// statusRecorder captures the response status code for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
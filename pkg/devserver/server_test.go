@@ -0,0 +1,157 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package devserver
+
+import (
+	"bufio"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/http"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) (*Store, *httptest.Server, string) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "dev-server.yaml"))
+	require.NoError(t, err)
+
+	server := NewServer(store)
+	server.PingInterval = time.Hour
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	return store, ts, store.PersonalTokens()[0]
+}
+
+func addServiceToken(t *testing.T, store *Store, access string) string {
+	key := randomToken("dp.st.dev.")
+	require.NoError(t, store.update(func(data *Data) error {
+		_, config, err := data.config("example", "dev")
+		if err != nil {
+			return err
+		}
+		config.Tokens = append(config.Tokens, &ServiceToken{Name: "test", Slug: randomSlug(), Key: key, Access: access, CreatedAt: now()})
+		return nil
+	}))
+	return key
+}
+
+func TestAuthentication(t *testing.T) {
+	_, ts, _ := newTestServer(t)
+
+	_, err := http.GetProjects(ts.URL, false, "dp.pt.invalid", 1, 20)
+	require.NotNil(t, err.Unwrap())
+	assert.Equal(t, nethttp.StatusUnauthorized, err.Code)
+}
+
+func TestDownloadSecretsETag(t *testing.T) {
+	_, ts, token := newTestServer(t)
+
+	statusCode, headers, body, err := http.DownloadSecrets(ts.URL, false, token, "example", "dev", models.JSON, nil, "", 0, nil)
+	require.Nil(t, err.Unwrap())
+	assert.Equal(t, nethttp.StatusOK, statusCode)
+
+	var secrets map[string]string
+	require.NoError(t, json.Unmarshal(body, &secrets))
+	assert.Equal(t, "https://dev.example.com", secrets["API_URL"])
+	assert.Equal(t, "dev", secrets["DOPPLER_CONFIG"])
+
+	etag := headers.Get("ETag")
+	require.NotEmpty(t, etag)
+	statusCode, _, _, err = http.DownloadSecrets(ts.URL, false, token, "example", "dev", models.JSON, nil, etag, 0, nil)
+	require.Nil(t, err.Unwrap())
+	assert.Equal(t, nethttp.StatusNotModified, statusCode)
+
+	_, err = http.SetSecrets(ts.URL, false, token, "example", "dev", map[string]interface{}{"API_URL": "http://localhost"}, nil)
+	require.Nil(t, err.Unwrap())
+	statusCode, _, _, err = http.DownloadSecrets(ts.URL, false, token, "example", "dev", models.JSON, nil, etag, 0, nil)
+	require.Nil(t, err.Unwrap())
+	assert.Equal(t, nethttp.StatusOK, statusCode)
+}
+
+func TestSecretReferences(t *testing.T) {
+	_, ts, token := newTestServer(t)
+
+	secrets, err := http.SetSecrets(ts.URL, false, token, "example", "dev", map[string]interface{}{"HOST": "localhost", "URL": "http://${HOST}:8080"}, nil)
+	require.Nil(t, err.Unwrap())
+	assert.Equal(t, "http://${HOST}:8080", *secrets["URL"].RawValue)
+	assert.Equal(t, "http://localhost:8080", *secrets["URL"].ComputedValue)
+}
+
+func TestServiceTokenScope(t *testing.T) {
+	store, ts, _ := newTestServer(t)
+
+	readToken := addServiceToken(t, store, "read")
+	_, err := http.GetSecretNames(ts.URL, false, readToken, "ignored", "ignored", false)
+	assert.Nil(t, err.Unwrap())
+
+	_, err = http.SetSecrets(ts.URL, false, readToken, "", "", map[string]interface{}{"FOO": "bar"}, nil)
+	require.NotNil(t, err.Unwrap())
+	assert.Equal(t, nethttp.StatusForbidden, err.Code)
+
+	_, err = http.GetProjects(ts.URL, false, readToken, 1, 20)
+	require.NotNil(t, err.Unwrap())
+	assert.Equal(t, nethttp.StatusForbidden, err.Code)
+
+	writeToken := addServiceToken(t, store, "read/write")
+	_, err = http.SetSecrets(ts.URL, false, writeToken, "", "", map[string]interface{}{"FOO": "bar"}, nil)
+	assert.Nil(t, err.Unwrap())
+}
+
+func TestWatchSecrets(t *testing.T) {
+	_, ts, token := newTestServer(t)
+
+	req, reqErr := nethttp.NewRequest("GET", ts.URL+"/v3/configs/config/secrets/watch?project=example&config=dev", nil)
+	require.NoError(t, reqErr)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, reqErr := nethttp.DefaultClient.Do(req)
+	require.NoError(t, reqErr)
+	defer resp.Body.Close()
+	require.Equal(t, nethttp.StatusOK, resp.StatusCode)
+
+	events := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				events <- data
+			}
+		}
+		close(events)
+	}()
+
+	nextEvent := func() string {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for watch event")
+			return ""
+		}
+	}
+
+	assert.Equal(t, `{"type":"connected"}`, nextEvent())
+	_, err := http.SetSecrets(ts.URL, false, token, "example", "dev", map[string]interface{}{"FOO": "bar"}, nil)
+	require.Nil(t, err.Unwrap())
+	assert.Equal(t, `{"type":"secrets.update"}`, nextEvent())
+}
@@ -0,0 +1,328 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package devserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Data the contents of the dev server's YAML store
+type Data struct {
+	Workplace Workplace        `yaml:"workplace"`
+	Tokens    []*PersonalToken `yaml:"tokens"`
+	Projects  []*Project       `yaml:"projects"`
+}
+
+// Workplace the dev server's workplace
+type Workplace struct {
+	ID           string `yaml:"id"`
+	Name         string `yaml:"name"`
+	BillingEmail string `yaml:"billing_email"`
+}
+
+// PersonalToken a token with full access to the workplace
+type PersonalToken struct {
+	Name      string `yaml:"name"`
+	Key       string `yaml:"key"`
+	CreatedAt string `yaml:"created_at"`
+}
+
+// Project a project
+type Project struct {
+	Name         string            `yaml:"name"`
+	Description  string            `yaml:"description"`
+	CreatedAt    string            `yaml:"created_at"`
+	Environments []*Environment    `yaml:"environments"`
+	Configs      []*Config         `yaml:"configs"`
+	Notes        map[string]string `yaml:"notes,omitempty"`
+}
+
+// Environment an environment
+type Environment struct {
+	Slug      string `yaml:"slug"`
+	Name      string `yaml:"name"`
+	CreatedAt string `yaml:"created_at"`
+}
+
+// Config a config
+type Config struct {
+	Name        string            `yaml:"name"`
+	Environment string            `yaml:"environment"`
+	Locked      bool              `yaml:"locked"`
+	CreatedAt   string            `yaml:"created_at"`
+	Secrets     map[string]string `yaml:"secrets"`
+	Tokens      []*ServiceToken   `yaml:"tokens,omitempty"`
+}
+
+// ServiceToken a token scoped to a single config
+type ServiceToken struct {
+	Name      string `yaml:"name"`
+	Slug      string `yaml:"slug"`
+	Key       string `yaml:"key"`
+	Access    string `yaml:"access"`
+	CreatedAt string `yaml:"created_at"`
+	ExpiresAt string `yaml:"expires_at,omitempty"`
+}
+
+// Store a YAML-backed store of projects, configs, and secrets. All methods are safe for concurrent use.
+type Store struct {
+	path        string
+	data        Data
+	mutex       sync.Mutex
+	subscribers map[string]map[chan struct{}]bool
+}
+
+// LoadStore reads the store from the specified path, creating it with example data if it doesn't exist
+func LoadStore(path string) (*Store, error) {
+	store := &Store{path: path, subscribers: map[string]map[chan struct{}]bool{}}
+
+	if !utils.Exists(path) {
+		store.data = exampleData()
+		if err := store.save(); err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+
+	bytes, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(bytes, &store.data); err != nil {
+		return nil, err
+	}
+
+	// normalize so handlers never encounter nil maps
+	for _, project := range store.data.Projects {
+		for _, config := range project.Configs {
+			if config.Secrets == nil {
+				config.Secrets = map[string]string{}
+			}
+		}
+	}
+
+	return store, nil
+}
+
+// Path the path of the store's YAML file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// PersonalTokens the workplace's personal tokens
+func (s *Store) PersonalTokens() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var keys []string
+	for _, token := range s.data.Tokens {
+		keys = append(keys, token.Key)
+	}
+	return keys
+}
+
+// save writes the store to disk. The caller must hold the mutex.
+func (s *Store) save() error {
+	bytes, err := yaml.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(s.path, bytes, utils.RestrictedFilePerms())
+}
+
+// update runs fn while holding the lock and persists any changes
+func (s *Store) update(fn func(data *Data) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := fn(&s.data); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// view runs fn while holding the lock
+func (s *Store) view(fn func(data *Data) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return fn(&s.data)
+}
+
+// subscribe registers for notifications when a config's secrets change
+func (s *Store) subscribe(project string, config string) chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := project + "/" + config
+	if s.subscribers[key] == nil {
+		s.subscribers[key] = map[chan struct{}]bool{}
+	}
+	ch := make(chan struct{}, 1)
+	s.subscribers[key][ch] = true
+	return ch
+}
+
+func (s *Store) unsubscribe(project string, config string, ch chan struct{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.subscribers[project+"/"+config], ch)
+}
+
+// notify informs subscribers that a config's secrets changed. The caller must hold the mutex.
+func (s *Store) notify(project string, config string) {
+	for ch := range s.subscribers[project+"/"+config] {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// a notification is already pending
+		}
+	}
+}
+
+func (d *Data) project(name string) (*Project, error) {
+	for _, project := range d.Projects {
+		if project.Name == name {
+			return project, nil
+		}
+	}
+	return nil, fmt.Errorf("Could not find requested project '%s'", name)
+}
+
+func (d *Data) config(projectName string, configName string) (*Project, *Config, error) {
+	project, err := d.project(projectName)
+	if err != nil {
+		return nil, nil, err
+	}
+	config := project.config(configName)
+	if config == nil {
+		return nil, nil, fmt.Errorf("Could not find requested config '%s'", configName)
+	}
+	return project, config, nil
+}
+
+func (p *Project) environment(slug string) *Environment {
+	for _, environment := range p.Environments {
+		if environment.Slug == slug {
+			return environment
+		}
+	}
+	return nil
+}
+
+func (p *Project) config(name string) *Config {
+	for _, config := range p.Configs {
+		if config.Name == name {
+			return config
+		}
+	}
+	return nil
+}
+
+// computedSecrets returns the config's secrets with ${NAME} references resolved, plus the Doppler-provided secrets
+func (p *Project) computedSecrets(config *Config) map[string]string {
+	secrets := map[string]string{}
+	for name, value := range config.Secrets {
+		secrets[name] = os.Expand(value, func(ref string) string {
+			if v, ok := config.Secrets[ref]; ok {
+				return v
+			}
+			return "${" + ref + "}"
+		})
+	}
+	secrets["DOPPLER_PROJECT"] = p.Name
+	secrets["DOPPLER_ENVIRONMENT"] = config.Environment
+	secrets["DOPPLER_CONFIG"] = config.Name
+	return secrets
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+func randomToken(prefix string) string {
+	buffer := make([]byte, 22)
+	rand.Read(buffer) // #nosec G104
+	return prefix + hex.EncodeToString(buffer)
+}
+
+func randomSlug() string {
+	buffer := make([]byte, 8)
+	rand.Read(buffer) // #nosec G104
+	return hex.EncodeToString(buffer)
+}
+
+// validName returns whether the name is suitable for use as a project or config name
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// validConfigName returns whether the name is valid for a config in the specified environment
+func validConfigName(name string, environment string) bool {
+	return validName(name) && (name == environment || strings.HasPrefix(name, environment+"_"))
+}
+
+func exampleData() Data {
+	createdAt := now()
+	project := &Project{Name: "example", Description: "Example project", CreatedAt: createdAt}
+	for _, environment := range []struct{ slug, name string }{{"dev", "Development"}, {"stg", "Staging"}, {"prd", "Production"}} {
+		project.Environments = append(project.Environments, &Environment{Slug: environment.slug, Name: environment.name, CreatedAt: createdAt})
+		project.Configs = append(project.Configs, &Config{
+			Name:        environment.slug,
+			Environment: environment.slug,
+			CreatedAt:   createdAt,
+			Secrets: map[string]string{
+				"API_URL":   fmt.Sprintf("https://%s.example.com", environment.slug),
+				"LOG_LEVEL": "info",
+				"API_KEY":   randomSlug(),
+			},
+		})
+	}
+
+	return Data{
+		Workplace: Workplace{ID: randomSlug(), Name: "Dev Server", BillingEmail: "dev@localhost"},
+		Tokens:    []*PersonalToken{{Name: "dev-server", Key: randomToken("dp.pt."), CreatedAt: createdAt}},
+		Projects:  []*Project{project},
+	}
+}
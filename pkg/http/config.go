@@ -25,3 +25,9 @@ var TimeoutDuration = 10 * time.Second
 
 // RequestAttempts how many request attempts are made before giving up
 var RequestAttempts = 5
+
+// MaxConnsPerHost the maximum number of connections opened to a single host. Zero means no limit
+var MaxConnsPerHost = 8
+
+// IdleConnTimeout how long an idle connection is kept open for reuse
+var IdleConnTimeout = 30 * time.Second
@@ -22,15 +22,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/DopplerHQ/cli/pkg/telemetry"
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/DopplerHQ/cli/pkg/version"
 )
@@ -45,12 +49,33 @@ type errorResponse struct {
 	Success  bool
 }
 
+// ResponseError a failed response from the API
+type ResponseError struct {
+	Err        error
+	StatusCode int
+	RequestID  string
+}
+
+func (e *ResponseError) Error() string { return e.Err.Error() }
+
+// Unwrap get the original error
+func (e *ResponseError) Unwrap() error { return e.Err }
+
+// HTTPStatus the response's HTTP status code
+func (e *ResponseError) HTTPStatus() int { return e.StatusCode }
+
+// HTTPRequestID the response's request ID, if any
+func (e *ResponseError) HTTPRequestID() string { return e.RequestID }
+
 // DNS resolver
 var UseCustomDNSResolver = false
 var DNSResolverAddress = "1.1.1.1:53"
 var DNSResolverProto = "udp"
 var DNSResolverTimeout = time.Duration(5) * time.Second
 
+var transportsMutex sync.Mutex
+var transports = map[bool]*http.Transport{}
+
 func generateURL(host string, uri string, params []queryParam) (*url.URL, error) {
 	host = strings.TrimSuffix(host, "/")
 	if !strings.HasPrefix(uri, "/") {
@@ -158,74 +183,42 @@ func request(req *http.Request, verifyTLS bool, allowTimeout bool, allowRetry bo
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	// close the connection after reading the response, to help prevent socket exhaustion
-	req.Close = true
-
-	client := &http.Client{}
+	client := &http.Client{
+		Transport: wrapTransport(getTransport(verifyTLS)),
+	}
 	// set http timeout
 	if allowTimeout && UseTimeout {
 		client.Timeout = TimeoutDuration
 	}
 
-	// set TLS config
-	tlsConfig := &tls.Config{
-		MinVersion: tls.VersionTLS12,
-	}
-	// #nosec G402
-	if !verifyTLS {
-		tlsConfig.InsecureSkipVerify = true
-	}
+	utils.LogDebugWithFields(fmt.Sprintf("Performing HTTP %s to %s", req.Method, req.URL), utils.LogFields{"method": req.Method, "url": req.URL.String()})
 
-	// use custom DNS resolver
-	dialer := &net.Dialer{}
-	if UseCustomDNSResolver {
-		utils.LogDebug(fmt.Sprintf("Using custom DNS resolver %s", DNSResolverAddress))
-
-		dialer = &net.Dialer{
-			Resolver: &net.Resolver{
-				PreferGo: true,
-				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-					d := net.Dialer{
-						Timeout: DNSResolverTimeout,
-					}
-					return d.DialContext(ctx, DNSResolverProto, DNSResolverAddress)
-				},
-			},
-		}
-	}
-	dialContext := func(ctx context.Context, network, addr string) (net.Conn, error) {
-		return dialer.DialContext(ctx, network, addr)
-	}
-
-	proxyUrl, err := http.ProxyFromEnvironment(req)
-	if err != nil {
-		utils.LogDebug("Unable to read proxy from environment")
-		utils.LogDebugError(err)
-		proxyUrl = nil
-	}
-	if proxyUrl != nil {
-		utils.LogDebug(fmt.Sprintf("Using proxy %s", proxyUrl))
-	}
-
-	client.Transport = &http.Transport{
-		// disable keep alives to prevent multiple CLI instances from exhausting the
-		// OS's available network sockets. this adds a negligible performance penalty
-		DisableKeepAlives: true,
-		TLSClientConfig:   tlsConfig,
-		DialContext:       dialContext,
-		Proxy:             http.ProxyURL(proxyUrl),
-	}
-
-	utils.LogDebug(fmt.Sprintf("Performing HTTP %s to %s", req.Method, req.URL))
+	span := telemetry.StartClientSpan("http.request", map[string]interface{}{
+		"http.request.method": req.Method,
+		"url.path":            req.URL.Path,
+		"server.address":      req.URL.Hostname(),
+	})
+	defer span.End()
 
 	startTime := time.Now()
 	var response *http.Response
 	response = nil
+	attempt := 0
+
+	err := utils.Retry(RequestAttempts, 500*time.Millisecond, func() error {
+		attempt++
+		if response != nil {
+			// release the previous attempt's connection so it can be reused
+			drainAndClose(response.Body)
+			response = nil
+		}
+		attemptSpan := span.StartChild("http.attempt", map[string]interface{}{"http.request.resend_count": attempt - 1})
+		defer attemptSpan.End()
 
-	err = utils.Retry(RequestAttempts, 500*time.Millisecond, func() error {
 		// disable semgrep rule b/c we properly check that resp isn't nil before using it within the err block
-		resp, err := client.Do(req) // nosemgrep: trailofbits.go.invalid-usage-of-modified-variable.invalid-usage-of-modified-variable
+		resp, err := client.Do(withTrace(req, attemptSpan)) // nosemgrep: trailofbits.go.invalid-usage-of-modified-variable.invalid-usage-of-modified-variable
 		if err != nil {
+			attemptSpan.RecordError(err)
 			if resp != nil {
 				defer func() {
 					if closeErr := resp.Body.Close(); closeErr != nil {
@@ -234,7 +227,7 @@ func request(req *http.Request, verifyTLS bool, allowTimeout bool, allowRetry bo
 				}()
 			}
 
-			utils.LogDebug(err.Error())
+			utils.LogDebugWithFields(err.Error(), utils.LogFields{"attempt": attempt})
 
 			if allowRetry && (isTimeout(err) || errors.Is(err, syscall.ECONNREFUSED)) {
 				// retry request
@@ -245,9 +238,11 @@ func request(req *http.Request, verifyTLS bool, allowTimeout bool, allowRetry bo
 		}
 
 		response = resp
+		attemptSpan.SetAttribute("http.response.status_code", resp.StatusCode)
 
 		if requestID := resp.Header.Get("x-request-id"); requestID != "" {
-			utils.LogDebug(fmt.Sprintf("Request ID %s", requestID))
+			utils.LogDebugWithFields(fmt.Sprintf("Request ID %s", requestID), utils.LogFields{"request_id": requestID, "status_code": resp.StatusCode, "attempt": attempt})
+			attemptSpan.SetAttribute("doppler.request_id", requestID)
 		}
 
 		if isSuccess(resp.StatusCode) {
@@ -259,7 +254,7 @@ func request(req *http.Request, verifyTLS bool, allowTimeout bool, allowRetry bo
 			// start logging retries after 10 seconds so it doesn't feel like we've frozen
 			// we subtract 1 millisecond so that we always win the race against a request that exhausts its full 10 second time out
 			if time.Now().After(startTime.Add(10 * time.Second).Add(-1 * time.Millisecond)) {
-				utils.Log(fmt.Sprintf("Request failed with HTTP %d, retrying", resp.StatusCode))
+				utils.LogWithFields(fmt.Sprintf("Request failed with HTTP %d, retrying", resp.StatusCode), utils.LogFields{"request_id": resp.Header.Get("x-request-id"), "status_code": resp.StatusCode, "attempt": attempt})
 			}
 			return errors.New("Request failed")
 		}
@@ -268,18 +263,126 @@ func request(req *http.Request, verifyTLS bool, allowTimeout bool, allowRetry bo
 		return utils.StopRetryError(errors.New("Request failed"))
 	})
 
+	span.SetAttribute("http.request.attempts", attempt)
+	if response != nil {
+		span.SetAttribute("http.response.status_code", response.StatusCode)
+	}
+	span.RecordError(err)
+
 	return response, err
 }
 
+// getTransport returns the transport shared by all requests with the specified TLS verification. Connections are kept
+// alive and reused across requests for the lifetime of the process, and the number of connections per host is capped
+// to prevent multiple CLI instances from exhausting the OS's available network sockets.
+func getTransport(verifyTLS bool) *http.Transport {
+	transportsMutex.Lock()
+	defer transportsMutex.Unlock()
+
+	if transport, ok := transports[verifyTLS]; ok {
+		return transport
+	}
+
+	// set TLS config
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	// #nosec G402
+	if !verifyTLS {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	// use custom DNS resolver
+	dialer := &net.Dialer{}
+	if UseCustomDNSResolver {
+		utils.LogDebug(fmt.Sprintf("Using custom DNS resolver %s", DNSResolverAddress))
+
+		dialer = &net.Dialer{
+			Resolver: &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					d := net.Dialer{
+						Timeout: DNSResolverTimeout,
+					}
+					return d.DialContext(ctx, DNSResolverProto, DNSResolverAddress)
+				},
+			},
+		}
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		DialContext:     dialer.DialContext,
+		Proxy:           proxyFromEnvironment,
+		// a custom dialer and TLS config disable HTTP/2 unless explicitly requested
+		ForceAttemptHTTP2:   true,
+		MaxConnsPerHost:     MaxConnsPerHost,
+		MaxIdleConnsPerHost: MaxConnsPerHost,
+		IdleConnTimeout:     IdleConnTimeout,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	transports[verifyTLS] = transport
+	return transport
+}
+
+func proxyFromEnvironment(req *http.Request) (*url.URL, error) {
+	proxyUrl, err := http.ProxyFromEnvironment(req)
+	if err != nil {
+		utils.LogDebug("Unable to read proxy from environment")
+		utils.LogDebugError(err)
+		return nil, nil
+	}
+	if proxyUrl != nil {
+		utils.LogDebug(fmt.Sprintf("Using proxy %s", proxyUrl))
+	}
+	return proxyUrl, nil
+}
+
+// drainAndClose reads the remainder of the body so the underlying connection can be reused
+func drainAndClose(body io.ReadCloser) {
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		utils.LogDebug(err.Error())
+	}
+	if err := body.Close(); err != nil {
+		utils.LogDebug(err.Error())
+	}
+}
+
+// withTrace records DNS, connect, and TLS timings on the span
+func withTrace(req *http.Request, span *telemetry.Span) *http.Request {
+	if span == nil {
+		return req
+	}
+
+	var dnsStart, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			span.SetAttribute("http.dns.duration_ms", time.Since(dnsStart))
+		},
+		ConnectStart: func(string, string) { connectStart = time.Now() },
+		ConnectDone: func(string, string, error) {
+			span.SetAttribute("http.connect.duration_ms", time.Since(connectStart))
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			span.SetAttribute("http.tls.duration_ms", time.Since(tlsStart))
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			span.SetAttribute("http.connection.reused", info.Reused)
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
 func performSSERequest(req *http.Request, verifyTLS bool, handler func([]byte)) (int, http.Header, error) {
 	// nosemgrep: trailofbits.go.invalid-usage-of-modified-variable.invalid-usage-of-modified-variable
 	response, requestErr := request(req, verifyTLS, false, false)
 	if requestErr != nil {
-		statusCode := 0
 		if response != nil {
-			statusCode = response.StatusCode
+			return response.StatusCode, nil, newResponseError(response, requestErr)
 		}
-		return statusCode, nil, requestErr
+		return 0, nil, requestErr
 	}
 
 	if response != nil {
@@ -343,13 +446,17 @@ func performRequest(req *http.Request, verifyTLS bool) (int, http.Header, []byte
 		err = json.Unmarshal(body, &errResponse)
 		if err != nil {
 			utils.LogDebug(fmt.Sprintf("Unable to parse response body: \n%s", string(body)))
-			return response.StatusCode, headers, nil, err
+			return response.StatusCode, headers, nil, newResponseError(response, err)
 		}
 
-		return response.StatusCode, headers, body, errors.New(strings.Join(errResponse.Messages, "\n"))
+		return response.StatusCode, headers, body, newResponseError(response, errors.New(strings.Join(errResponse.Messages, "\n")))
 	}
 
-	return response.StatusCode, headers, nil, fmt.Errorf("Request failed with HTTP %d", response.StatusCode)
+	return response.StatusCode, headers, nil, newResponseError(response, fmt.Errorf("Request failed with HTTP %d", response.StatusCode))
+}
+
+func newResponseError(response *http.Response, err error) *ResponseError {
+	return &ResponseError{Err: err, StatusCode: response.StatusCode, RequestID: response.Header.Get("x-request-id")}
 }
 
 func isSuccess(statusCode int) bool {
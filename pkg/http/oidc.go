@@ -0,0 +1,54 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// GetGitHubActionsIDToken request an OIDC token from GitHub Actions' ID token endpoint
+func GetGitHubActionsIDToken(requestURL string, requestToken string, audience string) (string, Error) {
+	url, err := url.Parse(requestURL)
+	if err != nil {
+		return "", Error{Err: err, Message: "Unable to parse GitHub Actions ID token request url"}
+	}
+	if audience != "" {
+		query := url.Query()
+		query.Set("audience", audience)
+		url.RawQuery = query.Encode()
+	}
+
+	headers := map[string]string{"Authorization": fmt.Sprintf("bearer %s", requestToken)}
+	statusCode, _, response, err := GetRequest(url, true, headers)
+	if err != nil {
+		return "", Error{Err: err, Message: "Unable to request OIDC token from GitHub Actions", Code: statusCode}
+	}
+
+	var result struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(response, &result); err != nil {
+		return "", Error{Err: err, Message: "Unable to parse GitHub Actions ID token response", Code: statusCode}
+	}
+	if result.Value == "" {
+		return "", Error{Err: errors.New("response did not include a token"), Message: "Unable to parse GitHub Actions ID token response", Code: statusCode}
+	}
+
+	return result.Value, Error{}
+}
@@ -0,0 +1,300 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// RecordDir when set, request/response pairs are captured to fixture files in this directory
+var RecordDir = ""
+
+// ReplayDir when set, responses are served from fixture files in this directory without accessing the network
+var ReplayDir = ""
+
+// headers that are never written to fixtures
+var scrubbedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// JWTs, such as OIDC identity tokens, which consist of base64url-encoded JSON header and payload segments
+var jwtRegex = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+
+// headers that vary between runs and are excluded from fixtures
+var volatileHeaders = []string{"Client-Version", "Client-Os", "Client-Arch", "User-Agent", "Date"}
+
+// Fixture a recorded request/response pair
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+// FixtureRequest the recorded request
+type FixtureRequest struct {
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    string              `json:"body,omitempty"`
+}
+
+// FixtureResponse the recorded response
+type FixtureResponse struct {
+	StatusCode   int                 `json:"status_code"`
+	Headers      map[string][]string `json:"headers,omitempty"`
+	Body         string              `json:"body,omitempty"`
+	BodyEncoding string              `json:"body_encoding,omitempty"`
+}
+
+// recorder implements http.RoundTripper for record and replay modes
+type recorder struct {
+	dir       string
+	replay    bool
+	transport http.RoundTripper
+}
+
+var fixtureCountsMutex sync.Mutex
+var fixtureCounts = map[string]int{}
+
+// wrapTransport returns a transport that records or replays requests, if either mode is enabled
+func wrapTransport(transport http.RoundTripper) http.RoundTripper {
+	if ReplayDir != "" {
+		return &recorder{dir: ReplayDir, replay: true}
+	}
+	if RecordDir != "" {
+		return &recorder{dir: RecordDir, transport: transport}
+	}
+	return transport
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+
+	fixtureRequest := scrubRequest(req, requestBody)
+	path := filepath.Join(r.dir, fixtureName(fixtureRequest))
+
+	if r.replay {
+		return replayFixture(req, path, fixtureRequest)
+	}
+
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = io.NopCloser(bytes.NewReader(requestBody))
+	outgoing.ContentLength = int64(len(requestBody))
+
+	resp, err := r.transport.RoundTrip(outgoing)
+	if err != nil {
+		return resp, err
+	}
+
+	// the fixture is written once the body has been fully read, so long-lived streams are captured as they were consumed
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		onDone: func(body []byte) {
+			fixture := Fixture{Request: fixtureRequest, Response: scrubResponse(resp, body)}
+			if err := writeFixture(path, fixture); err != nil {
+				utils.LogDebug(fmt.Sprintf("Unable to write HTTP fixture %s", path))
+				utils.LogDebugError(err)
+			} else {
+				utils.LogDebug(fmt.Sprintf("Recorded HTTP fixture %s", path))
+			}
+		},
+	}
+
+	return resp, nil
+}
+
+func replayFixture(req *http.Request, path string, fixtureRequest FixtureRequest) (*http.Response, error) {
+	// #nosec G304
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("No recorded response for %s %s (expected fixture %s)", fixtureRequest.Method, fixtureRequest.URL, path)
+		}
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(bytes, &fixture); err != nil {
+		return nil, fmt.Errorf("Unable to parse HTTP fixture %s: %w", path, err)
+	}
+	utils.LogDebug(fmt.Sprintf("Replaying HTTP fixture %s", path))
+
+	body := []byte(fixture.Response.Body)
+	if fixture.Response.BodyEncoding == "base64" {
+		if body, err = base64.StdEncoding.DecodeString(fixture.Response.Body); err != nil {
+			return nil, fmt.Errorf("Unable to decode HTTP fixture %s: %w", path, err)
+		}
+	}
+
+	headers := http.Header{}
+	for key, values := range fixture.Response.Headers {
+		for _, value := range values {
+			headers.Add(key, value)
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Response.StatusCode, http.StatusText(fixture.Response.StatusCode)),
+		StatusCode:    fixture.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        headers,
+		Body:          io.NopCloser(strings.NewReader(string(body))),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// fixtureName returns a deterministic name for the request. Identical requests made within
+// the same invocation (e.g. retries) are numbered sequentially.
+func fixtureName(request FixtureRequest) string {
+	keyBytes, err := json.Marshal(request)
+	if err != nil {
+		// FixtureRequest only contains strings, so this can't happen
+		panic(err)
+	}
+	hash := sha256.Sum256(keyBytes)
+	key := hex.EncodeToString(hash[:])[:16]
+
+	fixtureCountsMutex.Lock()
+	n := fixtureCounts[key]
+	fixtureCounts[key] = n + 1
+	fixtureCountsMutex.Unlock()
+
+	return fmt.Sprintf("%s-%d.json", key, n)
+}
+
+func writeFixture(path string, fixture Fixture) error {
+	bytes, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return utils.WriteFile(path, bytes, utils.RestrictedFilePerms())
+}
+
+func scrubRequest(req *http.Request, body []byte) FixtureRequest {
+	// sort query params so fixture names don't depend on param ordering
+	u := *req.URL
+	query := u.Query()
+	keys := []string{}
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			params = append(params, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	u.RawQuery = ""
+	url := u.String()
+	if len(params) > 0 {
+		url = fmt.Sprintf("%s?%s", url, strings.Join(params, "&"))
+	}
+
+	return FixtureRequest{
+		Method:  req.Method,
+		URL:     scrubValue(url),
+		Headers: scrubHeaders(req.Header),
+		Body:    scrubValue(string(body)),
+	}
+}
+
+func scrubResponse(resp *http.Response, body []byte) FixtureResponse {
+	response := FixtureResponse{
+		StatusCode: resp.StatusCode,
+		Headers:    scrubHeaders(resp.Header),
+	}
+	if utf8.Valid(body) {
+		response.Body = scrubValue(string(body))
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(body)
+		response.BodyEncoding = "base64"
+	}
+	return response
+}
+
+// scrubValue redacts Doppler auth tokens and JWTs
+func scrubValue(value string) string {
+	return jwtRegex.ReplaceAllStringFunc(utils.RedactAuthTokens(value), utils.RedactAuthToken)
+}
+
+func scrubHeaders(headers http.Header) map[string][]string {
+	scrubbed := map[string][]string{}
+	for key, values := range headers {
+		key = http.CanonicalHeaderKey(key)
+		if utils.Contains(scrubbedHeaders, key) || utils.Contains(volatileHeaders, key) {
+			continue
+		}
+		for _, value := range values {
+			scrubbed[key] = append(scrubbed[key], scrubValue(value))
+		}
+	}
+	if len(scrubbed) == 0 {
+		return nil
+	}
+	return scrubbed
+}
+
+// recordingBody buffers the response body as it is read and invokes onDone once it has been fully consumed or closed
+type recordingBody struct {
+	io.ReadCloser
+	buffer bytes.Buffer
+	once   sync.Once
+	onDone func([]byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buffer.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		b.once.Do(func() { b.onDone(b.buffer.Bytes()) })
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.once.Do(func() { b.onDone(b.buffer.Bytes()) })
+	return b.ReadCloser.Close()
+}
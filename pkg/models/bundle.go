@@ -0,0 +1,54 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package models
+
+import "time"
+
+// BundleVersion the current version of the bundle format
+const BundleVersion = "1"
+
+// BundleMetadata describes an offline secrets bundle
+type BundleMetadata struct {
+	Version   string     `json:"version"`
+	Project   string     `json:"project"`
+	Config    string     `json:"config"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// the public keys the bundle is encrypted to
+	Recipients []string `json:"recipients"`
+}
+
+// Bundle the decrypted contents of an offline secrets bundle
+type Bundle struct {
+	BundleMetadata
+	Secrets map[string]string `json:"secrets"`
+}
+
+// IsExpired whether the bundle has passed its expiration time
+func (m BundleMetadata) IsExpired(now time.Time) bool {
+	return m.ExpiresAt != nil && now.After(*m.ExpiresAt)
+}
+
+// BundleInfo describes a bundle on disk
+type BundleInfo struct {
+	BundleMetadata
+	Path    string `json:"path"`
+	Expired bool   `json:"expired"`
+	// whether the bundle was decrypted and its contents verified
+	Verified bool `json:"verified"`
+	// the names of the secrets in the bundle, only populated when it was decrypted
+	SecretNames []string `json:"secret_names,omitempty"`
+}
@@ -17,25 +17,76 @@ package models
 
 import (
 	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
 )
 
 // ConfigFile structure of the config file
 type ConfigFile struct {
-	Scoped       map[string]FileScopedOptions `yaml:"scoped"`
-	VersionCheck VersionCheck                 `yaml:"version-check"`
-	Analytics    AnalyticsOptions             `yaml:"analytics,omitempty"`
-	TUI          TUIOptions                   `yaml:"tui"`
-	Flags        Flags                        `yaml:"flags,omitempty"`
+	Scoped        map[string]FileScopedOptions `yaml:"scoped"`
+	Profiles      map[string]ProfileOptions    `yaml:"profiles,omitempty"`
+	VersionCheck  VersionCheck                 `yaml:"version-check,omitempty"`
+	Analytics     AnalyticsOptions             `yaml:"analytics,omitempty"`
+	TUI           TUIOptions                   `yaml:"tui,omitempty"`
+	Flags         Flags                        `yaml:"flags,omitempty"`
+	SecureStorage *bool                        `yaml:"secure-storage,omitempty"`
+	OIDCSessions  map[string]OIDCSession       `yaml:"oidc-sessions,omitempty"`
+}
+
+// token storage locations
+const (
+	TokenStorageKeyring   = "keyring"
+	TokenStorageEncrypted = "encrypted file"
+	TokenStoragePlaintext = "plaintext"
+)
+
+// TokenStorage how a token in the config file is stored
+type TokenStorage struct {
+	Scope   string `json:"scope,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Storage string `json:"storage"`
+}
+
+// SecureStorageStatus the status of secure token storage
+type SecureStorageStatus struct {
+	Enabled          bool           `json:"enabled"`
+	KeyringAvailable bool           `json:"keyring_available"`
+	Tokens           []TokenStorage `json:"tokens"`
+}
+
+// how to resolve conflicts when importing config
+const (
+	ImportConflictPrompt    = "prompt"
+	ImportConflictKeep      = "keep"
+	ImportConflictOverwrite = "overwrite"
+)
+
+var ImportConflictActions = []string{ImportConflictPrompt, ImportConflictKeep, ImportConflictOverwrite}
+
+// IsValidImportConflictAction whether the action is valid
+func IsValidImportConflictAction(action string) bool {
+	return utils.Contains(ImportConflictActions, action)
+}
+
+// ProfileOptions the options stored in a named profile
+type ProfileOptions struct {
+	Token         string `json:"token,omitempty" yaml:"token,omitempty"`
+	APIHost       string `json:"api-host,omitempty" yaml:"api-host,omitempty"`
+	DashboardHost string `json:"dashboard-host,omitempty" yaml:"dashboard-host,omitempty"`
+	VerifyTLS     string `json:"verify-tls,omitempty" yaml:"verify-tls,omitempty"`
 }
 
 // FileScopedOptions config options
 type FileScopedOptions struct {
-	Token          string `json:"token,omitempty" yaml:"token,omitempty"`
-	APIHost        string `json:"api-host,omitempty" yaml:"api-host,omitempty"`
-	DashboardHost  string `json:"dashboard-host,omitempty" yaml:"dashboard-host,omitempty"`
-	VerifyTLS      string `json:"verify-tls,omitempty" yaml:"verify-tls,omitempty"`
-	EnclaveProject string `json:"enclave.project,omitempty" yaml:"enclave.project,omitempty"`
-	EnclaveConfig  string `json:"enclave.config,omitempty" yaml:"enclave.config,omitempty"`
+	Token           string `json:"token,omitempty" yaml:"token,omitempty"`
+	APIHost         string `json:"api-host,omitempty" yaml:"api-host,omitempty"`
+	DashboardHost   string `json:"dashboard-host,omitempty" yaml:"dashboard-host,omitempty"`
+	VerifyTLS       string `json:"verify-tls,omitempty" yaml:"verify-tls,omitempty"`
+	EnclaveProject  string `json:"enclave.project,omitempty" yaml:"enclave.project,omitempty"`
+	EnclaveConfig   string `json:"enclave.config,omitempty" yaml:"enclave.config,omitempty"`
+	FallbackKDF     string `json:"fallback-kdf,omitempty" yaml:"fallback-kdf,omitempty"`
+	FallbackKeyring string `json:"fallback-keyring,omitempty" yaml:"fallback-keyring,omitempty"`
+	Profile         string `json:"profile,omitempty" yaml:"profile,omitempty"`
 }
 
 // VersionCheck info about the last check for the latest cli version
@@ -56,12 +107,15 @@ type TUIOptions struct {
 
 // ScopedOptions options with their scope
 type ScopedOptions struct {
-	Token          ScopedOption `json:"token,omitempty" yaml:"token,omitempty"`
-	APIHost        ScopedOption `json:"api-host,omitempty" yaml:"api-host,omitempty"`
-	DashboardHost  ScopedOption `json:"dashboard-host,omitempty" yaml:"dashboard-host,omitempty"`
-	VerifyTLS      ScopedOption `json:"verify-tls,omitempty" yaml:"verify-tls,omitempty"`
-	EnclaveProject ScopedOption `json:"enclave.project,omitempty" yaml:"enclave.project,omitempty"`
-	EnclaveConfig  ScopedOption `json:"enclave.config,omitempty" yaml:"enclave.config,omitempty"`
+	Token           ScopedOption `json:"token,omitempty" yaml:"token,omitempty"`
+	APIHost         ScopedOption `json:"api-host,omitempty" yaml:"api-host,omitempty"`
+	DashboardHost   ScopedOption `json:"dashboard-host,omitempty" yaml:"dashboard-host,omitempty"`
+	VerifyTLS       ScopedOption `json:"verify-tls,omitempty" yaml:"verify-tls,omitempty"`
+	EnclaveProject  ScopedOption `json:"enclave.project,omitempty" yaml:"enclave.project,omitempty"`
+	EnclaveConfig   ScopedOption `json:"enclave.config,omitempty" yaml:"enclave.config,omitempty"`
+	FallbackKDF     ScopedOption `json:"fallback-kdf,omitempty" yaml:"fallback-kdf,omitempty"`
+	FallbackKeyring ScopedOption `json:"fallback-keyring,omitempty" yaml:"fallback-keyring,omitempty"`
+	Profile         ScopedOption `json:"profile,omitempty" yaml:"profile,omitempty"`
 }
 
 // ScopedOption value and its scope
@@ -69,6 +123,8 @@ type ScopedOption struct {
 	Value  string `json:"value"`
 	Scope  string `json:"scope"`
 	Source string `json:"source"`
+	// the glob scope that matched, if any
+	Rule string `json:"rule,omitempty"`
 }
 
 type source int
@@ -79,10 +135,11 @@ const (
 	ConfigFileSource
 	EnvironmentSource
 	DefaultValueSource
+	ProfileSource
 )
 
 func (s source) String() string {
-	return [...]string{"Flag", "Config File", "Environment", "Default Value"}[s]
+	return [...]string{"Flag", "Config File", "Environment", "Default Value", "Profile"}[s]
 }
 
 var allConfigOptions = []string{
@@ -92,6 +149,9 @@ var allConfigOptions = []string{
 	"verify-tls",
 	"enclave.project",
 	"enclave.config",
+	"fallback-kdf",
+	"fallback-keyring",
+	"profile",
 }
 
 type configOption int
@@ -104,6 +164,9 @@ const (
 	ConfigVerifyTLS
 	ConfigEnclaveProject
 	ConfigEnclaveConfig
+	ConfigFallbackKDF
+	ConfigFallbackKeyring
+	ConfigProfile
 )
 
 func (s configOption) String() string {
@@ -118,49 +181,71 @@ func AllConfigOptions() []string {
 // OptionsMap get the options for the given config
 func OptionsMap(conf FileScopedOptions) map[string]string {
 	return map[string]string{
-		ConfigToken.String():          conf.Token,
-		ConfigAPIHost.String():        conf.APIHost,
-		ConfigDashboardHost.String():  conf.DashboardHost,
-		ConfigVerifyTLS.String():      conf.VerifyTLS,
-		ConfigEnclaveProject.String(): conf.EnclaveProject,
-		ConfigEnclaveConfig.String():  conf.EnclaveConfig,
+		ConfigToken.String():           conf.Token,
+		ConfigAPIHost.String():         conf.APIHost,
+		ConfigDashboardHost.String():   conf.DashboardHost,
+		ConfigVerifyTLS.String():       conf.VerifyTLS,
+		ConfigEnclaveProject.String():  conf.EnclaveProject,
+		ConfigEnclaveConfig.String():   conf.EnclaveConfig,
+		ConfigFallbackKDF.String():     conf.FallbackKDF,
+		ConfigFallbackKeyring.String(): conf.FallbackKeyring,
+		ConfigProfile.String():         conf.Profile,
 	}
 }
 
 // ScopedOptionsMap get the options for the given scoped config
 func ScopedOptionsMap(conf *ScopedOptions) map[string]*ScopedOption {
 	return map[string]*ScopedOption{
-		ConfigToken.String():          &conf.Token,
-		ConfigAPIHost.String():        &conf.APIHost,
-		ConfigDashboardHost.String():  &conf.DashboardHost,
-		ConfigVerifyTLS.String():      &conf.VerifyTLS,
-		ConfigEnclaveProject.String(): &conf.EnclaveProject,
-		ConfigEnclaveConfig.String():  &conf.EnclaveConfig,
+		ConfigToken.String():           &conf.Token,
+		ConfigAPIHost.String():         &conf.APIHost,
+		ConfigDashboardHost.String():   &conf.DashboardHost,
+		ConfigVerifyTLS.String():       &conf.VerifyTLS,
+		ConfigEnclaveProject.String():  &conf.EnclaveProject,
+		ConfigEnclaveConfig.String():   &conf.EnclaveConfig,
+		ConfigFallbackKDF.String():     &conf.FallbackKDF,
+		ConfigFallbackKeyring.String(): &conf.FallbackKeyring,
+		ConfigProfile.String():         &conf.Profile,
 	}
 }
 
 // ScopedOptions get the options for the given scoped config
 func ScopedOptionsStringMap(conf *ScopedOptions) map[string]string {
 	return map[string]string{
-		ConfigToken.String():          conf.Token.Value,
-		ConfigAPIHost.String():        conf.APIHost.Value,
-		ConfigDashboardHost.String():  conf.DashboardHost.Value,
-		ConfigVerifyTLS.String():      conf.VerifyTLS.Value,
-		ConfigEnclaveProject.String(): conf.EnclaveProject.Value,
-		ConfigEnclaveConfig.String():  conf.EnclaveConfig.Value,
+		ConfigToken.String():           conf.Token.Value,
+		ConfigAPIHost.String():         conf.APIHost.Value,
+		ConfigDashboardHost.String():   conf.DashboardHost.Value,
+		ConfigVerifyTLS.String():       conf.VerifyTLS.Value,
+		ConfigEnclaveProject.String():  conf.EnclaveProject.Value,
+		ConfigEnclaveConfig.String():   conf.EnclaveConfig.Value,
+		ConfigFallbackKDF.String():     conf.FallbackKDF.Value,
+		ConfigFallbackKeyring.String(): conf.FallbackKeyring.Value,
+		ConfigProfile.String():         conf.Profile.Value,
 	}
 }
 
 // EnvOptions get the scoped config options for each environment variable
 func EnvOptions(conf *ScopedOptions) map[string]*ScopedOption {
 	return map[string]*ScopedOption{
-		"DOPPLER_TOKEN":          &conf.Token,
-		"DOPPLER_API_HOST":       &conf.APIHost,
-		"DOPPLER_DASHBOARD_HOST": &conf.DashboardHost,
-		"DOPPLER_VERIFY_TLS":     &conf.VerifyTLS,
-		"DOPPLER_PROJECT":        &conf.EnclaveProject,
-		"DOPPLER_CONFIG":         &conf.EnclaveConfig,
-		"ENCLAVE_PROJECT":        &conf.EnclaveProject, // deprecated, remove in v4
-		"ENCLAVE_CONFIG":         &conf.EnclaveConfig,  // deprecated, remove in v4
+		"DOPPLER_TOKEN":            &conf.Token,
+		"DOPPLER_API_HOST":         &conf.APIHost,
+		"DOPPLER_DASHBOARD_HOST":   &conf.DashboardHost,
+		"DOPPLER_VERIFY_TLS":       &conf.VerifyTLS,
+		"DOPPLER_PROJECT":          &conf.EnclaveProject,
+		"DOPPLER_CONFIG":           &conf.EnclaveConfig,
+		"DOPPLER_FALLBACK_KDF":     &conf.FallbackKDF,
+		"DOPPLER_FALLBACK_KEYRING": &conf.FallbackKeyring,
+		"DOPPLER_PROFILE":          &conf.Profile,
+		"ENCLAVE_PROJECT":          &conf.EnclaveProject, // deprecated, remove in v4
+		"ENCLAVE_CONFIG":           &conf.EnclaveConfig,  // deprecated, remove in v4
+	}
+}
+
+// ProfileOptionsMap get the options for the given profile
+func ProfileOptionsMap(profile ProfileOptions) map[string]string {
+	return map[string]string{
+		ConfigToken.String():         profile.Token,
+		ConfigAPIHost.String():       profile.APIHost,
+		ConfigDashboardHost.String(): profile.DashboardHost,
+		ConfigVerifyTLS.String():     profile.VerifyTLS,
 	}
 }
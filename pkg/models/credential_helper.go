@@ -0,0 +1,81 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package models
+
+import "github.com/DopplerHQ/cli/pkg/utils"
+
+// protocols supported by 'doppler credential-helper'
+const (
+	CredentialHelperGit       = "git"
+	CredentialHelperDocker    = "docker"
+	CredentialHelperAWS       = "aws"
+	CredentialHelperKubectl   = "kubectl"
+	CredentialHelperTerraform = "terraform"
+)
+
+var CredentialHelperProtocols = []string{CredentialHelperGit, CredentialHelperDocker, CredentialHelperAWS, CredentialHelperKubectl, CredentialHelperTerraform}
+
+// IsValidCredentialHelperProtocol whether the protocol is supported
+func IsValidCredentialHelperProtocol(protocol string) bool {
+	return utils.Contains(CredentialHelperProtocols, protocol)
+}
+
+// CredentialField a credential provided to a tool, and the secret it's read from unless mapped to a different secret
+type CredentialField struct {
+	Name          string
+	DefaultSecret string
+	Required      bool
+}
+
+// CredentialSecret the secret a credential is read from
+type CredentialSecret struct {
+	Name string
+	// whether the secret must exist. secrets are required when the field is required or the secret was explicitly mapped
+	Required bool
+}
+
+// DockerCredential the response to a docker credential helper's get action
+type DockerCredential struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// AWSCredential the output of an AWS credential_process
+type AWSCredential struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+}
+
+// KubectlExecCredential the output of a kubectl exec credential plugin
+type KubectlExecCredential struct {
+	APIVersion string                      `json:"apiVersion"`
+	Kind       string                      `json:"kind"`
+	Status     KubectlExecCredentialStatus `json:"status"`
+}
+
+type KubectlExecCredentialStatus struct {
+	Token                 string `json:"token,omitempty"`
+	ClientCertificateData string `json:"clientCertificateData,omitempty"`
+	ClientKeyData         string `json:"clientKeyData,omitempty"`
+}
+
+// TerraformCredential the response to a terraform credentials helper's get action
+type TerraformCredential struct {
+	Token string `json:"token"`
+}
@@ -27,21 +27,91 @@ import (
 const Pbkdf2Rounds = 500000
 const LegacyPbkdf2Rounds = 50000
 
+// Argon2id parameters used when encrypting, per the second recommended option of RFC 9106
+const Argon2idMemory = 64 * 1024 // KiB
+const Argon2idTime = 3
+const Argon2idThreads = 4
+
+// limits on the Argon2id parameters accepted when decrypting, to prevent excessive resource usage
+const maxArgon2idMemory = 4 * 1024 * 1024 // KiB
+const maxArgon2idTime = 100
+
+// key derivation functions
+const Pbkdf2KDF = "pbkdf2"
+const Argon2idKDF = "argon2id"
+
+// KDFs supported key derivation functions
+var KDFs = []string{Pbkdf2KDF, Argon2idKDF}
+
+// IsValidKDF whether the specified key derivation function is supported
+func IsValidKDF(kdf string) bool {
+	return utils.Contains(KDFs, kdf)
+}
+
 const Base64EncodingPrefix = "base64"
 const HexEncodingPrefix = "hex"
 
 type EncryptedFile struct {
 	Version    int
+	KDF        string
 	NumRounds  int
+	Argon2id   Argon2idParams
 	Encoding   string
 	Ciphertext string
 }
 
+type Argon2idParams struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+func (p Argon2idParams) String() string {
+	return fmt.Sprintf("%s,m=%d,t=%d,p=%d", Argon2idKDF, p.Memory, p.Time, p.Threads)
+}
+
+// ParseArgon2idParams parses params in the format `argon2id,m=65536,t=3,p=4`
+func ParseArgon2idParams(s string) (Argon2idParams, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 || parts[0] != Argon2idKDF {
+		return Argon2idParams{}, errors.New("Invalid format: unable to parse argon2id parameters")
+	}
+
+	values := map[string]uint64{}
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return Argon2idParams{}, errors.New("Invalid format: unable to parse argon2id parameters")
+		}
+		n, err := strconv.ParseUint(kv[1], 10, 32)
+		if err != nil {
+			return Argon2idParams{}, fmt.Errorf("Invalid format: unable to parse argon2id parameter '%s'", kv[0])
+		}
+		values[kv[0]] = n
+	}
+
+	m, t, p := values["m"], values["t"], values["p"]
+	if m < 8 || m > maxArgon2idMemory {
+		return Argon2idParams{}, fmt.Errorf("Invalid argon2id memory: %d", m)
+	}
+	if t < 1 || t > maxArgon2idTime {
+		return Argon2idParams{}, fmt.Errorf("Invalid argon2id time: %d", t)
+	}
+	if p < 1 || p > 255 {
+		return Argon2idParams{}, fmt.Errorf("Invalid argon2id parallelism: %d", p)
+	}
+
+	return Argon2idParams{Memory: uint32(m), Time: uint32(t), Threads: uint8(p)}, nil
+}
+
 type FileVersionOptions struct {
 	Version          int
 	HasVersionNumber bool
 	HasEncoding      bool
 	HasNumRounds     bool
+	HasKDFParams     bool
+	// whether the plaintext is a versioned payload envelope
+	HasPayload bool
 }
 
 var FileVersions = map[int]FileVersionOptions{
@@ -53,11 +123,25 @@ var FileVersions = map[int]FileVersionOptions{
 	3: {Version: 3, HasEncoding: true, HasNumRounds: true, HasVersionNumber: false},
 	// ciphertext, encoding, pbkdf2 rounds, and explicit version number
 	4: {Version: 4, HasEncoding: true, HasNumRounds: true, HasVersionNumber: true},
+	// ciphertext, encoding, argon2id parameters, and explicit version number
+	5: {Version: 5, HasEncoding: true, HasKDFParams: true, HasVersionNumber: true},
+	// same as version 4, but the plaintext is a versioned payload envelope
+	6: {Version: 6, HasEncoding: true, HasNumRounds: true, HasVersionNumber: true, HasPayload: true},
+	// same as version 5, but the plaintext is a versioned payload envelope
+	7: {Version: 7, HasEncoding: true, HasKDFParams: true, HasVersionNumber: true, HasPayload: true},
 }
 
 func FileVersion(ciphertext string) (int, error) {
 	cParts := strings.Split(ciphertext, ":")
 	if len(cParts) == 4 {
+		switch cParts[0] {
+		case "5":
+			return 5, nil
+		case "6":
+			return 6, nil
+		case "7":
+			return 7, nil
+		}
 		return 4, nil
 	}
 	if len(cParts) == 3 {
@@ -72,14 +156,35 @@ func FileVersion(ciphertext string) (int, error) {
 	return -1, errors.New("Invalid ciphertext")
 }
 
+// ParseEncryptedFile detects the ciphertext's file version and parses it accordingly
+func ParseEncryptedFile(ciphertext string) (EncryptedFile, error) {
+	versionN, err := FileVersion(ciphertext)
+	if err != nil {
+		return EncryptedFile{}, err
+	}
+
+	options, ok := FileVersions[versionN]
+	if !ok {
+		return EncryptedFile{}, fmt.Errorf("Invalid version number: %d", versionN)
+	}
+
+	return options.Parse(ciphertext)
+}
+
 func (options *FileVersionOptions) Parse(ciphertext string) (EncryptedFile, error) {
 	var version string
 	var encoding string
 	var numRounds string
+	var kdfParams string
 	var data string
 
 	cParts := strings.SplitN(ciphertext, ":", 4)
-	if options.Version == 4 {
+	if options.Version == 5 || options.Version == 7 {
+		version = cParts[0]
+		encoding = cParts[1]
+		kdfParams = cParts[2]
+		data = cParts[3]
+	} else if options.Version == 4 || options.Version == 6 {
 		version = cParts[0]
 		encoding = cParts[1]
 		numRounds = cParts[2]
@@ -102,6 +207,7 @@ func (options *FileVersionOptions) Parse(ciphertext string) (EncryptedFile, erro
 	versionSpecified := version != ""
 	encodingSpecified := encoding != ""
 	numRoundsSpecified := numRounds != ""
+	kdfParamsSpecified := kdfParams != ""
 
 	// check required values
 	if data == "" {
@@ -116,6 +222,9 @@ func (options *FileVersionOptions) Parse(ciphertext string) (EncryptedFile, erro
 	if options.HasNumRounds && !numRoundsSpecified {
 		return EncryptedFile{}, errors.New("Invalid format: number of derivation rounds is required")
 	}
+	if options.HasKDFParams && !kdfParamsSpecified {
+		return EncryptedFile{}, errors.New("Invalid format: key derivation parameters are required")
+	}
 
 	file := EncryptedFile{Version: options.Version, Ciphertext: data}
 	if options.HasVersionNumber && version != strconv.Itoa(options.Version) {
@@ -131,13 +240,22 @@ func (options *FileVersionOptions) Parse(ciphertext string) (EncryptedFile, erro
 		// TODO remove support for v1 when releasing CLI v4 (DPLR-435)
 		file.Encoding = HexEncodingPrefix
 	}
-	if options.HasNumRounds {
+	if options.HasKDFParams {
+		params, err := ParseArgon2idParams(kdfParams)
+		if err != nil {
+			return EncryptedFile{}, err
+		}
+		file.KDF = Argon2idKDF
+		file.Argon2id = params
+	} else if options.HasNumRounds {
 		n, err := strconv.ParseInt(numRounds, 10, 32)
 		if err != nil {
 			return EncryptedFile{}, errors.New("Unable to parse number of rounds")
 		}
+		file.KDF = Pbkdf2KDF
 		file.NumRounds = int(n)
 	} else {
+		file.KDF = Pbkdf2KDF
 		file.NumRounds = LegacyPbkdf2Rounds
 	}
 
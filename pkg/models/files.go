@@ -15,11 +15,19 @@ limitations under the License.
 */
 package models
 
+import (
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
 // SecretsFileMetadata contains metadata about a secrets file
 type SecretsFileMetadata struct {
 	Version string `json:"version,omitempty" yaml:"version,omitempty"`
 	ETag    string `json:"etag,omitempty" yaml:"etag,omitempty"`
 	Hash    string `json:"hash,omitempty" yaml:"hash,omitempty"`
+	// the last time the fallback file's contents were confirmed to be current, which may be after they were written
+	SyncedAt time.Time `json:"synced_at,omitempty" yaml:"synced_at,omitempty"`
 }
 
 // ParseSecretsFileMetadata parse secrets file metadata
@@ -38,3 +46,87 @@ func ParseSecretsFileMetadata(data map[string]interface{}) SecretsFileMetadata {
 
 	return parsedMetadata
 }
+
+// FallbackIndex contains non-secret metadata about fallback files, keyed by the fallback file's path
+type FallbackIndex struct {
+	Version string                        `json:"version"`
+	Files   map[string]FallbackIndexEntry `json:"files"`
+}
+
+// FallbackIndexEntry contains non-secret metadata about a fallback file
+type FallbackIndexEntry struct {
+	Path         string `json:"path"`
+	MetadataPath string `json:"metadata_path,omitempty"`
+	Project      string `json:"project,omitempty"`
+	Config       string `json:"config,omitempty"`
+	Format       string `json:"format,omitempty"`
+	// a hash of the token used to fetch the secrets, used to determine whether the passphrase can be derived
+	TokenHash string `json:"token_hash,omitempty"`
+	// whether the file was encrypted with a user-specified passphrase rather than the derived passphrase
+	CustomPassphrase bool `json:"custom_passphrase"`
+	// whether the file was encrypted with this machine's fallback key rather than the derived passphrase
+	FallbackKey bool      `json:"fallback_key"`
+	WrittenAt   time.Time `json:"written_at"`
+	// the last time the file's contents were confirmed to be current
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// FallbackFileInfo describes a fallback file on disk
+type FallbackFileInfo struct {
+	FallbackIndexEntry
+	// the hash identifying the file, derived from its name
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+	// whether the file has an entry in the fallback index
+	Indexed bool `json:"indexed"`
+	// whether the file is outside the fallback directory, e.g. because it was written to a path specified with --fallback
+	External bool `json:"external"`
+	// the following are only populated when inspecting a file
+	FileVersion int    `json:"file_version,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+	KDF         string `json:"kdf,omitempty"`
+	KDFRounds   int    `json:"kdf_rounds,omitempty"`
+	KDFParams   string `json:"kdf_params,omitempty"`
+	ETag        string `json:"etag,omitempty"`
+}
+
+// FallbackFileResult the result of an operation on a fallback file
+type FallbackFileResult struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Project string `json:"project,omitempty"`
+	Config  string `json:"config,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	FallbackResultOK      = "ok"
+	FallbackResultFailed  = "failed"
+	FallbackResultSkipped = "skipped"
+)
+
+// FallbackPayload the encrypted contents of a fallback file. Storing the write time inside the
+// encrypted payload prevents it from being modified without the passphrase.
+type FallbackPayload struct {
+	// identifies the payload as an envelope, distinguishing it from legacy files that only contain secrets
+	Version   string    `json:"doppler_fallback_version"`
+	WrittenAt time.Time `json:"written_at"`
+	Secrets   []byte    `json:"secrets"`
+}
+
+// actions to take when reading a fallback file that exceeds the max age
+const (
+	FallbackStaleFail = "fail"
+	FallbackStaleWarn = "warn"
+)
+
+var FallbackStaleActions = []string{FallbackStaleFail, FallbackStaleWarn}
+
+// IsValidFallbackStaleAction whether the action is valid
+func IsValidFallbackStaleAction(action string) bool {
+	return utils.Contains(FallbackStaleActions, action)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2020 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package models
+
+import (
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// CI providers that an OIDC token can be retrieved from
+const (
+	OIDCProviderAuto      = "auto"
+	OIDCProviderGitHub    = "github"
+	OIDCProviderGitLab    = "gitlab"
+	OIDCProviderCircleCI  = "circleci"
+	OIDCProviderBuildkite = "buildkite"
+)
+
+var OIDCProviders = []string{OIDCProviderAuto, OIDCProviderGitHub, OIDCProviderGitLab, OIDCProviderCircleCI, OIDCProviderBuildkite}
+
+// IsValidOIDCProvider whether the provider is valid
+func IsValidOIDCProvider(provider string) bool {
+	return utils.Contains(OIDCProviders, provider)
+}
+
+// OIDCSession a service account identity token created via 'doppler oidc login', along with the source of the
+// OIDC token used to create it so that it can be refreshed
+type OIDCSession struct {
+	Identity  string    `yaml:"identity"`
+	ExpiresAt time.Time `yaml:"expires-at,omitempty"`
+	// the CI provider the OIDC token was retrieved from. blank when the OIDC token was specified via --token,
+	// in which case the session can't be refreshed
+	Provider string `yaml:"provider,omitempty"`
+	Audience string `yaml:"audience,omitempty"`
+	TokenEnv string `yaml:"token-env,omitempty"`
+}
+
+// CanRefresh whether a new identity token can be created for the session
+func (s OIDCSession) CanRefresh() bool {
+	return s.Identity != "" && s.Provider != "" && s.Provider != OIDCProviderAuto && !s.ExpiresAt.IsZero()
+}
+
+// OIDCAuthToken a service account identity token
+type OIDCAuthToken struct {
+	Token        string
+	ExpiresAt    time.Time
+	DashboardURL string
+}
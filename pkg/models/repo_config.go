@@ -18,9 +18,42 @@ package models
 
 // Config struct represents the basic project setup values
 type ProjectConfig struct {
+	Config          string         `yaml:"config"`
+	Project         string         `yaml:"project"`
+	Path            string         `yaml:"path"`
+	Branches        []BranchConfig `yaml:"branches,omitempty"`
+	RequiredSecrets []string       `yaml:"required-secrets,omitempty"`
+	Run             RunConfig      `yaml:"run,omitempty"`
+	Hooks           Hooks          `yaml:"hooks,omitempty"`
+}
+
+// BranchConfig struct maps git branches matching the pattern to a config
+type BranchConfig struct {
+	Pattern string `yaml:"pattern"`
 	Config  string `yaml:"config"`
-	Project string `yaml:"project"`
-	Path    string `yaml:"path"`
+}
+
+// RunConfig struct represents the defaults used by 'doppler run'
+type RunConfig struct {
+	Command         string `yaml:"command,omitempty"`
+	NameTransformer string `yaml:"name-transformer,omitempty"`
+	Mount           string `yaml:"mount,omitempty"`
+	Format          string `yaml:"format,omitempty"`
+	MountTemplate   string `yaml:"mount-template,omitempty"`
+}
+
+// Hooks struct represents commands run during setup
+type Hooks struct {
+	PostSetup []string `yaml:"post-setup,omitempty"`
+}
+
+// SetupCheck struct represents the result of comparing the local config with the repo config file
+type SetupCheck struct {
+	Path     string `json:"path"`
+	Option   string `json:"option"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	Matches  bool   `json:"matches"`
 }
 
 // RepoConfig struct representing legacy doppler.yaml setup file format
@@ -35,4 +68,6 @@ type RepoConfig struct {
 type MultiRepoConfig struct {
 	Setup []ProjectConfig `yaml:"setup"`
 	Flags Flags           `yaml:"flags"`
+	// File the absolute path of the file the config was read from, if any
+	File string `yaml:"-"`
 }
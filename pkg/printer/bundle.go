@@ -0,0 +1,52 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package printer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+)
+
+// Bundle print bundle info
+func Bundle(info models.BundleInfo, jsonFlag bool) {
+	if jsonFlag {
+		JSON(info)
+		return
+	}
+
+	expiresAt := "never"
+	if info.ExpiresAt != nil {
+		expiresAt = formatTime(*info.ExpiresAt)
+	}
+
+	rows := [][]string{
+		{"path", info.Path},
+		{"version", info.Version},
+		{"project", info.Project},
+		{"config", info.Config},
+		{"created at", formatTime(info.CreatedAt)},
+		{"expires at", expiresAt},
+		{"expired", strconv.FormatBool(info.Expired)},
+		{"recipients", strings.Join(info.Recipients, "\n")},
+		{"verified", strconv.FormatBool(info.Verified)},
+	}
+	if info.Verified {
+		rows = append(rows, []string{"secrets", strings.Join(info.SecretNames, "\n")})
+	}
+	Table([]string{"name", "value"}, rows, TableOptions())
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package printer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// columns available to tables that list resources. these are also used to complete --columns and --sort-by
+var (
+	ProjectColumns            = []string{"id", "name", "description", "created at"}
+	EnvironmentColumns        = []string{"id", "name", "initial fetch", "created at", "project"}
+	ConfigColumns             = []string{"name", "initial fetch", "last fetch", "created at", "environment", "project", "inheritable", "inherits"}
+	SecretColumns             = []string{"name", "visibility", "type", "value", "raw visibility", "raw type", "raw value", "note"}
+	ConfigServiceTokenColumns = []string{"name", "slug", "project", "environment", "config", "created at", "expires at", "access"}
+	FallbackFileColumns       = []string{"id", "project", "config", "format", "size", "last synced", "age"}
+	ProfileColumns            = []string{"name", "api-host", "dashboard-host", "verify-tls", "has token", "active"}
+)
+
+// ColumnName the name used to reference the column via --columns and --sort-by (e.g. "created-at")
+func ColumnName(header string) string {
+	name := strings.ToLower(strings.TrimSpace(header))
+	return strings.NewReplacer(" ", "-", "_", "-").Replace(name)
+}
+
+// ColumnNames the names used to reference the columns via --columns and --sort-by
+func ColumnNames(headers []string) []string {
+	names := make([]string, len(headers))
+	for i, header := range headers {
+		names[i] = ColumnName(header)
+	}
+	return names
+}
+
+// columnIndex the index of the column with the specified name, or -1 if it doesn't exist
+func columnIndex(headers []string, name string) int {
+	name = ColumnName(name)
+	for i, header := range headers {
+		if ColumnName(header) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func invalidColumnError(headers []string, name string) error {
+	return fmt.Errorf("invalid column %q. Valid columns are %s", name, strings.Join(ColumnNames(headers), ", "))
+}
+
+// sortRows sorts the rows by the column specified via --sort-by, and reverses them if --reverse was specified
+func sortRows(headers []string, rows [][]string) [][]string {
+	if utils.OutputSortBy == "" && !utils.OutputReverse {
+		return rows
+	}
+
+	sorted := make([][]string, len(rows))
+	copy(sorted, rows)
+
+	if utils.OutputSortBy != "" {
+		index := columnIndex(headers, utils.OutputSortBy)
+		if index == -1 {
+			utils.ErrExit(invalidColumnError(headers, utils.OutputSortBy), utils.ExitCodeValidation)
+		}
+		sort.SliceStable(sorted, func(a, b int) bool {
+			return lessValue(cell(sorted[a], index), cell(sorted[b], index))
+		})
+	}
+
+	if utils.OutputReverse {
+		for a, b := 0, len(sorted)-1; a < b; a, b = a+1, b-1 {
+			sorted[a], sorted[b] = sorted[b], sorted[a]
+		}
+	}
+
+	return sorted
+}
+
+// selectColumns filters the headers and rows to the columns specified via --columns, or the default columns
+func selectColumns(headers []string, defaultColumns []string, rows [][]string) ([]string, [][]string) {
+	columns := defaultColumns
+	if len(utils.OutputColumns) > 0 {
+		columns = utils.OutputColumns
+	}
+
+	var indexes []int
+	for _, column := range columns {
+		index := columnIndex(headers, column)
+		if index == -1 {
+			utils.ErrExit(invalidColumnError(headers, column), utils.ExitCodeValidation)
+		}
+		indexes = append(indexes, index)
+	}
+
+	if len(indexes) == len(headers) {
+		inOrder := true
+		for i, index := range indexes {
+			inOrder = inOrder && i == index
+		}
+		if inOrder {
+			return headers, rows
+		}
+	}
+
+	selectedHeaders := make([]string, len(indexes))
+	for i, index := range indexes {
+		selectedHeaders[i] = headers[index]
+	}
+	selectedRows := make([][]string, len(rows))
+	for r, row := range rows {
+		selectedRows[r] = make([]string, len(indexes))
+		for i, index := range indexes {
+			selectedRows[r][i] = cell(row, index)
+		}
+	}
+	return selectedHeaders, selectedRows
+}
+
+func cell(row []string, index int) string {
+	if index < len(row) {
+		return row[index]
+	}
+	return ""
+}
+
+// lessValue compares the values numerically if both are numbers, otherwise lexically
+func lessValue(a string, b string) bool {
+	numA, errA := strconv.ParseFloat(a, 64)
+	numB, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return numA < numB
+	}
+	return strings.ToLower(a) < strings.ToLower(b)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package printer
+
+import (
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectColumns(t *testing.T) {
+	defer func() { utils.OutputColumns = nil }()
+
+	headers := []string{"name", "created at", "note"}
+	rows := [][]string{{"a", "2026-01-01", "first"}, {"b", "2026-01-02"}}
+
+	selectedHeaders, selectedRows := selectColumns(headers, []string{"name", "note"}, rows)
+	assert.Equal(t, []string{"name", "note"}, selectedHeaders)
+	assert.Equal(t, [][]string{{"a", "first"}, {"b", ""}}, selectedRows)
+
+	utils.OutputColumns = []string{"Created_At", "name"}
+	selectedHeaders, selectedRows = selectColumns(headers, []string{"name", "note"}, rows)
+	assert.Equal(t, []string{"created at", "name"}, selectedHeaders)
+	assert.Equal(t, [][]string{{"2026-01-01", "a"}, {"2026-01-02", "b"}}, selectedRows)
+}
+
+func TestSortRows(t *testing.T) {
+	defer func() {
+		utils.OutputSortBy = ""
+		utils.OutputReverse = false
+	}()
+
+	headers := []string{"name", "size"}
+	rows := [][]string{{"b", "10"}, {"C", "9"}, {"a", "100"}}
+
+	assert.Equal(t, rows, sortRows(headers, rows))
+
+	utils.OutputSortBy = "name"
+	assert.Equal(t, [][]string{{"a", "100"}, {"b", "10"}, {"C", "9"}}, sortRows(headers, rows))
+
+	// numeric values are compared as numbers
+	utils.OutputSortBy = "size"
+	assert.Equal(t, [][]string{{"C", "9"}, {"b", "10"}, {"a", "100"}}, sortRows(headers, rows))
+
+	utils.OutputReverse = true
+	assert.Equal(t, [][]string{{"a", "100"}, {"b", "10"}, {"C", "9"}}, sortRows(headers, rows))
+
+	// the original rows are unchanged
+	assert.Equal(t, [][]string{{"b", "10"}, {"C", "9"}, {"a", "100"}}, rows)
+}
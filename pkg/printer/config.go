@@ -68,7 +68,7 @@ func ScopedConfigSource(conf models.ScopedOptions, jsonFlag bool, source bool, o
 
 			row := []string{translatedName, value, pair.Scope}
 			if source {
-				row = append(row, pair.Source)
+				row = append(row, pair.Source, pair.Rule)
 			}
 			rows = append(rows, row)
 		}
@@ -81,7 +81,7 @@ func ScopedConfigSource(conf models.ScopedOptions, jsonFlag bool, source bool, o
 
 	headers := []string{"name", "value", "scope"}
 	if source {
-		headers = append(headers, "source")
+		headers = append(headers, "source", "rule")
 	}
 
 	Table(headers, rows, TableOptions())
@@ -221,3 +221,78 @@ func Flag(flag string, value bool, jsonFlag bool, plain bool, copy bool) {
 
 	Table([]string{"flag", "value"}, [][]string{{flag, strconv.FormatBool(value)}}, TableOptions())
 }
+
+// Profiles print profiles. Tokens are never printed.
+func Profiles(profiles map[string]models.ProfileOptions, active string, jsonFlag bool) {
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if jsonFlag {
+		profilesInfo := []map[string]interface{}{}
+		for _, name := range names {
+			profile := profiles[name]
+			profilesInfo = append(profilesInfo, map[string]interface{}{
+				"name":           name,
+				"api-host":       profile.APIHost,
+				"dashboard-host": profile.DashboardHost,
+				"verify-tls":     profile.VerifyTLS,
+				"has-token":      profile.Token != "",
+				"active":         name == active,
+			})
+		}
+		JSON(profilesInfo)
+		return
+	}
+
+	var rows [][]string
+	for _, name := range names {
+		profile := profiles[name]
+		activeMarker := ""
+		if name == active {
+			activeMarker = "*"
+		}
+		rows = append(rows, []string{name, profile.APIHost, profile.DashboardHost, profile.VerifyTLS, strconv.FormatBool(profile.Token != ""), activeMarker})
+	}
+	Table(ProfileColumns, rows, TableOptions())
+}
+
+// SecureStorageStatus print the secure storage setting and how each token is stored
+func SecureStorageStatus(status models.SecureStorageStatus, jsonFlag bool) {
+	if jsonFlag {
+		JSON(status)
+		return
+	}
+
+	rows := [][]string{
+		{"enabled", strconv.FormatBool(status.Enabled)},
+		{"keyring available", strconv.FormatBool(status.KeyringAvailable)},
+	}
+	Table([]string{"name", "value"}, rows, TableOptions())
+
+	if len(status.Tokens) == 0 {
+		return
+	}
+
+	var tokenRows [][]string
+	for _, token := range status.Tokens {
+		tokenRows = append(tokenRows, []string{token.Scope, token.Profile, token.Storage})
+	}
+	Table([]string{"scope", "profile", "storage"}, tokenRows, TableOptions())
+}
+
+// SetupCheck print the result of comparing the local config with the repo config file
+func SetupCheck(results []models.SetupCheck, jsonFlag bool) {
+	if jsonFlag {
+		JSON(results)
+		return
+	}
+
+	var rows [][]string
+	for _, result := range results {
+		rows = append(rows, []string{result.Path, result.Option, result.Expected, result.Actual, strconv.FormatBool(result.Matches)})
+	}
+	Table([]string{"path", "option", "expected", "actual", "matches"}, rows, TableOptions())
+}
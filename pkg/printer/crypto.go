@@ -0,0 +1,50 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package printer
+
+import (
+	"strconv"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+)
+
+// EncryptedFile print the parameters of an encrypted file
+func EncryptedFile(file models.EncryptedFile, jsonFlag bool) {
+	info := map[string]interface{}{
+		"version":  file.Version,
+		"encoding": file.Encoding,
+		"kdf":      file.KDF,
+	}
+	rows := [][]string{
+		{"version", strconv.Itoa(file.Version)},
+		{"encoding", file.Encoding},
+		{"kdf", file.KDF},
+	}
+	if file.KDF == models.Argon2idKDF {
+		info["kdf_params"] = file.Argon2id.String()
+		rows = append(rows, []string{"kdf params", file.Argon2id.String()})
+	} else {
+		info["kdf_rounds"] = file.NumRounds
+		rows = append(rows, []string{"kdf rounds", strconv.Itoa(file.NumRounds)})
+	}
+
+	if jsonFlag {
+		JSON(info)
+		return
+	}
+
+	Table([]string{"name", "value"}, rows, TableOptions())
+}
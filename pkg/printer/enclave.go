@@ -115,8 +115,17 @@ func ActivityLog(log models.ActivityLog, jsonFlag bool, diff bool) {
 	fmt.Println("")
 }
 
-// JSON print object as json
+// JSON print object as json, or as yaml or a template when using those output formats
 func JSON(structure interface{}) {
+	switch utils.OutputFormat {
+	case utils.OutputFormatYAML:
+		YAML(structure)
+		return
+	case utils.OutputFormatTemplate:
+		Template(structure, utils.OutputTemplate)
+		return
+	}
+
 	resp, err := json.Marshal(structure)
 	if err != nil {
 		utils.HandleError(err)
@@ -173,7 +182,6 @@ func ConfigsInfo(info []models.ConfigInfo, jsonFlag bool) {
 		return
 	}
 
-	header := []string{"name", "initial fetch", "last fetch", "created at", "environment", "project", "inheritable", "inherits"}
 	var rows [][]string
 	for _, configInfo := range info {
 		var inheritsStrings []string
@@ -199,7 +207,7 @@ func ConfigsInfo(info []models.ConfigInfo, jsonFlag bool) {
 			inheritsString,
 		})
 	}
-	Table(header, rows, TableOptions())
+	Table(ConfigColumns, rows, TableOptions())
 }
 
 // EnvironmentsInfo print environments
@@ -214,7 +222,7 @@ func EnvironmentsInfo(info []models.EnvironmentInfo, jsonFlag bool) {
 		rows = append(rows, []string{environmentInfo.ID, environmentInfo.Name, environmentInfo.InitialFetchAt,
 			environmentInfo.CreatedAt, environmentInfo.Project})
 	}
-	Table([]string{"id", "name", "initial fetch", "created at", "project"}, rows, TableOptions())
+	Table(EnvironmentColumns, rows, TableOptions())
 }
 
 // EnvironmentInfo print environment
@@ -225,7 +233,7 @@ func EnvironmentInfo(info models.EnvironmentInfo, jsonFlag bool) {
 	}
 
 	rows := [][]string{{info.ID, info.Name, info.InitialFetchAt, info.CreatedAt, info.Project}}
-	Table([]string{"id", "name", "initial fetch", "created at", "project"}, rows, TableOptions())
+	Table(EnvironmentColumns, rows, TableOptions())
 }
 
 // ProjectsInfo print info of multiple projects
@@ -239,7 +247,7 @@ func ProjectsInfo(info []models.ProjectInfo, jsonFlag bool) {
 	for _, projectInfo := range info {
 		rows = append(rows, []string{projectInfo.ID, projectInfo.Name, projectInfo.Description, projectInfo.CreatedAt})
 	}
-	Table([]string{"id", "name", "description", "created at"}, rows, TableOptions())
+	Table(ProjectColumns, rows, TableOptions())
 }
 
 // ProjectInfo print project info
@@ -250,7 +258,7 @@ func ProjectInfo(info models.ProjectInfo, jsonFlag bool) {
 	}
 
 	rows := [][]string{{info.ID, info.Name, info.Description, info.CreatedAt}}
-	Table([]string{"id", "name", "description", "created at"}, rows, TableOptions())
+	Table(ProjectColumns, rows, TableOptions())
 }
 
 // Secrets print secrets
@@ -340,24 +348,25 @@ func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string,
 		return
 	}
 
-	headers := []string{"name"}
+	// visibility, type, and raw values are only displayed by default when requested
+	defaultColumns := []string{"name"}
 	if visibility {
-		headers = append(headers, "visibility")
+		defaultColumns = append(defaultColumns, "visibility")
 	}
 	if valueType {
-		headers = append(headers, "type")
+		defaultColumns = append(defaultColumns, "type")
 	}
-	headers = append(headers, "value")
+	defaultColumns = append(defaultColumns, "value")
 	if raw {
 		if visibility {
-			headers = append(headers, "raw visibility")
+			defaultColumns = append(defaultColumns, "raw visibility")
 		}
 		if valueType {
-			headers = append(headers, "raw type")
+			defaultColumns = append(defaultColumns, "raw type")
 		}
-		headers = append(headers, "raw value")
+		defaultColumns = append(defaultColumns, "raw value")
 	}
-	headers = append(headers, "note")
+	defaultColumns = append(defaultColumns, "note")
 
 	var rows [][]string
 	for _, secret := range matchedSecrets {
@@ -367,37 +376,17 @@ func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string,
 		} else {
 			computedValue = "[RESTRICTED]"
 		}
-
-		row := []string{secret.Name}
-		if visibility {
-			row = append(row, secret.ComputedVisibility)
-		}
-		if valueType {
-			row = append(row, secret.ComputedValueType.Type)
-		}
-		row = append(row, computedValue)
-		if raw {
-			var rawValue string
-			if secret.RawValue != nil {
-				rawValue = *secret.RawValue
-			} else {
-				rawValue = "[RESTRICTED]"
-			}
-			if visibility {
-				row = append(row, secret.RawVisibility)
-			}
-			if valueType {
-				row = append(row, secret.RawValueType.Type)
-			}
-			row = append(row, rawValue)
+		var rawValue string
+		if secret.RawValue != nil {
+			rawValue = *secret.RawValue
+		} else {
+			rawValue = "[RESTRICTED]"
 		}
 
-		row = append(row, secret.Note)
-
-		rows = append(rows, row)
+		rows = append(rows, []string{secret.Name, secret.ComputedVisibility, secret.ComputedValueType.Type, computedValue, secret.RawVisibility, secret.RawValueType.Type, rawValue, secret.Note})
 	}
 
-	Table(headers, rows, TableOptions())
+	TableWithDefaultColumns(SecretColumns, defaultColumns, rows, TableOptions())
 }
 
 // SecretsNames print secrets names
@@ -460,7 +449,7 @@ func ConfigServiceTokensInfo(tokens []models.ConfigServiceToken, number int, jso
 	for _, token := range tokens {
 		rows = append(rows, []string{token.Name, token.Slug, token.Project, token.Environment, token.Config, token.CreatedAt, token.ExpiresAt, token.Access})
 	}
-	Table([]string{"name", "slug", "project", "environment", "config", "created at", "expires at", "access"}, rows, TableOptions())
+	Table(ConfigServiceTokenColumns, rows, TableOptions())
 }
 
 // ConfigServiceTokenInfo print config service token info
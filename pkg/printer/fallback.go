@@ -0,0 +1,119 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package printer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+)
+
+// FallbackFiles print info of multiple fallback files
+func FallbackFiles(files []models.FallbackFileInfo, jsonFlag bool) {
+	if jsonFlag {
+		if files == nil {
+			files = []models.FallbackFileInfo{}
+		}
+		JSON(files)
+		return
+	}
+
+	rows := [][]string{}
+	for _, file := range files {
+		rows = append(rows, []string{shortFallbackFileID(file.ID), file.Project, file.Config, file.Format, strconv.FormatInt(file.Size, 10), formatTime(file.SyncedAt), fallbackFileAge(file)})
+	}
+	Table(FallbackFileColumns, rows, TableOptions())
+}
+
+// FallbackFile print fallback file info
+func FallbackFile(file models.FallbackFileInfo, jsonFlag bool) {
+	if jsonFlag {
+		JSON(file)
+		return
+	}
+
+	rows := [][]string{
+		{"id", file.ID},
+		{"name", file.Name},
+		{"path", file.Path},
+		{"indexed", strconv.FormatBool(file.Indexed)},
+		{"external", strconv.FormatBool(file.External)},
+		{"project", file.Project},
+		{"config", file.Config},
+		{"format", file.Format},
+		{"custom passphrase", strconv.FormatBool(file.CustomPassphrase)},
+		{"fallback key", strconv.FormatBool(file.FallbackKey)},
+		{"size", strconv.FormatInt(file.Size, 10)},
+		{"file version", strconv.Itoa(file.FileVersion)},
+		{"encoding", file.Encoding},
+		{"kdf", file.KDF},
+		{"kdf rounds", strconv.Itoa(file.KDFRounds)},
+		{"kdf params", file.KDFParams},
+		{"metadata file", file.MetadataPath},
+		{"etag", file.ETag},
+		{"written at", formatTime(file.WrittenAt)},
+		{"last synced", formatTime(file.SyncedAt)},
+		{"modified at", formatTime(file.ModifiedAt)},
+		{"age", fallbackFileAge(file)},
+	}
+	Table([]string{"name", "value"}, rows, TableOptions())
+}
+
+// FallbackFileResults print the results of an operation on fallback files
+func FallbackFileResults(results []models.FallbackFileResult, jsonFlag bool) {
+	if jsonFlag {
+		if results == nil {
+			results = []models.FallbackFileResult{}
+		}
+		JSON(results)
+		return
+	}
+
+	rows := [][]string{}
+	for _, result := range results {
+		rows = append(rows, []string{shortFallbackFileID(result.ID), result.Project, result.Config, result.Status, result.Message})
+	}
+	Table([]string{"id", "project", "config", "status", "message"}, rows, TableOptions())
+}
+
+// shortFallbackFileID an abbreviated ID, which can be used to reference the file in other commands
+func shortFallbackFileID(id string) string {
+	if len(id) > 12 {
+		return id[0:12]
+	}
+	return id
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// fallbackFileAge the time since the file was last synced, or last modified if unknown
+func fallbackFileAge(file models.FallbackFileInfo) string {
+	lastSynced := file.SyncedAt
+	if lastSynced.IsZero() {
+		lastSynced = file.ModifiedAt
+	}
+	if lastSynced.IsZero() {
+		return ""
+	}
+	return fmt.Sprint(time.Since(lastSynced).Round(time.Second))
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package printer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// YAML print object as yaml, using the same field names as json
+func YAML(structure interface{}) {
+	resp, err := yaml.Marshal(jsonValue(structure))
+	if err != nil {
+		utils.HandleError(err)
+	}
+
+	fmt.Print(string(resp))
+}
+
+// Template print object using the Go template. Fields are accessed using their json names (e.g. {{.name}})
+func Template(structure interface{}, text string) {
+	funcs := template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			resp, err := json.Marshal(value)
+			return string(resp), err
+		},
+		"join": func(sep string, values []interface{}) string {
+			var strs []string
+			for _, value := range values {
+				strs = append(strs, fmt.Sprint(value))
+			}
+			return strings.Join(strs, sep)
+		},
+	}
+
+	tmpl, err := template.New("output").Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		utils.HandleError(err, "Unable to parse output template")
+	}
+	if err := tmpl.Execute(os.Stdout, jsonValue(structure)); err != nil {
+		utils.HandleError(err, "Unable to render output template")
+	}
+}
+
+// Delimited print rows of delimiter-separated values, preceded by the headers
+func Delimited(headers []string, rows [][]string, delimiter rune) {
+	w := csv.NewWriter(os.Stdout)
+	w.Comma = delimiter
+
+	if err := w.Write(headers); err != nil {
+		utils.HandleError(err)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		utils.HandleError(err)
+	}
+}
+
+// jsonValue converts the object to the generic value it'd have when decoded from json
+func jsonValue(structure interface{}) interface{} {
+	resp, err := json.Marshal(structure)
+	if err != nil {
+		utils.HandleError(err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(resp, &value); err != nil {
+		utils.HandleError(err)
+	}
+	return value
+}
@@ -54,8 +54,25 @@ func TableOptions() tableOptions {
 	return tableOptions{ShowBorder: true, SeparateHeader: true, SeparateColumns: true}
 }
 
-// Table print table
+// Table print table, or delimited values when using the csv or tsv output formats.
+// Rows are sorted and columns are selected as specified via --sort-by, --reverse, and --columns.
 func Table(headers []string, rows [][]string, options tableOptions) {
+	TableWithDefaultColumns(headers, headers, rows, options)
+}
+
+// TableWithDefaultColumns print table, displaying only the default columns unless other columns were selected via --columns
+func TableWithDefaultColumns(headers []string, defaultColumns []string, rows [][]string, options tableOptions) {
+	headers, rows = selectColumns(headers, defaultColumns, sortRows(headers, rows))
+
+	switch utils.OutputFormat {
+	case utils.OutputFormatCSV:
+		Delimited(headers, rows, ',')
+		return
+	case utils.OutputFormatTSV:
+		Delimited(headers, rows, '\t')
+		return
+	}
+
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 	t.SetStyle(table.StyleLight)
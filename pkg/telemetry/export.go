@@ -0,0 +1,308 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/DopplerHQ/cli/pkg/version"
+)
+
+const defaultServiceName = "doppler-cli"
+const defaultExportTimeout = 10 * time.Second
+const instrumentationScope = "github.com/DopplerHQ/cli"
+
+type exporterConfig struct {
+	tracesEndpoint  string
+	metricsEndpoint string
+	tracesHeaders   map[string]string
+	metricsHeaders  map[string]string
+	timeout         time.Duration
+	resource        map[string]interface{}
+}
+
+// loadExporterConfig reads the standard OTEL_* environment variables
+func loadExporterConfig() (exporterConfig, error) {
+	config := exporterConfig{timeout: defaultExportTimeout}
+
+	if protocol := signalEnv("PROTOCOL", ""); protocol != "" && protocol != "http/json" {
+		utils.LogDebug(fmt.Sprintf("OTLP protocol %s is not supported; using http/json", protocol))
+	}
+
+	base := strings.TrimSuffix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/")
+	config.tracesEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if config.tracesEndpoint == "" && base != "" {
+		config.tracesEndpoint = base + "/v1/traces"
+	}
+	config.metricsEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT")
+	if config.metricsEndpoint == "" && base != "" {
+		config.metricsEndpoint = base + "/v1/metrics"
+	}
+
+	var err error
+	if config.tracesHeaders, err = parseKeyValues(signalEnv("HEADERS", "TRACES")); err != nil {
+		return exporterConfig{}, fmt.Errorf("invalid OTLP headers: %w", err)
+	}
+	if config.metricsHeaders, err = parseKeyValues(signalEnv("HEADERS", "METRICS")); err != nil {
+		return exporterConfig{}, fmt.Errorf("invalid OTLP headers: %w", err)
+	}
+
+	if timeout := signalEnv("TIMEOUT", ""); timeout != "" {
+		ms, err := strconv.Atoi(timeout)
+		if err != nil {
+			return exporterConfig{}, fmt.Errorf("invalid OTLP timeout: %w", err)
+		}
+		config.timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	resourceAttributes, err := parseKeyValues(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"))
+	if err != nil {
+		return exporterConfig{}, fmt.Errorf("invalid OTEL_RESOURCE_ATTRIBUTES: %w", err)
+	}
+	config.resource = map[string]interface{}{}
+	for key, value := range resourceAttributes {
+		config.resource[key] = value
+	}
+	config.resource["service.name"] = defaultServiceName
+	if serviceName := os.Getenv("OTEL_SERVICE_NAME"); serviceName != "" {
+		config.resource["service.name"] = serviceName
+	}
+	config.resource["service.version"] = version.ProgramVersion
+	config.resource["os.type"] = utils.HostOS()
+
+	return config, nil
+}
+
+// signalEnv reads OTEL_EXPORTER_OTLP_<SIGNAL>_<NAME>, falling back to OTEL_EXPORTER_OTLP_<NAME>
+func signalEnv(name string, signal string) string {
+	if signal != "" {
+		if value := os.Getenv(fmt.Sprintf("OTEL_EXPORTER_OTLP_%s_%s", signal, name)); value != "" {
+			return value
+		}
+	}
+	return os.Getenv(fmt.Sprintf("OTEL_EXPORTER_OTLP_%s", name))
+}
+
+// parseKeyValues parses the W3C baggage-style format used by OTEL_EXPORTER_OTLP_HEADERS (e.g. "a=1,b=2")
+func parseKeyValues(s string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		values[strings.TrimSpace(parts[0])] = value
+	}
+	return values, nil
+}
+
+func exportTraces(config exporterConfig, spans []*Span) error {
+	if config.tracesEndpoint == "" || len(spans) == 0 {
+		return nil
+	}
+
+	var otlpSpans []interface{}
+	for _, span := range spans {
+		span.mutex.Lock()
+		otlpSpan := map[string]interface{}{
+			"traceId":           span.traceID,
+			"spanId":            span.spanID,
+			"name":              span.name,
+			"kind":              span.kind,
+			"startTimeUnixNano": unixNano(span.start),
+			"endTimeUnixNano":   unixNano(span.end),
+			"attributes":        otlpAttributes(span.attributes),
+			"status":            map[string]interface{}{"code": span.statusCode, "message": span.statusMsg},
+		}
+		if span.parentID != "" {
+			otlpSpan["parentSpanId"] = span.parentID
+		}
+		var events []interface{}
+		for _, event := range span.events {
+			events = append(events, map[string]interface{}{
+				"name":         event.name,
+				"timeUnixNano": unixNano(event.time),
+				"attributes":   otlpAttributes(event.attributes),
+			})
+		}
+		if len(events) > 0 {
+			otlpSpan["events"] = events
+		}
+		span.mutex.Unlock()
+
+		otlpSpans = append(otlpSpans, otlpSpan)
+	}
+
+	body := map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{"attributes": otlpAttributes(config.resource)},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": otlpScope(),
+						"spans": otlpSpans,
+					},
+				},
+			},
+		},
+	}
+
+	return post(config.tracesEndpoint, config.tracesHeaders, config.timeout, body)
+}
+
+func exportMetrics(config exporterConfig, counters []counter, start time.Time, end time.Time) error {
+	if config.metricsEndpoint == "" || len(counters) == 0 {
+		return nil
+	}
+
+	// group data points by metric name
+	var names []string
+	dataPoints := map[string][]interface{}{}
+	for _, c := range counters {
+		if _, ok := dataPoints[c.name]; !ok {
+			names = append(names, c.name)
+		}
+		dataPoints[c.name] = append(dataPoints[c.name], map[string]interface{}{
+			"asInt":             strconv.FormatInt(c.value, 10),
+			"startTimeUnixNano": unixNano(start),
+			"timeUnixNano":      unixNano(end),
+			"attributes":        otlpAttributes(c.attributes),
+		})
+	}
+
+	var metrics []interface{}
+	for _, name := range names {
+		metrics = append(metrics, map[string]interface{}{
+			"name": name,
+			"unit": "1",
+			"sum": map[string]interface{}{
+				// cumulative
+				"aggregationTemporality": 2,
+				"isMonotonic":            true,
+				"dataPoints":             dataPoints[name],
+			},
+		})
+	}
+
+	body := map[string]interface{}{
+		"resourceMetrics": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{"attributes": otlpAttributes(config.resource)},
+				"scopeMetrics": []interface{}{
+					map[string]interface{}{
+						"scope":   otlpScope(),
+						"metrics": metrics,
+					},
+				},
+			},
+		},
+	}
+
+	return post(config.metricsEndpoint, config.metricsHeaders, config.timeout, body)
+}
+
+func post(endpoint string, headers map[string]string, timeout time.Duration, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("user-agent", "doppler-go-cli-"+version.ProgramVersion)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	// use a dedicated client so exports are never themselves instrumented, retried, or recorded
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("OTLP export to %s failed with HTTP %d", endpoint, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func otlpScope() map[string]interface{} {
+	return map[string]interface{}{"name": instrumentationScope, "version": version.ProgramVersion}
+}
+
+func otlpAttributes(attributes map[string]interface{}) []interface{} {
+	keys := []string{}
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	otlp := []interface{}{}
+	for _, key := range keys {
+		otlp = append(otlp, map[string]interface{}{"key": key, "value": otlpValue(attributes[key])})
+	}
+	return otlp
+}
+
+func otlpValue(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	case time.Duration:
+		return map[string]interface{}{"doubleValue": float64(v.Microseconds()) / 1000}
+	case string:
+		return map[string]interface{}{"stringValue": redact(v)}
+	default:
+		return map[string]interface{}{"stringValue": redact(fmt.Sprintf("%v", v))}
+	}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,305 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package telemetry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// This package records OpenTelemetry traces and metrics for CLI operations.
+// It is disabled by default and exports via OTLP/HTTP (JSON encoding) when enabled.
+
+// Enabled whether spans and metrics should be recorded
+var Enabled = false
+
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+
+	statusCodeUnset = 0
+	statusCodeError = 2
+)
+
+// Span a timed operation
+type Span struct {
+	name       string
+	kind       int
+	traceID    string
+	spanID     string
+	parentID   string
+	start      time.Time
+	end        time.Time
+	attributes map[string]interface{}
+	events     []spanEvent
+	statusCode int
+	statusMsg  string
+	mutex      sync.Mutex
+}
+
+type spanEvent struct {
+	name       string
+	time       time.Time
+	attributes map[string]interface{}
+}
+
+type counter struct {
+	name       string
+	attributes map[string]interface{}
+	value      int64
+}
+
+var mutex sync.Mutex
+var exporter *exporterConfig
+var traceID string
+var rootSpan *Span
+var startTime time.Time
+var finishedSpans []*Span
+var counters = map[string]*counter{}
+var shutdownOnce sync.Once
+
+// Start begins recording a trace for the specified command. Telemetry is disabled
+// if no OTLP endpoint has been configured.
+func Start(command string) {
+	if !Enabled {
+		return
+	}
+
+	config, err := loadExporterConfig()
+	if err != nil {
+		utils.LogDebug("Unable to load OpenTelemetry exporter config; disabling telemetry")
+		utils.LogDebugError(err)
+		Enabled = false
+		return
+	}
+	if config.tracesEndpoint == "" && config.metricsEndpoint == "" {
+		utils.LogDebug("No OTLP endpoint specified; disabling telemetry")
+		Enabled = false
+		return
+	}
+
+	mutex.Lock()
+	exporter = &config
+	traceID = randomHex(16)
+	startTime = time.Now()
+	mutex.Unlock()
+
+	rootSpan = newSpan(command, spanKindInternal, "", map[string]interface{}{"doppler.command": command})
+
+	utils.LogDebug(fmt.Sprintf("Exporting OpenTelemetry data to %s", config.tracesEndpoint))
+
+	utils.RegisterExitHandler(func(exitCode int) {
+		Shutdown(exitCode)
+	})
+}
+
+// Shutdown ends the root span and exports all recorded telemetry
+func Shutdown(exitCode int) {
+	if !Enabled {
+		return
+	}
+
+	shutdownOnce.Do(func() {
+		if rootSpan != nil {
+			rootSpan.SetAttribute("process.exit.code", exitCode)
+			if exitCode != 0 {
+				rootSpan.setStatus(statusCodeError, fmt.Sprintf("exited with code %d", exitCode))
+			}
+			rootSpan.End()
+		}
+
+		mutex.Lock()
+		spans := finishedSpans
+		finishedSpans = nil
+		metrics := []counter{}
+		for _, c := range counters {
+			metrics = append(metrics, *c)
+		}
+		config := exporter
+		mutex.Unlock()
+
+		if config == nil {
+			return
+		}
+
+		sort.Slice(metrics, func(a, b int) bool {
+			return metrics[a].name < metrics[b].name
+		})
+
+		if err := exportTraces(*config, spans); err != nil {
+			utils.LogDebug("Unable to export OpenTelemetry traces")
+			utils.LogDebugError(err)
+		}
+		if err := exportMetrics(*config, metrics, startTime, time.Now()); err != nil {
+			utils.LogDebug("Unable to export OpenTelemetry metrics")
+			utils.LogDebugError(err)
+		}
+	})
+}
+
+// StartSpan starts a span as a child of the command's root span.
+// Returns nil when telemetry is disabled; all Span methods are safe to call on nil.
+func StartSpan(name string, attributes map[string]interface{}) *Span {
+	if !Enabled || rootSpan == nil {
+		return nil
+	}
+
+	return rootSpan.StartChild(name, attributes)
+}
+
+// StartClientSpan starts a span representing an outbound request
+func StartClientSpan(name string, attributes map[string]interface{}) *Span {
+	span := StartSpan(name, attributes)
+	if span != nil {
+		span.kind = spanKindClient
+	}
+	return span
+}
+
+// StartChild starts a span as a child of this span
+func (s *Span) StartChild(name string, attributes map[string]interface{}) *Span {
+	if s == nil {
+		return nil
+	}
+
+	return newSpan(name, spanKindInternal, s.spanID, attributes)
+}
+
+// SetAttribute sets an attribute on the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.attributes[key] = value
+}
+
+// AddEvent records a point-in-time event on the span
+func (s *Span) AddEvent(name string, attributes map[string]interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, spanEvent{name: name, time: time.Now(), attributes: attributes})
+}
+
+// RecordError marks the span as failed
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.AddEvent("exception", map[string]interface{}{"exception.message": redact(err.Error())})
+	s.setStatus(statusCodeError, redact(err.Error()))
+}
+
+// End completes the span. Calling End more than once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	if !s.end.IsZero() {
+		s.mutex.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mutex.Unlock()
+
+	mutex.Lock()
+	finishedSpans = append(finishedSpans, s)
+	mutex.Unlock()
+}
+
+func (s *Span) setStatus(code int, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.statusCode = code
+	s.statusMsg = message
+}
+
+// AddCounter increments a monotonic counter
+func AddCounter(name string, value int64, attributes map[string]interface{}) {
+	if !Enabled {
+		return
+	}
+
+	key := counterKey(name, attributes)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	c, ok := counters[key]
+	if !ok {
+		c = &counter{name: name, attributes: attributes}
+		counters[key] = c
+	}
+	c.value += value
+}
+
+func newSpan(name string, kind int, parentID string, attributes map[string]interface{}) *Span {
+	attrs := map[string]interface{}{}
+	for key, value := range attributes {
+		attrs[key] = value
+	}
+
+	return &Span{
+		name:       name,
+		kind:       kind,
+		traceID:    traceID,
+		spanID:     randomHex(8),
+		parentID:   parentID,
+		start:      time.Now(),
+		attributes: attrs,
+		statusCode: statusCodeUnset,
+	}
+}
+
+func counterKey(name string, attributes map[string]interface{}) string {
+	keys := []string{}
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{name}
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, attributes[key]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func randomHex(numBytes int) string {
+	buffer := make([]byte, numBytes)
+	rand.Read(buffer) // #nosec G104
+	return hex.EncodeToString(buffer)
+}
+
+// redact ensures auth tokens never leave the machine as part of telemetry data
+func redact(s string) string {
+	return utils.RedactAuthTokens(s)
+}
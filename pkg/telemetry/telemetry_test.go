@@ -0,0 +1,156 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type collector struct {
+	mutex    sync.Mutex
+	requests map[string][]map[string]interface{}
+	headers  map[string]http.Header
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	c := &collector{requests: map[string][]map[string]interface{}{}, headers: map[string]http.Header{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		var payload map[string]interface{}
+		assert.NoError(t, json.Unmarshal(body, &payload))
+
+		c.mutex.Lock()
+		c.requests[r.URL.Path] = append(c.requests[r.URL.Path], payload)
+		c.headers[r.URL.Path] = r.Header
+		c.mutex.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func reset() {
+	Enabled = false
+	exporter = nil
+	rootSpan = nil
+	finishedSpans = nil
+	counters = map[string]*counter{}
+	shutdownOnce = sync.Once{}
+}
+
+func TestDisabledByDefault(t *testing.T) {
+	reset()
+	t.Cleanup(reset)
+
+	span := StartSpan("test", nil)
+	assert.Nil(t, span)
+
+	// methods are safe to call on nil spans
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("error"))
+	span.StartChild("child", nil).End()
+	span.End()
+
+	AddCounter("doppler.cache.hits", 1, nil)
+	assert.Empty(t, counters)
+}
+
+func TestDisabledWithoutEndpoint(t *testing.T) {
+	reset()
+	t.Cleanup(reset)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "")
+
+	Enabled = true
+	Start("doppler run")
+	assert.False(t, Enabled)
+}
+
+func TestExport(t *testing.T) {
+	reset()
+	t.Cleanup(reset)
+
+	c, server := newCollector(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-api-key=abc%20123")
+	t.Setenv("OTEL_SERVICE_NAME", "my-service")
+
+	Enabled = true
+	Start("doppler run")
+
+	span := StartClientSpan("http.request", map[string]interface{}{"http.request.method": "GET"})
+	attempt := span.StartChild("http.attempt", nil)
+	attempt.RecordError(errors.New("failed using token dp.st.dev.abcdefghijklmnopqrstuvwxyz0123456789"))
+	attempt.End()
+	span.End()
+
+	AddCounter("doppler.cache.hits", 1, nil)
+	AddCounter("doppler.cache.hits", 2, nil)
+
+	Shutdown(3)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	assert.Equal(t, "abc 123", c.headers["/v1/traces"].Get("x-api-key"))
+	assert.Len(t, c.requests["/v1/traces"], 1)
+	assert.Len(t, c.requests["/v1/metrics"], 1)
+
+	traces, err := json.Marshal(c.requests["/v1/traces"][0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(traces), `"stringValue":"my-service"`)
+	assert.Contains(t, string(traces), `"name":"doppler run"`)
+	assert.Contains(t, string(traces), `"name":"http.attempt"`)
+	assert.Contains(t, string(traces), `"key":"process.exit.code","value":{"intValue":"3"}`)
+	assert.True(t, strings.Contains(string(traces), "dp.st.dev....56789"), "token should be redacted")
+	assert.False(t, strings.Contains(string(traces), "abcdefghijklmnopqrstuvwxyz"), "token should not be exported")
+
+	metrics, err := json.Marshal(c.requests["/v1/metrics"][0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(metrics), `"name":"doppler.cache.hits"`)
+	assert.Contains(t, string(metrics), `"asInt":"3"`)
+
+	// export only happens once
+	Shutdown(0)
+	assert.Len(t, c.requests["/v1/traces"], 1)
+}
+
+func TestSignalSpecificEndpoints(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318/")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "http://localhost:9000/metrics")
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "2500")
+
+	config, err := loadExporterConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:4318/v1/traces", config.tracesEndpoint)
+	assert.Equal(t, "http://localhost:9000/metrics", config.metricsEndpoint)
+	assert.Equal(t, int64(2500), config.timeout.Milliseconds())
+
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "invalid")
+	_, err = loadExporterConfig()
+	assert.Error(t, err)
+}
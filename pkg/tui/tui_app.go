@@ -16,9 +16,6 @@ limitations under the License.
 package tui
 
 import (
-	"log"
-	"os"
-
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/tui/common"
 	"github.com/DopplerHQ/cli/pkg/tui/gui"
@@ -33,17 +30,17 @@ type App struct {
 func Start(opts models.ScopedOptions) {
 	cmn, err := common.NewCommon(opts)
 	if err != nil {
-		log.Fatal(err)
+		utils.HandleError(err)
 	}
 
 	if cmn.Opts.EnclaveProject.Value == "" {
 		utils.Log("You must run `doppler setup` prior to launching the TUI")
-		os.Exit(1)
+		utils.Exit(1)
 	}
 
 	gui, err := gui.NewGui(cmn)
 	if err != nil {
-		log.Fatal(err)
+		utils.HandleError(err)
 	}
 
 	app := &App{
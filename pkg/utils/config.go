@@ -24,5 +24,26 @@ var DebugTUI = false
 // Silent whether we should display Info messages
 var Silent = false
 
-// OutputJSON whether to print OutputJSON
+// OutputJSON whether to print structured output (json, yaml, or template) rather than a table
 var OutputJSON = false
+
+// OutputFormat the format of command output
+var OutputFormat = OutputFormatTable
+
+// OutputTemplate the Go template used when the output format is template
+var OutputTemplate = ""
+
+// OutputColumns the table columns to display. When empty, each table's default columns are displayed
+var OutputColumns []string
+
+// OutputSortBy the table column to sort rows by
+var OutputSortBy = ""
+
+// OutputReverse whether to reverse the order of table rows
+var OutputReverse = false
+
+// LogFormat the format of messages logged to stderr
+var LogFormat = LogFormatText
+
+// CommandPath the path of the command being executed (e.g. "doppler run"), used to annotate logs
+var CommandPath = ""
@@ -0,0 +1,127 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Exit codes. These are stable and documented in EXIT_CODES.md
+const (
+	ExitCodeSuccess      = 0
+	ExitCodeError        = 1
+	ExitCodeValidation   = 2
+	ExitCodeAuth         = 3
+	ExitCodeNotFound     = 4
+	ExitCodeNetwork      = 5
+	ExitCodeFallbackUsed = 6
+)
+
+// exitCodeNames the machine-readable name of each exit code, used in JSON error output
+var exitCodeNames = map[int]string{
+	ExitCodeSuccess:      "success",
+	ExitCodeError:        "error",
+	ExitCodeValidation:   "validation",
+	ExitCodeAuth:         "auth",
+	ExitCodeNotFound:     "not_found",
+	ExitCodeNetwork:      "network",
+	ExitCodeFallbackUsed: "fallback_used",
+}
+
+// HTTPError an error response from the Doppler API
+type HTTPError interface {
+	error
+	HTTPStatus() int
+	HTTPRequestID() string
+}
+
+// ExitCodeName the machine-readable name of the exit code
+func ExitCodeName(exitCode int) string {
+	if name, ok := exitCodeNames[exitCode]; ok {
+		return name
+	}
+	return exitCodeNames[ExitCodeError]
+}
+
+// ExitCodeForError the exit code that best describes the error
+func ExitCodeForError(e error) int {
+	var httpErr HTTPError
+	if errors.As(e, &httpErr) {
+		return ExitCodeForHTTPStatus(httpErr.HTTPStatus())
+	}
+
+	// url errors wrap the underlying request error, except when the url couldn't be parsed
+	var urlErr *url.Error
+	if errors.As(e, &urlErr) {
+		if urlErr.Op == "parse" {
+			return ExitCodeError
+		}
+		return ExitCodeNetwork
+	}
+	var netErr net.Error
+	if errors.As(e, &netErr) {
+		return ExitCodeNetwork
+	}
+
+	return ExitCodeError
+}
+
+// ExitCodeForHTTPStatus the exit code for a failed request with the specified HTTP status
+func ExitCodeForHTTPStatus(statusCode int) int {
+	switch {
+	case statusCode == 400 || statusCode == 422:
+		return ExitCodeValidation
+	case statusCode == 401 || statusCode == 403:
+		return ExitCodeAuth
+	case statusCode == 404:
+		return ExitCodeNotFound
+	case statusCode == 429 || statusCode >= 500:
+		return ExitCodeNetwork
+	}
+	return ExitCodeError
+}
+
+var exitHandlers []func(exitCode int)
+var exitHandlersMutex sync.Mutex
+
+// RegisterExitHandler registers a function to run before the process exits via Exit
+func RegisterExitHandler(handler func(exitCode int)) {
+	exitHandlersMutex.Lock()
+	defer exitHandlersMutex.Unlock()
+	exitHandlers = append(exitHandlers, handler)
+}
+
+// RunExitHandlers runs all registered exit handlers. Each handler runs at most once.
+func RunExitHandlers(exitCode int) {
+	exitHandlersMutex.Lock()
+	handlers := exitHandlers
+	exitHandlers = nil
+	exitHandlersMutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(exitCode)
+	}
+}
+
+// Exit runs all registered exit handlers and then exits the process
+func Exit(exitCode int) {
+	RunExitHandlers(exitCode)
+	os.Exit(exitCode)
+}
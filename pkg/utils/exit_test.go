@@ -0,0 +1,85 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testHTTPError struct {
+	status    int
+	requestID string
+}
+
+func (e testHTTPError) Error() string         { return fmt.Sprintf("Request failed with HTTP %d", e.status) }
+func (e testHTTPError) HTTPStatus() int       { return e.status }
+func (e testHTTPError) HTTPRequestID() string { return e.requestID }
+
+func TestExitCodeForError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"generic", errors.New("failed"), ExitCodeError},
+		{"bad request", testHTTPError{status: 400}, ExitCodeValidation},
+		{"unprocessable", testHTTPError{status: 422}, ExitCodeValidation},
+		{"unauthorized", testHTTPError{status: 401}, ExitCodeAuth},
+		{"forbidden", testHTTPError{status: 403}, ExitCodeAuth},
+		{"not found", testHTTPError{status: 404}, ExitCodeNotFound},
+		{"rate limited", testHTTPError{status: 429}, ExitCodeNetwork},
+		{"server error", testHTTPError{status: 503}, ExitCodeNetwork},
+		{"conflict", testHTTPError{status: 409}, ExitCodeError},
+		{"wrapped", fmt.Errorf("unable to fetch: %w", testHTTPError{status: 404}), ExitCodeNotFound},
+		{"connection refused", &url.Error{Op: "Get", URL: "https://api.doppler.com", Err: syscall.ECONNREFUSED}, ExitCodeNetwork},
+		{"invalid url", &url.Error{Op: "parse", URL: "://", Err: errors.New("missing protocol scheme")}, ExitCodeError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExitCodeForError(tc.err))
+		})
+	}
+}
+
+func TestFormatJSONError(t *testing.T) {
+	line := formatJSONError(testHTTPError{status: 401, requestID: "req_123"}, ExitCodeAuth, []string{"Unable to fetch secrets"})
+
+	var parsed map[string]interface{}
+	assert.NoError(t, json.Unmarshal(line, &parsed))
+	assert.Equal(t, "auth", parsed["code"])
+	assert.Equal(t, float64(ExitCodeAuth), parsed["exit_code"])
+	assert.Equal(t, "Request failed with HTTP 401", parsed["message"])
+	assert.Equal(t, "Request failed with HTTP 401", parsed["error"])
+	assert.Equal(t, []interface{}{"Unable to fetch secrets"}, parsed["details"])
+	assert.Equal(t, float64(401), parsed["http_status"])
+	assert.Equal(t, "req_123", parsed["request_id"])
+
+	line = formatJSONError(errors.New("failed"), ExitCodeError, nil)
+	parsed = map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(line, &parsed))
+	assert.Equal(t, "error", parsed["code"])
+	assert.NotContains(t, parsed, "details")
+	assert.NotContains(t, parsed, "http_status")
+	assert.NotContains(t, parsed, "request_id")
+}
@@ -45,3 +45,20 @@ func CreateNamedPipe(path string, mode uint32) error {
 	// only available while the writer (i.e. this program) is alive
 	return syscall.Mkfifo(path, mode)
 }
+
+// SameFilesystem whether both paths are on the same filesystem (i.e. device)
+func SameFilesystem(a string, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	statA, okA := infoA.Sys().(*syscall.Stat_t)
+	statB, okB := infoB.Sys().(*syscall.Stat_t)
+	if !okA || !okB {
+		return false
+	}
+
+	return statA.Dev == statB.Dev
+}
@@ -15,7 +15,10 @@ limitations under the License.
 */
 package utils
 
-import "errors"
+import (
+	"errors"
+	"path/filepath"
+)
 
 const SupportsNamedPipes = false
 
@@ -26,3 +29,8 @@ func FileOwnership(path string) (int, int, error) {
 func CreateNamedPipe(path string, mode uint32) error {
 	return errors.New("This platform does not support named pipes")
 }
+
+// SameFilesystem whether both paths are on the same volume
+func SameFilesystem(a string, b string) bool {
+	return filepath.VolumeName(a) == filepath.VolumeName(b)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// FileLockTimeout the max time to wait to acquire a file lock
+var FileLockTimeout = 10 * time.Second
+
+const fileLockRetryInterval = 10 * time.Millisecond
+
+var errLockHeld = errors.New("lock is held by another process")
+
+// FileLock an advisory lock on a file, which coordinates access between processes
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile acquires an advisory lock on the file at path, creating it if necessary. Multiple processes may
+// hold a shared lock at once, while an exclusive lock can only be held by one. The lock is released
+// when Unlock is called or the process exits.
+func LockFile(path string, exclusive bool) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(FileLockTimeout)
+	for {
+		err := lockFile(file, exclusive)
+		if err == nil {
+			return &FileLock{file: file}, nil
+		}
+		if err != errLockHeld || time.Now().After(deadline) {
+			_ = file.Close()
+			if err == errLockHeld {
+				return nil, fmt.Errorf("timed out waiting for lock on %s", path)
+			}
+			return nil, err
+		}
+		time.Sleep(fileLockRetryInterval)
+	}
+}
+
+// Unlock releases the lock
+func (l *FileLock) Unlock() error {
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFile(t *testing.T) {
+	origTimeout := FileLockTimeout
+	FileLockTimeout = 50 * time.Millisecond
+	defer func() { FileLockTimeout = origTimeout }()
+
+	path := filepath.Join(t.TempDir(), "test.lock")
+
+	// shared locks can be held concurrently
+	shared1, err := LockFile(path, false)
+	require.NoError(t, err)
+	shared2, err := LockFile(path, false)
+	require.NoError(t, err)
+
+	_, err = LockFile(path, true)
+	assert.Error(t, err)
+
+	require.NoError(t, shared1.Unlock())
+	require.NoError(t, shared2.Unlock())
+
+	// exclusive locks can't be held concurrently
+	exclusive, err := LockFile(path, true)
+	require.NoError(t, err)
+	_, err = LockFile(path, false)
+	assert.Error(t, err)
+
+	// a waiting lock is acquired once the lock is released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = exclusive.Unlock()
+	}()
+	shared, err := LockFile(path, false)
+	require.NoError(t, err)
+	require.NoError(t, shared.Unlock())
+}
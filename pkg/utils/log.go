@@ -17,12 +17,33 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/gookit/color.v1"
 )
 
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogFormats the supported log formats
+var LogFormats = []string{LogFormatText, LogFormatJSON}
+
+// LogFields structured data attached to a log message
+type LogFields map[string]interface{}
+
+const (
+	logLevelDebug = "debug"
+	logLevelInfo  = "info"
+	logLevelWarn  = "warn"
+	logLevelError = "error"
+)
+
 // Print output to stdout
 func Print(info string) {
 	fmt.Println(info)
@@ -35,11 +56,26 @@ func PrintWarning(s string) {
 
 // Log info message to stderr
 func Log(info string) {
+	LogWithFields(info, nil)
+}
+
+// LogWithFields logs an info message to stderr. Fields are only output when using the JSON log format.
+func LogWithFields(info string, fields LogFields) {
+	if LogFormat == LogFormatJSON {
+		writeJSONLog(logLevelInfo, info, fields)
+		return
+	}
+
 	fmt.Fprintln(os.Stderr, info)
 }
 
 // LogWarning message to stderr
 func LogWarning(s string) {
+	if LogFormat == LogFormatJSON {
+		writeJSONLog(logLevelWarn, s, nil)
+		return
+	}
+
 	fmt.Fprintln(os.Stderr, color.Yellow.Render("Warning:"), s)
 }
 
@@ -56,7 +92,17 @@ func CanLogInfo() bool {
 
 // LogDebug prints a debug message to stderr
 func LogDebug(s string) {
+	LogDebugWithFields(s, nil)
+}
+
+// LogDebugWithFields prints a debug message to stderr. Fields are only output when using the JSON log format.
+func LogDebugWithFields(s string, fields LogFields) {
 	if CanLogDebug() {
+		if LogFormat == LogFormatJSON {
+			writeJSONLog(logLevelDebug, s, fields)
+			return
+		}
+
 		// log debug messages to stderr
 		fmt.Fprintln(os.Stderr, color.Blue.Render("Debug:"), s)
 	}
@@ -74,19 +120,33 @@ func CanLogDebug() bool {
 	return Debug
 }
 
-// HandleError prints the error and exits with code 1
+// HandleError prints the error and exits with the exit code that best describes it
 func HandleError(e error, messages ...string) {
-	ErrExit(e, 1, messages...)
+	ErrExit(e, ExitCodeForError(e), messages...)
 }
 
 // ErrExit prints the error and exits with the specified code
 func ErrExit(e error, exitCode int, messages ...string) {
+	var lines []string
+	for _, message := range messages {
+		if message != "" {
+			lines = append(lines, message)
+		}
+	}
+
 	if OutputJSON {
-		resp, err := json.Marshal(map[string]string{"error": e.Error()})
-		if err != nil {
-			panic(err)
+		fmt.Fprintln(os.Stderr, string(formatJSONError(e, exitCode, lines)))
+	} else if LogFormat == LogFormatJSON {
+		fields := errorFields(e, exitCode)
+		if len(lines) > 0 {
+			fields["details"] = strings.Join(lines, "\n")
 		}
-		fmt.Fprintln(os.Stderr, string(resp))
+
+		message := "Doppler Error"
+		if e != nil {
+			message = e.Error()
+		}
+		writeJSONLog(logLevelError, message, fields)
 	} else {
 		if len(messages) > 0 && messages[0] != "" {
 			fmt.Fprintln(os.Stderr, messages[0])
@@ -103,9 +163,80 @@ func ErrExit(e error, exitCode int, messages ...string) {
 		}
 	}
 
-	os.Exit(exitCode)
+	Exit(exitCode)
+}
+
+// formatJSONError formats the error as a JSON object. The "error" field is retained for backwards compatibility.
+func formatJSONError(e error, exitCode int, details []string) []byte {
+	message := "Doppler Error"
+	if e != nil {
+		message = e.Error()
+	}
+
+	resp := errorFields(e, exitCode)
+	resp["error"] = message
+	resp["message"] = message
+	if len(details) > 0 {
+		resp["details"] = details
+	}
+
+	bytes, err := json.Marshal(resp)
+	if err != nil {
+		panic(err)
+	}
+	return bytes
+}
+
+// errorFields the exit code of the error, along with the HTTP status and request ID when the error came from the API
+func errorFields(e error, exitCode int) LogFields {
+	fields := LogFields{
+		"code":      ExitCodeName(exitCode),
+		"exit_code": exitCode,
+	}
+
+	var httpErr HTTPError
+	if errors.As(e, &httpErr) {
+		fields["http_status"] = httpErr.HTTPStatus()
+		if requestID := httpErr.HTTPRequestID(); requestID != "" {
+			fields["request_id"] = requestID
+		}
+	}
+	return fields
 }
 
 func printError(e error) {
+	if LogFormat == LogFormatJSON {
+		writeJSONLog(logLevelError, fmt.Sprintf("%v", e), nil)
+		return
+	}
+
 	fmt.Fprintln(os.Stderr, color.Red.Render("Doppler Error:"), e)
 }
+
+func writeJSONLog(level string, message string, fields LogFields) {
+	fmt.Fprintln(os.Stderr, string(formatJSONLog(level, message, fields, time.Now())))
+}
+
+// formatJSONLog formats a log message as a single line of JSON. Auth tokens are redacted.
+func formatJSONLog(level string, message string, fields LogFields, t time.Time) []byte {
+	line := map[string]interface{}{}
+	for key, value := range fields {
+		if s, ok := value.(string); ok {
+			value = RedactAuthTokens(s)
+		}
+		line[key] = value
+	}
+	line["level"] = level
+	line["time"] = t.UTC().Format(time.RFC3339Nano)
+	line["msg"] = RedactAuthTokens(message)
+	if CommandPath != "" {
+		line["command"] = CommandPath
+	}
+
+	resp, err := json.Marshal(line)
+	if err != nil {
+		// fields contained a value that can't be marshaled; log the message alone
+		resp, _ = json.Marshal(map[string]string{"level": level, "time": t.UTC().Format(time.RFC3339Nano), "msg": RedactAuthTokens(message)})
+	}
+	return resp
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatJSONLog(t *testing.T) {
+	CommandPath = "doppler run"
+	defer func() { CommandPath = "" }()
+
+	token := "dp.st.dev.abcdefghijklmnopqrstuvwxyz0123456789"
+	timestamp := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	line := formatJSONLog(logLevelInfo, "Request failed using "+token, LogFields{"request_id": "abc", "status_code": 500, "attempt": 2, "token": token}, timestamp)
+
+	var parsed map[string]interface{}
+	assert.NoError(t, json.Unmarshal(line, &parsed))
+	assert.Equal(t, "info", parsed["level"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", parsed["time"])
+	assert.Equal(t, "doppler run", parsed["command"])
+	assert.Equal(t, "abc", parsed["request_id"])
+	assert.Equal(t, float64(500), parsed["status_code"])
+	assert.Equal(t, float64(2), parsed["attempt"])
+	assert.Equal(t, "Request failed using "+RedactAuthToken(token), parsed["msg"])
+	assert.Equal(t, RedactAuthToken(token), parsed["token"])
+	assert.NotContains(t, string(line), token)
+	assert.NotContains(t, string(line), "\n")
+}
+
+func TestFormatJSONLogReservedFields(t *testing.T) {
+	line := formatJSONLog(logLevelWarn, "message", LogFields{"level": "info", "msg": "overwritten"}, time.Now())
+
+	var parsed map[string]interface{}
+	assert.NoError(t, json.Unmarshal(line, &parsed))
+	assert.Equal(t, "warn", parsed["level"])
+	assert.Equal(t, "message", parsed["msg"])
+	assert.NotContains(t, parsed, "command")
+}
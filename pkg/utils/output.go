@@ -0,0 +1,58 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	OutputFormatTable    = "table"
+	OutputFormatJSON     = "json"
+	OutputFormatYAML     = "yaml"
+	OutputFormatCSV      = "csv"
+	OutputFormatTSV      = "tsv"
+	OutputFormatTemplate = "template"
+)
+
+// OutputFormats the supported output formats. The template format is specified as template=<template>
+var OutputFormats = []string{OutputFormatTable, OutputFormatJSON, OutputFormatYAML, OutputFormatCSV, OutputFormatTSV, OutputFormatTemplate}
+
+// ParseOutputFormat parses the value of --output (e.g. "yaml" or "template={{.name}}"), returning the format and template
+func ParseOutputFormat(value string) (string, string, error) {
+	format, template, hasTemplate := strings.Cut(value, "=")
+	if !Contains(OutputFormats, format) {
+		return "", "", fmt.Errorf("Invalid output format %q. Valid formats are %s", format, strings.Join(OutputFormats, ", "))
+	}
+
+	if format == OutputFormatTemplate {
+		if template == "" {
+			return "", "", fmt.Errorf("a template must be specified when using the template output format (e.g. --output='template={{.name}}')")
+		}
+		return format, template, nil
+	}
+
+	if hasTemplate {
+		return "", "", fmt.Errorf("the %s output format does not accept a value", format)
+	}
+	return format, "", nil
+}
+
+// IsStructuredOutputFormat whether the format outputs structured data rather than a table
+func IsStructuredOutputFormat(format string) bool {
+	return format == OutputFormatJSON || format == OutputFormatYAML || format == OutputFormatTemplate
+}
@@ -0,0 +1,43 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	format, template, err := ParseOutputFormat("yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, OutputFormatYAML, format)
+	assert.Equal(t, "", template)
+
+	// the template may itself contain =
+	format, template, err = ParseOutputFormat("template={{if eq .name \"a=b\"}}{{.name}}{{end}}")
+	assert.NoError(t, err)
+	assert.Equal(t, OutputFormatTemplate, format)
+	assert.Equal(t, "{{if eq .name \"a=b\"}}{{.name}}{{end}}", template)
+
+	for _, value := range []string{"xml", "template", "template=", "csv=x", ""} {
+		_, _, err = ParseOutputFormat(value)
+		assert.Error(t, err, value)
+	}
+
+	assert.True(t, IsStructuredOutputFormat(OutputFormatTemplate))
+	assert.False(t, IsStructuredOutputFormat(OutputFormatTSV))
+}
@@ -24,6 +24,7 @@ import (
 	"os/signal"
 	"os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -204,7 +205,11 @@ func IsProcessRunning(p *os.Process) bool {
 func RequireValue(name string, value string) {
 	value = strings.TrimSpace(value)
 	if value == "" {
-		HandleError(fmt.Errorf("you must provide a %s", name))
+		exitCode := ExitCodeValidation
+		if name == "token" {
+			exitCode = ExitCodeAuth
+		}
+		ErrExit(fmt.Errorf("you must provide a %s", name), exitCode)
 	}
 }
 
@@ -323,7 +328,7 @@ func ConfirmationPrompt(message string, defaultValue bool) bool {
 	if err != nil {
 		if err == terminal.InterruptErr {
 			Log("Exiting")
-			os.Exit(1)
+			Exit(1)
 		}
 		HandleError(err)
 	}
@@ -346,7 +351,7 @@ func SelectPrompt(message string, options []string, defaultOption string) string
 	if err != nil {
 		if err == terminal.InterruptErr {
 			Log("Exiting")
-			os.Exit(1)
+			Exit(1)
 		}
 		HandleError(err)
 	}
@@ -431,6 +436,13 @@ func RedactAuthToken(token string) string {
 	return "[REDACTED]"
 }
 
+var authTokenRegex = regexp.MustCompile(`dp\.(st|pt|ct|sa|said|scim|audit)\.[A-Za-z0-9_.\-]+`)
+
+// RedactAuthTokens redacts any Doppler auth tokens contained in the string
+func RedactAuthTokens(s string) string {
+	return authTokenRegex.ReplaceAllStringFunc(s, RedactAuthToken)
+}
+
 func Contains[T comparable](s []T, e T) bool {
 	for _, v := range s {
 		if v == e {
@@ -109,4 +109,14 @@ beforeEach
 "$DOPPLER_BINARY" run --fallback ./nonexistent-fallback.json -- echo -n > /dev/null || (echo "ERROR: run w/ valid cache is not ignoring nonexistent fallback file" && exit 1)
 rm -f fallback.json nonexistent-fallback.json
 
+beforeEach
+
+# test 'run' respects --fallback-max-age
+"$DOPPLER_BINARY" run --fallback ./fallback.json -- echo -n > /dev/null
+sleep 2
+"$DOPPLER_BINARY" run --fallback ./fallback.json --fallback-only --fallback-max-age=1h -- echo -n > /dev/null || (echo "ERROR: --fallback-max-age rejected a current fallback file" && exit 1)
+"$DOPPLER_BINARY" run --fallback ./fallback.json --fallback-only --fallback-max-age=1s -- echo -n > /dev/null 2>&1 && (echo "ERROR: --fallback-max-age flag is not respected" && exit 1)
+"$DOPPLER_BINARY" run --fallback ./fallback.json --fallback-only --fallback-max-age=1s --fallback-stale=warn -- echo -n > /dev/null 2>&1 || (echo "ERROR: --fallback-stale flag is not respected" && exit 1)
+rm -f fallback.json
+
 afterAll